@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/Marseek/tfs-go-hw/course/handlers"
 	pkgpostgres "github.com/Marseek/tfs-go-hw/course/pkg/postgres"
+	"github.com/Marseek/tfs-go-hw/course/pkg/robotpb"
 	"github.com/Marseek/tfs-go-hw/course/repository"
 	"github.com/Marseek/tfs-go-hw/course/service"
 	"github.com/go-chi/chi/v5"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -29,7 +35,21 @@ func main() {
 
 	rep := repository.NewRepository(pool, logger)
 	serv := service.NewRobotService(rep, logger)
+	serv.SetReportsDir(os.Getenv("OPTIMIZE_REPORTS_DIR"))
+	go serv.WatchInstruments(time.Hour)
+	go serv.RunSchedules()
+	go serv.RunReports()
+	go repository.NewDispatcher(rep, logger).Run(time.Second)
 	handler := handlers.NewParamsSetter(logger, serv)
+	handler.SetSignalSecret(os.Getenv("SIGNALS_SECRET"))
+	err = setJWTKeys(handler, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	err = serv.LoadRevokedTokens()
+	if err != nil {
+		logger.Fatal("Can't load revoked tokens: ", err)
+	}
 	// query := `TRUNCATE TABLE orders`
 	// pool.Exec(context.Background(), query)
 
@@ -40,6 +60,23 @@ func main() {
 		logger.Fatal(http.ListenAndServe(":5000", r))
 	}()
 
+	// gRPC API управления роботом для Telegram бота
+	// По умолчанию доступен только с этой машины, адрес для других сетей задается ROBOT_GRPC_ADDR
+	grpcAddr := os.Getenv("ROBOT_GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = "127.0.0.1:5006"
+	}
+	listen, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	control := handlers.NewRobotControl(handler)
+	grpcSrv := grpc.NewServer(control.ServerOptions()...)
+	robotpb.RegisterRobotControlServer(grpcSrv, control)
+	go func() {
+		logger.Fatal(grpcSrv.Serve(listen))
+	}()
+
 	// Gracefull shutdown
 	sigquit := make(chan os.Signal, 1)
 	signal.Ignore(syscall.SIGHUP, syscall.SIGPIPE)
@@ -51,7 +88,42 @@ func main() {
 		if err := mainSrv.Shutdown(context.Background()); err != nil {
 			log.Println("Can't shutdown main server: ", err.Error())
 		}
+		grpcSrv.GracefulStop()
 		stopAppCh <- struct{}{}
 	}()
 	<-stopAppCh
 }
+
+// setJWTKeys настраивает подпись токенов API: JWT_PRIVATE_KEY - путь к закрытому ключу RSA в PEM (RS256),
+// иначе JWT_SECRET (HS256, не короче 32 байт). JWT_ACCESS_TTL и JWT_REFRESH_TTL - время жизни токенов, например 15m и 168h
+func setJWTKeys(handler *handlers.SetParams, logger log.FieldLogger) error {
+	switch {
+	case os.Getenv("JWT_PRIVATE_KEY") != "":
+		key, err := os.ReadFile(os.Getenv("JWT_PRIVATE_KEY"))
+		if err != nil {
+			return err
+		}
+		if err = handler.SetJWTRSAKey(key); err != nil {
+			return fmt.Errorf("bad JWT_PRIVATE_KEY: %w", err)
+		}
+	case os.Getenv("JWT_SECRET") != "":
+		if err := handler.SetJWTSecret(os.Getenv("JWT_SECRET")); err != nil {
+			return err
+		}
+	default:
+		logger.Warn("JWT_SECRET is not set, tokens are signed with a random secret and become invalid after restart")
+	}
+
+	var ttl [2]time.Duration
+	for i, key := range []string{"JWT_ACCESS_TTL", "JWT_REFRESH_TTL"} {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("bad %s: %q", key, v)
+			}
+			ttl[i] = d
+		}
+	}
+	handler.SetTokenTTL(ttl[0], ttl[1])
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errUnauthorized = errors.New("not logged in or the token has expired, run 'robotctl login'")
+	errForbidden    = errors.New("the user's role doesn't allow this request")
+)
+
+// client - клиент REST API робота. Токены после входа сохраняются в кэше пользователя
+// отдельно для каждого адреса API, поэтому login нужен один раз, пока действует refresh токен
+type client struct {
+	addr   string
+	http   *http.Client
+	cache  string // файл с токенами, пустой - токены не сохраняются
+	tokens tokens
+}
+
+// tokens - access и refresh токены, как их возвращают /login и /refresh
+type tokens struct {
+	Access  string `json:"access_token"`
+	Refresh string `json:"refresh_token"`
+}
+
+func newClient(addr string) *client {
+	c := &client{addr: strings.TrimRight(addr, "/"), http: &http.Client{}}
+	if dir, err := os.UserCacheDir(); err == nil {
+		c.cache = filepath.Join(dir, "robotctl", "tokens.json")
+	}
+	c.tokens = c.loadTokens()[c.addr]
+	return c
+}
+
+func (c *client) loadTokens() map[string]tokens {
+	res := make(map[string]tokens)
+	if c.cache == "" {
+		return res
+	}
+	if b, err := os.ReadFile(c.cache); err == nil {
+		_ = json.Unmarshal(b, &res)
+	}
+	return res
+}
+
+// saveTokens сохраняет токены адреса, пустые токены удаляют его из кэша
+func (c *client) saveTokens(t tokens) error {
+	c.tokens = t
+	if c.cache == "" {
+		return nil
+	}
+	all := c.loadTokens()
+	all[c.addr] = t
+	if t == (tokens{}) {
+		delete(all, c.addr)
+	}
+	b, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(c.cache), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(c.cache, b, 0o600)
+}
+
+func (c *client) post(ctx context.Context, path, auth string, in interface{}) (*http.Response, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", "Bearer "+auth)
+	}
+	return c.http.Do(req)
+}
+
+// getTokens получает токены через /login или /refresh и сохраняет их
+func (c *client) getTokens(ctx context.Context, path string, in interface{}) error {
+	resp, err := c.post(ctx, path, "", in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed: %s", strings.TrimPrefix(path, "/"), strings.TrimSpace(string(data)))
+	}
+	var t tokens
+	if err = json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	return c.saveTokens(t)
+}
+
+func (c *client) login(ctx context.Context, user, passwd string) error {
+	return c.getTokens(ctx, "/login", map[string]string{"login": user, "passwd": passwd})
+}
+
+// logout отзывает токены на сервере и удаляет их из кэша
+func (c *client) logout(ctx context.Context) error {
+	if c.tokens == (tokens{}) {
+		return errUnauthorized
+	}
+	resp, err := c.post(ctx, "/logout", c.tokens.Access, map[string]string{"refresh_token": c.tokens.Refresh})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// 401 - токены уже истекли или отозваны, их все равно нужно удалить из кэша
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		text, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+	return c.saveTokens(tokens{})
+}
+
+// relogin обновляет истекший access токен по refresh токену, а если это не удалось -
+// входит заново, если логин и пароль заданы переменными ROBOTCTL_USER и ROBOTCTL_PASSWORD
+func (c *client) relogin(ctx context.Context) bool {
+	if c.tokens.Refresh != "" && c.getTokens(ctx, "/refresh", map[string]string{"refresh_token": c.tokens.Refresh}) == nil {
+		return true
+	}
+	user, passwd := os.Getenv("ROBOTCTL_USER"), os.Getenv("ROBOTCTL_PASSWORD")
+	return user != "" && passwd != "" && c.login(ctx, user, passwd) == nil
+}
+
+func (c *client) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+"/api"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.tokens.Access != "" {
+		req.Header.Set("Authorization", "Bearer "+c.tokens.Access)
+	}
+	return c.http.Do(req)
+}
+
+// do выполняет запрос к /api. Json ответ разбирается в out, текстовый ответ возвращается строкой.
+// Ответ с кодом ошибки возвращается как error с текстом ответа
+func (c *client) do(ctx context.Context, method, path string, in, out interface{}) (string, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return "", err
+		}
+	}
+	resp, err := c.send(ctx, method, path, body)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.relogin(ctx) {
+		resp.Body.Close()
+		resp, err = c.send(ctx, method, path, body)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return "", errUnauthorized
+	case http.StatusForbidden:
+		return "", errForbidden
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		return "", json.Unmarshal(data, out)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// stream читает Server-Sent Events из /api/stream и передает их в handle, пока не отменен ctx
+func (c *client) stream(ctx context.Context, query string, handle func(event string, data json.RawMessage) error) error {
+	resp, err := c.send(ctx, "GET", "/stream"+query, nil)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.relogin(ctx) {
+		resp.Body.Close()
+		resp, err = c.send(ctx, "GET", "/stream"+query, nil)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		text, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var event string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err = handle(event, json.RawMessage(strings.TrimPrefix(line, "data: "))); err != nil {
+				return err
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
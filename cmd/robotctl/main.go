@@ -0,0 +1,260 @@
+// robotctl - консольный клиент REST API робота.
+//
+//	robotctl [-addr http://localhost:5000] [-o table|json] <command> [flags]
+//
+// Команды: login, logout, set, start, stop, status, trades, stats, watch. Флаги команды - robotctl <command> -h
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *client, out *output, args []string) error
+}
+
+var commands = map[string]command{
+	"login":  {"Log in and cache the tokens", runLogin},
+	"logout": {"Revoke and forget the cached tokens", runLogout},
+	"set":    {"Set trade parameters", runSet},
+	"start":  {"Send the signal to start", runStart},
+	"stop":   {"Send the signal to stop", runStop},
+	"status": {"Show robot state and parameters", runStatus},
+	"trades": {"List trades", runTrades},
+	"stats":  {"Show P&L, win rate and open positions", runStats},
+	"watch":  {"Stream ticks, state, events and P&L", runWatch},
+}
+
+var commandOrder = []string{"login", "logout", "set", "start", "stop", "status", "trades", "stats", "watch"}
+
+func main() {
+	flag.Usage = usage
+	addr := flag.String("addr", envOr("ROBOT_URL", "http://localhost:5000"), "robot API address")
+	format := flag.String("o", "table", "output format: table or json")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "output format must be 'table' or 'json'")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	out := &output{w: os.Stdout, json: *format == "json"}
+	if err := cmd.run(ctx, newClient(*addr), out, flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: robotctl [flags] <command> [command flags]\n\nCommands:\n")
+	for _, name := range commandOrder {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func runLogin(ctx context.Context, c *client, out *output, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	user := fs.String("user", os.Getenv("ROBOTCTL_USER"), "login")
+	passwd := fs.String("password", os.Getenv("ROBOTCTL_PASSWORD"), "password, asked on stdin if empty")
+	_ = fs.Parse(args)
+	if *user == "" {
+		return errors.New("'-user' is required")
+	}
+	if *passwd == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		*passwd = strings.TrimSpace(line)
+	}
+	if err := c.login(ctx, *user, *passwd); err != nil {
+		return err
+	}
+	out.message("Logged in as " + *user)
+	return nil
+}
+
+func runLogout(ctx context.Context, c *client, out *output, args []string) error {
+	if err := c.logout(ctx); err != nil {
+		return err
+	}
+	out.message("Logged out")
+	return nil
+}
+
+// runSet накладывает заданные флаги на текущие параметры робота, потому что /api/set заменяет их все
+func runSet(ctx context.Context, c *client, out *output, args []string) error {
+	fs := flag.NewFlagSet("set", flag.ExitOnError)
+	file := fs.String("file", "", "json file with all parameters, as for POST /api/set")
+	ticker := fs.String("ticker", "", "instrument")
+	side := fs.String("side", "", "buy or sell")
+	size := fs.Int("size", 0, "order size")
+	profit := fs.Float64("profit", 0, "take-profit and stop-loss distance")
+	_ = fs.Parse(args)
+
+	var opts domain.Options
+	if *file != "" {
+		b, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(b, &opts); err != nil {
+			return fmt.Errorf("can't parse %s: %w", *file, err)
+		}
+	} else {
+		var status domain.RobotStatus
+		if _, err := c.do(ctx, "GET", "/status", nil, &status); err != nil {
+			return err
+		}
+		opts = status.Params
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "ticker":
+			opts.Ticker = strings.ToUpper(*ticker)
+		case "side":
+			opts.Side = *side
+		case "size":
+			opts.Size = *size
+		case "profit":
+			opts.Profit = float32(*profit)
+		}
+	})
+	text, err := c.do(ctx, "POST", "/set", opts, nil)
+	if err != nil {
+		return err
+	}
+	out.message(text)
+	return nil
+}
+
+func runStart(ctx context.Context, c *client, out *output, args []string) error {
+	text, err := c.do(ctx, "POST", "/start", nil, nil)
+	if err != nil {
+		return err
+	}
+	out.message(text)
+	return nil
+}
+
+func runStop(ctx context.Context, c *client, out *output, args []string) error {
+	text, err := c.do(ctx, "POST", "/stop", nil, nil)
+	if err != nil {
+		return err
+	}
+	out.message(text)
+	return nil
+}
+
+func runStatus(ctx context.Context, c *client, out *output, args []string) error {
+	var status domain.RobotStatus
+	if _, err := c.do(ctx, "GET", "/status", nil, &status); err != nil {
+		return err
+	}
+	return out.status(status)
+}
+
+// tradesQuery - параметры выборки сделок для trades и stats
+func tradesQuery(fs *flag.FlagSet, args []string) (string, error) {
+	from := fs.String("from", "", "start of the period, RFC3339")
+	to := fs.String("to", "", "end of the period, RFC3339")
+	since := fs.Duration("since", 0, "period up to now, e.g. 24h or 168h; default - since midnight UTC")
+	ticker := fs.String("ticker", "", "instrument, default - all")
+	_ = fs.Parse(args)
+
+	q := url.Values{}
+	if *since > 0 {
+		if *from != "" {
+			return "", errors.New("'-since' and '-from' can't be used together")
+		}
+		*from = time.Now().Add(-*since).UTC().Format(time.RFC3339)
+	}
+	for k, v := range map[string]string{"from": *from, "to": *to, "ticker": *ticker} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if len(q) == 0 {
+		return "", nil
+	}
+	return "?" + q.Encode(), nil
+}
+
+func runTrades(ctx context.Context, c *client, out *output, args []string) error {
+	query, err := tradesQuery(flag.NewFlagSet("trades", flag.ExitOnError), args)
+	if err != nil {
+		return err
+	}
+	var trades []domain.Trade
+	if _, err = c.do(ctx, "GET", "/trades"+query, nil, &trades); err != nil {
+		return err
+	}
+	return out.trades(trades)
+}
+
+func runStats(ctx context.Context, c *client, out *output, args []string) error {
+	query, err := tradesQuery(flag.NewFlagSet("stats", flag.ExitOnError), args)
+	if err != nil {
+		return err
+	}
+	var trades []domain.Trade
+	if _, err = c.do(ctx, "GET", "/trades"+query, nil, &trades); err != nil {
+		return err
+	}
+	// Состояние риск-менеджера читает только trader, viewer получает итоги по сделкам без него
+	var risk domain.RiskStatus
+	_, err = c.do(ctx, "GET", "/risk", nil, &risk)
+	if errors.Is(err, errForbidden) {
+		return out.stats(makeStats(trades, nil))
+	}
+	if err != nil {
+		return err
+	}
+	return out.stats(makeStats(trades, &risk))
+}
+
+func runWatch(ctx context.Context, c *client, out *output, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	ticks := fs.String("ticks", "", "instruments separated by commas, default - the robot's instrument")
+	_ = fs.Parse(args)
+	query := ""
+	if *ticks != "" {
+		query = "?" + url.Values{"ticks": {*ticks}}.Encode()
+	}
+	return c.stream(ctx, query, out.streamMessage)
+}
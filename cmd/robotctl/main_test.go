@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// testServer - API робота с одним пользователем jlexie. /login выдает уже истекший access токен,
+// рабочий выдает /refresh. Тело последнего POST /api/set сохраняется в set
+func testServer(t *testing.T, set *domain.Options) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		var u struct{ Login, Passwd string }
+		_ = json.NewDecoder(r.Body).Decode(&u)
+		if u.Login != "jlexie" || u.Passwd != "passwd" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, "Incorrect username or password")
+			return
+		}
+		_, _ = io.WriteString(w, `{"access_token":"expired-token","refresh_token":"refresh-token","token_type":"Bearer","expires_in":900}`)
+	})
+	mux.HandleFunc("/refresh", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.RefreshToken != "refresh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, "Invalid refresh token")
+			return
+		}
+		_, _ = io.WriteString(w, `{"access_token":"secret-token","refresh_token":"refresh-token","token_type":"Bearer","expires_in":900}`)
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "Logged out\n")
+	})
+	auth := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("/api/status", auth(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(domain.RobotStatus{
+			State:  "stopped",
+			Params: domain.Options{Ticker: "PI_XBTUSD", Size: 2, Profit: 5, Side: "buy", Loop: domain.Loop{Cycles: 3}},
+		})
+	}))
+	mux.HandleFunc("/api/set", auth(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(set)
+		_, _ = io.WriteString(w, "Parameters had been set\n")
+	}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLoginAndSet(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("ROBOTCTL_USER", "")
+	var set domain.Options
+	srv := testServer(t, &set)
+	ctx := context.Background()
+	var buf bytes.Buffer
+	out := &output{w: &buf}
+
+	err := runStatus(ctx, newClient(srv.URL), out, nil)
+	assert.Equal(t, errUnauthorized, err)
+
+	err = runLogin(ctx, newClient(srv.URL), out, []string{"-user", "jlexie", "-password", "bad"})
+	assert.EqualError(t, err, "login failed: Incorrect username or password")
+	err = runLogin(ctx, newClient(srv.URL), out, []string{"-user", "jlexie", "-password", "passwd"})
+	assert.NoError(t, err)
+
+	// Токены берутся из кэша новым клиентом, истекший access токен обновляется по refresh токену
+	buf.Reset()
+	err = runSet(ctx, newClient(srv.URL), out, []string{"-size", "4", "-ticker", "pi_ethusd"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Parameters had been set\n", buf.String())
+	assert.Equal(t, domain.Options{Ticker: "PI_ETHUSD", Size: 4, Profit: 5, Side: "buy", Loop: domain.Loop{Cycles: 3}}, set)
+	assert.Equal(t, "secret-token", newClient(srv.URL).tokens.Access)
+
+	err = runLogout(ctx, newClient(srv.URL), out, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tokens{}, newClient(srv.URL).tokens)
+	err = runLogout(ctx, newClient(srv.URL), out, nil)
+	assert.Equal(t, errUnauthorized, err)
+}
+
+func TestMakeStats(t *testing.T) {
+	now := time.Now()
+	trades := []domain.Trade{
+		{Ticker: "PI_XBTUSD", Profit: 10, Time: now},
+		{Ticker: "PI_ETHUSD", Profit: -4, Time: now},
+		{Ticker: "PI_XBTUSD", Profit: 2, Time: now},
+		{Ticker: "PI_XBTUSD", Profit: -1, Time: now},
+	}
+	risk := domain.RiskStatus{DailyPnL: 7, Positions: map[string]int{"PI_XBTUSD": 2, "PI_ETHUSD": 0}}
+
+	s := makeStats(trades, &risk)
+	assert.Equal(t, 4, s.Trades)
+	assert.Equal(t, 2, s.Wins)
+	assert.Equal(t, float32(50), s.WinRate)
+	assert.Equal(t, float32(7), s.PnL)
+	assert.Equal(t, float32(10), s.LargestWin)
+	assert.Equal(t, float32(-4), s.LargestLoss)
+	assert.Equal(t, []domain.InstrumentReport{{Ticker: "PI_ETHUSD", Trades: 1, PnL: -4}, {Ticker: "PI_XBTUSD", Trades: 3, PnL: 11}}, s.Instruments)
+	assert.Equal(t, map[string]int{"PI_XBTUSD": 2}, s.Positions)
+}
+
+func TestStatsViewer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/trades", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]domain.Trade{{Ticker: "PI_XBTUSD", Profit: 3}})
+	})
+	mux.HandleFunc("/api/risk", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	c := newClient(srv.URL)
+
+	// Без доступа к /api/risk выводятся только итоги по сделкам
+	var buf bytes.Buffer
+	assert.NoError(t, runStats(context.Background(), c, &output{w: &buf, json: true}, nil))
+	var got map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, float64(1), got["trades"])
+	assert.NotContains(t, got, "daily_pnl")
+
+	buf.Reset()
+	assert.NoError(t, runStats(context.Background(), c, &output{w: &buf}, nil))
+	assert.Contains(t, buf.String(), "needs the trader role")
+}
+
+func TestStreamMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  bool
+		event string
+		data  string
+		want  string
+	}{
+		{"Tick", false, "tick", `{"ticker": "PI_XBTUSD", "bid": 50000, "ask": 50001.5}`, "PI_XBTUSD bid 50000.0000 ask 50001.5000\n"},
+		{"Event", false, "event", `{"type": "order_closed", "ticker": "PI_XBTUSD", "side": "sell", "size": 2, "price": 51000, "profit": 12.5}`,
+			"order_closed PI_XBTUSD sell 2 @ 51000.0000 profit 12.50\n"},
+		{"Json", true, "pnl", `{"daily":1,"weekly":2,"cycle":3}`, `{"type":"pnl","data":{"daily":1,"weekly":2,"cycle":3}}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := &output{w: &buf, json: tt.json}
+			assert.NoError(t, out.streamMessage(tt.event, json.RawMessage(tt.data)))
+			if tt.json {
+				assert.Equal(t, tt.want, buf.String())
+			} else {
+				// Строка начинается со времени и типа сообщения
+				assert.Equal(t, tt.want, buf.String()[15:])
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// output печатает результаты команд таблицей или json
+type output struct {
+	w    io.Writer
+	json bool
+}
+
+// stats - итоги по сделкам за период и текущее состояние риск-менеджера. Состояние риск-менеджера
+// доступно только с ролью trader, без нее оно не выводится
+type stats struct {
+	Trades      int                       `json:"trades"`
+	Wins        int                       `json:"wins"`
+	WinRate     float32                   `json:"win_rate"` // процент прибыльных сделок
+	PnL         float32                   `json:"pnl"`
+	LargestWin  float32                   `json:"largest_win"`
+	LargestLoss float32                   `json:"largest_loss"`
+	Instruments []domain.InstrumentReport `json:"instruments"`
+	*riskStats
+}
+
+type riskStats struct {
+	DailyPnL  float32        `json:"daily_pnl"`
+	WeeklyPnL float32        `json:"weekly_pnl"`
+	Positions map[string]int `json:"positions"`
+	Exposure  float32        `json:"exposure"`
+	Killed    bool           `json:"killed"`
+}
+
+// makeStats считает итоги по сделкам. risk - nil, если состояние риск-менеджера недоступно
+func makeStats(trades []domain.Trade, risk *domain.RiskStatus) stats {
+	res := stats{Instruments: []domain.InstrumentReport{}}
+	byTicker := make(map[string]*domain.InstrumentReport)
+	for _, t := range trades {
+		res.Trades++
+		res.PnL += t.Profit
+		if t.Profit > 0 {
+			res.Wins++
+		}
+		if t.Profit > res.LargestWin {
+			res.LargestWin = t.Profit
+		}
+		if t.Profit < res.LargestLoss {
+			res.LargestLoss = t.Profit
+		}
+		inst, ok := byTicker[t.Ticker]
+		if !ok {
+			inst = &domain.InstrumentReport{Ticker: t.Ticker}
+			byTicker[t.Ticker] = inst
+		}
+		inst.Trades++
+		inst.PnL += t.Profit
+	}
+	if res.Trades > 0 {
+		res.WinRate = float32(res.Wins) * 100 / float32(res.Trades)
+	}
+	for _, inst := range byTicker {
+		res.Instruments = append(res.Instruments, *inst)
+	}
+	sort.Slice(res.Instruments, func(i, j int) bool { return res.Instruments[i].Ticker < res.Instruments[j].Ticker })
+	if risk == nil {
+		return res
+	}
+	res.riskStats = &riskStats{
+		DailyPnL:  risk.DailyPnL,
+		WeeklyPnL: risk.WeeklyPnL,
+		Positions: make(map[string]int),
+		Exposure:  risk.Exposure,
+		Killed:    risk.Killed,
+	}
+	for ticker, size := range risk.Positions {
+		if size != 0 {
+			res.Positions[ticker] = size
+		}
+	}
+	return res
+}
+
+func (o *output) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(o.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (o *output) message(text string) {
+	if o.json {
+		_ = o.writeJSON(map[string]string{"message": text})
+		return
+	}
+	fmt.Fprintln(o.w, text)
+}
+
+func (o *output) status(st domain.RobotStatus) error {
+	if o.json {
+		return o.writeJSON(st)
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	cyclesLeft := "unlimited"
+	if st.CyclesLeft >= 0 {
+		cyclesLeft = fmt.Sprint(st.CyclesLeft)
+	}
+	rows := [][2]string{
+		{"State", st.State},
+		{"Ticker", st.Params.Ticker},
+		{"Side", st.Params.Side},
+		{"Size", fmt.Sprint(st.Params.Size)},
+		{"Profit", fmt.Sprint(st.Params.Profit)},
+		{"Cycle", fmt.Sprint(st.Cycle)},
+		{"Cycles left", cyclesLeft},
+		{"Cycle P&L", fmt.Sprintf("%.2f", st.PnL)},
+		{"To target", fmt.Sprintf("%.2f", st.ToTarget)},
+		{"Loss budget", fmt.Sprintf("%.2f", st.LossBudget)},
+	}
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s:\t%s\n", r[0], r[1])
+	}
+	return tw.Flush()
+}
+
+func (o *output) trades(trades []domain.Trade) error {
+	if o.json {
+		if trades == nil {
+			trades = []domain.Trade{}
+		}
+		return o.writeJSON(trades)
+	}
+	if len(trades) == 0 {
+		fmt.Fprintln(o.w, "No trades")
+		return nil
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "TIME\tTICKER\tSIDE\tSIZE\tPRICE\tPROFIT\t")
+	var total float32
+	for _, t := range trades {
+		total += t.Profit
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%.4f\t%.2f\t\n", t.Time.UTC().Format("2006-01-02 15:04:05"), t.Ticker, t.Side, t.Size, t.Price, t.Profit)
+	}
+	fmt.Fprintf(tw, "Total\t\t\t\t\t%.2f\t\n", total)
+	return tw.Flush()
+}
+
+func (o *output) stats(s stats) error {
+	if o.json {
+		return o.writeJSON(s)
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Trades:\t%d\n", s.Trades)
+	fmt.Fprintf(tw, "Win rate:\t%.1f%%\n", s.WinRate)
+	fmt.Fprintf(tw, "P&L:\t%.2f\n", s.PnL)
+	fmt.Fprintf(tw, "Largest win:\t%.2f\n", s.LargestWin)
+	fmt.Fprintf(tw, "Largest loss:\t%.2f\n", s.LargestLoss)
+	for _, inst := range s.Instruments {
+		fmt.Fprintf(tw, "  %s:\t%d trades, %.2f\n", inst.Ticker, inst.Trades, inst.PnL)
+	}
+	if s.riskStats == nil {
+		fmt.Fprintf(tw, "Risk status:\tneeds the trader role\n")
+		return tw.Flush()
+	}
+	fmt.Fprintf(tw, "Daily P&L:\t%.2f\n", s.DailyPnL)
+	fmt.Fprintf(tw, "Weekly P&L:\t%.2f\n", s.WeeklyPnL)
+	if len(s.Positions) == 0 {
+		fmt.Fprintf(tw, "Positions:\tnone\n")
+	}
+	tickers := make([]string, 0, len(s.Positions))
+	for ticker := range s.Positions {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	for i, ticker := range tickers {
+		name := ""
+		if i == 0 {
+			name = "Positions:"
+		}
+		fmt.Fprintf(tw, "%s\t%s %d\n", name, ticker, s.Positions[ticker])
+	}
+	fmt.Fprintf(tw, "Exposure:\t%.2f\n", s.Exposure)
+	if s.Killed {
+		fmt.Fprintf(tw, "Kill switch:\tengaged\n")
+	}
+	return tw.Flush()
+}
+
+// streamMessage печатает сообщение потока одной строкой: в json - как {"type": ..., "data": ...}
+func (o *output) streamMessage(event string, data json.RawMessage) error {
+	if o.json {
+		b, err := json.Marshal(struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}{event, data})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.w, "%s\n", b)
+		return err
+	}
+
+	var line string
+	switch event {
+	case "tick":
+		var t struct {
+			Ticker string  `json:"ticker"`
+			Bid    float32 `json:"bid"`
+			Ask    float32 `json:"ask"`
+		}
+		_ = json.Unmarshal(data, &t)
+		line = fmt.Sprintf("%s bid %.4f ask %.4f", t.Ticker, t.Bid, t.Ask)
+	case "state":
+		var st domain.RobotStatus
+		_ = json.Unmarshal(data, &st)
+		line = fmt.Sprintf("%s %s cycle %d P&L %.2f", st.State, st.Params.Ticker, st.Cycle, st.PnL)
+	case "pnl":
+		var p struct {
+			Daily  float32 `json:"daily"`
+			Weekly float32 `json:"weekly"`
+			Cycle  float32 `json:"cycle"`
+		}
+		_ = json.Unmarshal(data, &p)
+		line = fmt.Sprintf("daily %.2f weekly %.2f cycle %.2f", p.Daily, p.Weekly, p.Cycle)
+	case "event":
+		var ev domain.Event
+		_ = json.Unmarshal(data, &ev)
+		parts := []string{ev.Type}
+		for _, s := range []string{ev.Ticker, ev.Side} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		if ev.Size != 0 {
+			parts = append(parts, fmt.Sprint(ev.Size))
+		}
+		if ev.Price != 0 {
+			parts = append(parts, fmt.Sprintf("@ %.4f", ev.Price))
+		}
+		if ev.Profit != 0 {
+			parts = append(parts, fmt.Sprintf("profit %.2f", ev.Profit))
+		}
+		if ev.Reason != "" {
+			parts = append(parts, ev.Reason)
+		}
+		line = strings.Join(parts, " ")
+	default:
+		line = string(data)
+	}
+	_, err := fmt.Fprintf(o.w, "%s %-5s %s\n", time.Now().Format("15:04:05"), event, line)
+	return err
+}
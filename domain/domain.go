@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"errors"
+	"time"
+)
+
 type SubscribeWS struct {
 	Event string   `json:"event"`
 	Feed  string   `json:"feed"`
@@ -12,12 +17,68 @@ type Options struct {
 	Size   int     `json:"size"`
 	Profit float32 `json:"profit"`
 	Side   string  `json:"side"`
+	Sizing
+	Loop
+	DCA
+	Strategy
+	Exits
+}
+
+// Sizing - способ расчета размера сделки. При пустом SizeMode используется Size из Options
+type Sizing struct {
+	SizeMode      string  `json:"size_mode"`      // contracts, notional, equity, risk
+	Notional      float32 `json:"notional"`       // размер позиции в долларах
+	EquityPercent float32 `json:"equity_percent"` // процент от доступной маржи
+	Risk          float32 `json:"risk"`           // риск на сделку в долларах
+	AtrPeriod     int     `json:"atr_period"`     // если задан, риск делится на ATR вместо расстояния до стопа
+	AtrResolution string  `json:"atr_resolution"` // свечи для ATR: 1m, 5m, 15m, 30m, 1h, 4h, 12h, 1d, 1w; по умолчанию 1h
+}
+
+// DCA - усреднение позиции страховочными ордерами при движении цены против сделки.
+// При SafetyOrders = 0 робот входит в позицию одним ордером
+type DCA struct {
+	SafetyOrders   int     `json:"safety_orders"`   // число страховочных ордеров
+	Deviation      float32 `json:"deviation"`       // отклонение цены в процентах до первого страховочного ордера
+	StepScale      float32 `json:"step_scale"`      // множитель отклонения для каждого следующего ордера, 0 - то же, что 1
+	SizeMultiplier float32 `json:"size_multiplier"` // множитель размера каждого следующего ордера, 0 - то же, что 1
+}
+
+// Exits - цены stop-loss и take-profit, которые заменяют границы, посчитанные по Profit. 0 - не задана
+type Exits struct {
+	StopPrice   float32 `json:"stop_price"`
+	TargetPrice float32 `json:"target_price"`
+}
+
+// Strategy - стратегия выбора момента и направления входа. При пустом Name направление определяется
+// по средней цене нескольких последних тиков
+type Strategy struct {
+	Name     string  `json:"strategy"` // midpoint, bollinger, donchian, script
+	Interval int     `json:"interval"` // длительность свечи в секундах
+	Period   int     `json:"period"`   // число свечей для расчета индикатора
+	StdDev   float32 `json:"std_dev"`  // ширина полос Боллинджера в стандартных отклонениях
+	Script   string  `json:"script"`   // имя загруженного скрипта для стратегии script
+}
+
+// Script - стратегия на языке Starlark. Скрипт должен определять функцию signal(ctx),
+// которая возвращает "buy", "sell", "close" или "hold"
+type Script struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+type Candle struct {
+	Time  time.Time `json:"time"`
+	Open  float32   `json:"open"`
+	High  float32   `json:"high"`
+	Low   float32   `json:"low"`
+	Close float32   `json:"close"`
 }
 
 type WsResponse struct {
 	ProductID string  `json:"product_id"`
 	Bid       float32 `json:"bid"`
 	Ask       float32 `json:"ask"`
+	Time      int64   `json:"time"` // время тика на бирже, миллисекунды Unix
 }
 
 type SendStatus struct {
@@ -35,3 +96,457 @@ type APIResp struct {
 type OrderEvents struct {
 	Price float32 `json:"price"`
 }
+
+// Loop - режим, в котором робот после закрытия сделки снова входит в рынок
+type Loop struct {
+	Enabled      bool    `json:"loop"`
+	Cycles       int     `json:"cycles"`        // максимальное число сделок, 0 - без ограничения
+	LoopCooldown int     `json:"loop_cooldown"` // пауза в секундах между сделками
+	TargetPnL    float32 `json:"target_pnl"`    // остановиться при достижении прибыли в долларах, 0 - без ограничения
+	LossLimit    float32 `json:"loss_limit"`    // остановиться при достижении убытка в долларах, 0 - без ограничения
+}
+
+type RobotStatus struct {
+	State      string  `json:"state"` // stopped, trading, cooldown
+	Params     Options `json:"params"`
+	Cycle      int     `json:"cycle"`
+	CyclesLeft int     `json:"cycles_left"` // -1 - без ограничения
+	PnL        float32 `json:"pnl"`
+	ToTarget   float32 `json:"to_target"`   // прибыль, оставшаяся до target_pnl
+	LossBudget float32 `json:"loss_budget"` // убыток, оставшийся до loss_limit
+}
+
+type InstrumentsResp struct {
+	Result      string           `json:"result"`
+	Instruments []InstrumentSpec `json:"instruments"`
+	Error       string           `json:"error"`
+}
+
+// InstrumentSpec - инструмент в том виде, в котором его отдает /api/v3/instruments
+type InstrumentSpec struct {
+	Symbol                      string        `json:"symbol"`
+	Type                        string        `json:"type"`
+	Tradeable                   bool          `json:"tradeable"`
+	TickSize                    float32       `json:"tickSize"`
+	ContractSize                float32       `json:"contractSize"`
+	ContractValueTradePrecision int           `json:"contractValueTradePrecision"`
+	MarginLevels                []MarginLevel `json:"marginLevels"`
+}
+
+type MarginLevel struct {
+	Contracts         float32 `json:"contracts"`
+	InitialMargin     float32 `json:"initialMargin"`
+	MaintenanceMargin float32 `json:"maintenanceMargin"`
+}
+
+type Instrument struct {
+	Symbol       string  `json:"symbol"`
+	Type         string  `json:"type"`
+	Tradeable    bool    `json:"tradeable"`
+	TickSize     float32 `json:"tick_size"`
+	ContractSize float32 `json:"contract_size"`
+	MinOrderSize float32 `json:"min_order_size"`
+	MaxLeverage  float32 `json:"max_leverage"`
+}
+
+type AccountsResp struct {
+	Result   string   `json:"result"`
+	Accounts Accounts `json:"accounts"`
+	Error    string   `json:"error"`
+}
+
+type Accounts struct {
+	Flex FlexAccount `json:"flex"`
+}
+
+type FlexAccount struct {
+	AvailableMargin float32 `json:"availableMargin"`
+	PortfolioValue  float32 `json:"portfolioValue"`
+}
+
+// RiskLimits - ограничения риск-менеджера. Нулевое значение отключает ограничение
+type RiskLimits struct {
+	MaxPosition          int     `json:"max_position"`           // контрактов на инструмент
+	MaxExposure          float32 `json:"max_exposure"`           // суммарный размер позиций в долларах
+	MaxDailyLoss         float32 `json:"max_daily_loss"`         // в долларах
+	MaxWeeklyLoss        float32 `json:"max_weekly_loss"`        // в долларах
+	MaxTradesPerHour     int     `json:"max_trades_per_hour"`    // открытых сделок за последний час
+	MaxConsecutiveLosses int     `json:"max_consecutive_losses"` // убыточных сделок подряд до паузы
+	Cooldown             int     `json:"cooldown"`               // пауза в секундах после серии убытков
+}
+
+type RiskStatus struct {
+	Limits            RiskLimits     `json:"limits"`
+	Killed            bool           `json:"killed"`
+	Positions         map[string]int `json:"positions"`
+	Exposure          float32        `json:"exposure"`
+	DailyPnL          float32        `json:"daily_pnl"`
+	WeeklyPnL         float32        `json:"weekly_pnl"`
+	TradesLastHour    int            `json:"trades_last_hour"`
+	ConsecutiveLosses int            `json:"consecutive_losses"`
+	CooldownUntil     time.Time      `json:"cooldown_until"`
+}
+
+// Schedule - расписание работы робота. Start и Stop задаются в формате cron,
+// время считается в часовом поясе Timezone (по умолчанию UTC)
+type Schedule struct {
+	ID        string     `json:"id"`
+	Start     string     `json:"start"`
+	Stop      string     `json:"stop"`
+	Timezone  string     `json:"timezone"`
+	Blackouts []Blackout `json:"blackouts"`
+}
+
+// Blackout - интервал, в котором торговля запрещена. Days - дни недели (mon, tue, ...), пустой список - каждый день
+type Blackout struct {
+	Days []string `json:"days"`
+	From string   `json:"from"` // HH:MM
+	To   string   `json:"to"`   // HH:MM, может быть меньше From, если интервал переходит через полночь
+}
+
+type FillsResp struct {
+	Result string `json:"result"`
+	Fills  []Fill `json:"fills"`
+	Error  string `json:"error"`
+}
+
+type Fill struct {
+	FillID   string  `json:"fill_id"`
+	OrderID  string  `json:"order_id"`
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Size     float32 `json:"size"`
+	Price    float32 `json:"price"`
+	FillTime string  `json:"fillTime"`
+}
+
+type CancelResp struct {
+	Result       string       `json:"result"`
+	CancelStatus CancelStatus `json:"cancelStatus"`
+	Error        string       `json:"error"`
+}
+
+type CancelStatus struct {
+	Status  string `json:"status"`
+	OrderID string `json:"order_id"`
+}
+
+// GridParams - сетка лимитных ордеров из Levels уровней между Lower и Upper
+type GridParams struct {
+	Ticker string  `json:"ticker"`
+	Lower  float32 `json:"lower"`
+	Upper  float32 `json:"upper"`
+	Levels int     `json:"levels"`
+	Size   int     `json:"size"`
+}
+
+type GridOrder struct {
+	OrderID string  `json:"order_id"`
+	Side    string  `json:"side"`
+	Price   float32 `json:"price"`
+	Level   int     `json:"level"`
+}
+
+type GridStatus struct {
+	Active bool        `json:"active"`
+	Params GridParams  `json:"params"`
+	Orders []GridOrder `json:"orders"`
+	Trades int         `json:"trades"`
+	Profit float32     `json:"profit"`
+}
+
+// PairsParams - торговля спредом двух инструментов. Когда отношение цен TickerA/TickerB отклоняется
+// от среднего больше чем на Entry стандартных отклонений, открываются противоположные позиции по обоим
+type PairsParams struct {
+	TickerA  string  `json:"ticker_a"`
+	TickerB  string  `json:"ticker_b"`
+	SizeA    int     `json:"size_a"`
+	SizeB    int     `json:"size_b"`
+	Interval int     `json:"interval"` // длительность свечи отношения цен в секундах
+	Period   int     `json:"period"`   // число свечей для расчета среднего и отклонения
+	Entry    float32 `json:"entry"`    // z-score для входа
+	Exit     float32 `json:"exit"`     // z-score для выхода
+	StopZ    float32 `json:"stop_z"`   // z-score для закрытия с убытком, 0 - не используется
+}
+
+type PairLeg struct {
+	Ticker string  `json:"ticker"`
+	Side   string  `json:"side"`
+	Size   int     `json:"size"`
+	Price  float32 `json:"price"`
+}
+
+type PairsStatus struct {
+	Active bool        `json:"active"`
+	Params PairsParams `json:"params"`
+	ZScore float32     `json:"zscore"`
+	Legs   []PairLeg   `json:"legs"`
+	Trades int         `json:"trades"`
+	Profit float32     `json:"profit"`
+}
+
+type ChartsResp struct {
+	Candles []ChartCandle `json:"candles"`
+}
+
+// ChartCandle - свеча из charts API Kraken, цены передаются строками, время - в миллисекундах
+type ChartCandle struct {
+	Time  int64  `json:"time"`
+	Open  string `json:"open"`
+	High  string `json:"high"`
+	Low   string `json:"low"`
+	Close string `json:"close"`
+}
+
+// OptimizeRequest - подбор параметров стратегии на исторических свечах
+type OptimizeRequest struct {
+	Ticker     string                `json:"ticker"`
+	Resolution string                `json:"resolution"` // 1m, 5m, 15m, 30m, 1h, 4h, 12h, 1d, 1w
+	From       time.Time             `json:"from"`
+	To         time.Time             `json:"to"`
+	Options    Options               `json:"options"`     // параметры, которые не перебираются
+	Ranges     map[string]ParamRange `json:"ranges"`      // перебираемые параметры: period, std_dev, profit
+	Search     string                `json:"search"`      // grid (по умолчанию) или random
+	Samples    int                   `json:"samples"`     // число случайных наборов для random
+	Seed       int64                 `json:"seed"`        // зерно генератора для random, 0 - случайное
+	Metric     string                `json:"metric"`      // profit, win_rate, profit_factor, sharpe, drawdown
+	Folds      int                   `json:"folds"`       // число окон walk-forward, 0 - без разбиения
+	TrainRatio float32               `json:"train_ratio"` // доля окна для подбора, остаток - для проверки
+	Workers    int                   `json:"workers"`     // число параллельных прогонов, 0 - по числу ядер
+	Top        int                   `json:"top"`         // сколько лучших наборов вернуть
+	File       string                `json:"file"`        // сохранить отчет в файл
+	SaveToDB   bool                  `json:"save_to_db"`  // сохранить отчет в таблицу optimizations
+}
+
+type ParamRange struct {
+	From float32 `json:"from"`
+	To   float32 `json:"to"`
+	Step float32 `json:"step"`
+}
+
+type BacktestResult struct {
+	Trades       int     `json:"trades"`
+	Wins         int     `json:"wins"`
+	Profit       float32 `json:"profit"`
+	WinRate      float32 `json:"win_rate"`
+	MaxDrawdown  float32 `json:"max_drawdown"`
+	ProfitFactor float32 `json:"profit_factor"`
+	Sharpe       float32 `json:"sharpe"`
+}
+
+type OptimizeResult struct {
+	Params map[string]float32 `json:"params"`
+	Result BacktestResult     `json:"result"`
+}
+
+// WalkForwardFold - лучший набор на обучающей части окна и его результат на проверочной
+type WalkForwardFold struct {
+	Fold      int            `json:"fold"`
+	TrainFrom time.Time      `json:"train_from"`
+	TestFrom  time.Time      `json:"test_from"`
+	TestTo    time.Time      `json:"test_to"`
+	Best      OptimizeResult `json:"best"`
+	Test      BacktestResult `json:"test"`
+}
+
+type OptimizeReport struct {
+	Ticker       string            `json:"ticker"`
+	Strategy     string            `json:"strategy"`
+	Metric       string            `json:"metric"`
+	Candles      int               `json:"candles"`
+	Combinations int               `json:"combinations"`
+	Results      []OptimizeResult  `json:"results"`
+	Folds        []WalkForwardFold `json:"folds,omitempty"`
+}
+
+// Signal - торговый сигнал от внешней системы. Side "close" закрывает открытую по Ticker позицию
+type Signal struct {
+	ID     string    `json:"id"`
+	Ticker string    `json:"ticker"`
+	Side   string    `json:"side"`
+	Size   int       `json:"size"`   // 0 - размер по текущим настройкам робота
+	Stop   float32   `json:"stop"`   // цена stop-loss, 0 - по "profit"
+	Target float32   `json:"target"` // цена take-profit, 0 - по "profit"
+	Expiry time.Time `json:"expiry"` // обязателен, после этого момента сигнал не исполняется
+}
+
+// Каналы и уровни важности уведомлений. По каналу сервер Телеграм выбирает чаты, в которые отправляется сообщение
+const (
+	ChannelTrades  = "trades"
+	ChannelErrors  = "errors"
+	ChannelSummary = "summary"
+
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Типы событий робота, о которых отправляются уведомления
+const (
+	EventOrderOpened   = "order_opened"
+	EventOrderClosed   = "order_closed"
+	EventOrderRejected = "order_rejected"
+	EventRiskLimitHit  = "risk_limit_hit"
+	EventSafetyOrder   = "safety_order_filled"
+	EventRobotError    = "robot_error"
+	EventGridStarted   = "grid_started"
+	EventGridStopped   = "grid_stopped"
+	EventPairsStarted  = "pairs_started"
+	EventPairsStopped  = "pairs_stopped"
+	EventPairOpened    = "pair_opened"
+	EventPairClosed    = "pair_closed"
+	EventSignal        = "signal_accepted"
+	EventReport        = "report"
+	EventStateChanged  = "state_changed"
+)
+
+// eventChannels - канал и уровень важности уведомления для каждого типа события
+var eventChannels = map[string][2]string{
+	EventOrderOpened:   {ChannelTrades, SeverityInfo},
+	EventOrderClosed:   {ChannelTrades, SeverityInfo},
+	EventOrderRejected: {ChannelErrors, SeverityError},
+	EventRiskLimitHit:  {ChannelErrors, SeverityWarning},
+	EventSafetyOrder:   {ChannelTrades, SeverityInfo},
+	EventRobotError:    {ChannelErrors, SeverityError},
+	EventGridStarted:   {ChannelTrades, SeverityInfo},
+	EventGridStopped:   {ChannelTrades, SeverityInfo},
+	EventPairsStarted:  {ChannelTrades, SeverityInfo},
+	EventPairsStopped:  {ChannelTrades, SeverityInfo},
+	EventPairOpened:    {ChannelTrades, SeverityInfo},
+	EventPairClosed:    {ChannelTrades, SeverityInfo},
+	EventSignal:        {ChannelTrades, SeverityInfo},
+	EventReport:        {ChannelSummary, SeverityInfo},
+	EventStateChanged:  {ChannelTrades, SeverityInfo},
+}
+
+// Event - событие робота. Текст уведомления получается из шаблона для типа события,
+// поля, которые к событию не относятся, остаются пустыми
+type Event struct {
+	ID           string    `json:"id,omitempty"` // уникальный id события, по нему outbox отбрасывает повторную запись
+	Type         string    `json:"type"`
+	Ticker       string    `json:"ticker,omitempty"`
+	Side         string    `json:"side,omitempty"`
+	Size         int       `json:"size,omitempty"`
+	Price        float32   `json:"price,omitempty"`      // цена исполнения, для закрытия - цена закрытия
+	OpenPrice    float32   `json:"open_price,omitempty"` // средняя цена входа
+	StopLoss     float32   `json:"stop_loss,omitempty"`
+	TakeProfit   float32   `json:"take_profit,omitempty"`
+	Profit       float32   `json:"profit,omitempty"`
+	TotalProfit  float32   `json:"total_profit,omitempty"`
+	PositionSize int       `json:"position_size,omitempty"`
+	Number       int       `json:"number,omitempty"` // номер страховочного ордера, число уровней или сделок
+	Lower        float32   `json:"lower,omitempty"`
+	Upper        float32   `json:"upper,omitempty"`
+	ZScore       float32   `json:"z_score,omitempty"`
+	EntryZ       float32   `json:"entry_z,omitempty"`
+	ExitZ        float32   `json:"exit_z,omitempty"`
+	Legs         []PairLeg `json:"legs,omitempty"`
+	SignalID     string    `json:"signal_id,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	Text         string    `json:"text,omitempty"`  // готовый текст, например отчета
+	State        string    `json:"state,omitempty"` // новое состояние робота: stopped, trading, cooldown
+}
+
+func (e Event) Channel() string {
+	if ch, ok := eventChannels[e.Type]; ok {
+		return ch[0]
+	}
+	return ChannelErrors
+}
+
+func (e Event) Severity() string {
+	if ch, ok := eventChannels[e.Type]; ok {
+		return ch[1]
+	}
+	return SeverityError
+}
+
+// Trade - закрытая сделка из базы. Profit - результат сделки
+type Trade struct {
+	Ticker string    `json:"ticker"`
+	Side   string    `json:"side"`
+	Size   int       `json:"size"`
+	Price  float32   `json:"price"`
+	Profit float32   `json:"profit"`
+	Time   time.Time `json:"time"`
+}
+
+type InstrumentReport struct {
+	Ticker string  `json:"ticker"`
+	Trades int     `json:"trades"`
+	PnL    float32 `json:"pnl"`
+}
+
+// Report - итоги торговли за период day или week
+type Report struct {
+	Period      string             `json:"period"`
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	Trades      int                `json:"trades"`
+	Wins        int                `json:"wins"`
+	WinRate     float32            `json:"win_rate"` // процент прибыльных сделок
+	PnL         float32            `json:"pnl"`
+	LargestWin  float32            `json:"largest_win"`
+	LargestLoss float32            `json:"largest_loss"`
+	Instruments []InstrumentReport `json:"instruments"`
+	Positions   map[string]int     `json:"positions"` // открытые позиции на момент отчета
+	Exposure    float32            `json:"exposure"`
+}
+
+// OutboxMessage - уведомление, записанное в базу и ожидающее отправки
+type OutboxMessage struct {
+	ID       int64
+	Channel  string
+	Severity string
+	Text     string
+	Event    *Event
+	Attempts int
+}
+
+type OutboxStatus struct {
+	Backlog int       `json:"backlog"` // сообщения, которые еще будут отправляться
+	Failed  int       `json:"failed"`  // сообщения, отправка которых прекращена после всех попыток
+	Oldest  time.Time `json:"oldest"`  // время создания самого старого неотправленного сообщения
+}
+
+var (
+	ErrUserNotFound = errors.New("user is not found")
+	ErrUserExists   = errors.New("user already exists")
+	// ErrBadCredentials - неверный логин или пароль либо пользователь отключен
+	ErrBadCredentials = errors.New("incorrect username or password")
+	// ErrDuplicateSignal - сигнал с этим ID уже был принят
+	ErrDuplicateSignal = errors.New("signal with this id had already been received")
+	// ErrTokenRevoked - токен уже был отозван, например refresh токен использован повторно
+	ErrTokenRevoked = errors.New("token is already revoked")
+	// Ошибки запуска: робот, сетка и пары не торгуют одновременно, в запрещенный расписанием интервал ничего не запускается
+	ErrRobotActive       = errors.New("robot is trading, stop it first")
+	ErrGridActive        = errors.New("grid strategy is active, stop it first")
+	ErrPairsActive       = errors.New("pairs trading is active, stop it first")
+	ErrTradingNotAllowed = errors.New("trading is not allowed now by schedule")
+	ErrKillSwitch        = errors.New("kill switch is engaged")
+)
+
+// Виды торговли робота, из которых одновременно может работать только один
+const (
+	TradingRobot = "robot" // основной цикл, в том числе по сигналам и расписанию
+	TradingGrid  = "grid"
+	TradingPairs = "pairs"
+)
+
+// Роли пользователей API. Каждая следующая роль может все, что и предыдущая
+const (
+	RoleViewer = "viewer" // только чтение состояния робота, сделок и потока событий
+	RoleTrader = "trader" // чтение риска, расписаний, стратегий, outbox и отчетов, параметры, старт и остановка робота и стратегий
+	RoleAdmin  = "admin"  // пользователи, риск-менеджер и kill switch
+)
+
+// User - пользователь API. Пароль хранится только в виде bcrypt хэша
+type User struct {
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	Disabled     bool      `json:"disabled"`
+	Created      time.Time `json:"created"`
+	// TokensValidAfter - токены, выданные раньше этого момента, не обновляются. Сдвигается при смене пароля
+	TokensValidAfter time.Time `json:"-"`
+}
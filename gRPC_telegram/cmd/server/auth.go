@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tokenMargin - за сколько до истечения access токен обновляется заранее
+const tokenMargin = 30 * time.Second
+
+// robotAuth - учетные данные для gRPC API робота. Access токен берется из REST API робота (/login, /refresh)
+// и передается в метаданных каждого вызова
+type robotAuth struct {
+	api     string
+	user    string
+	passwd  string
+	http    *http.Client
+	access  string
+	refresh string
+	expires time.Time
+	mu      sync.Mutex
+}
+
+func newRobotAuth(api, user, passwd string) *robotAuth {
+	return &robotAuth{
+		api:    strings.TrimRight(api, "/"),
+		user:   user,
+		passwd: passwd,
+		http:   &http.Client{Timeout: commandTimeout},
+	}
+}
+
+// GetRequestMetadata добавляет к вызову заголовок authorization, при необходимости обновляя токен
+func (a *robotAuth) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.access == "" || time.Now().After(a.expires) {
+		if err := a.renew(ctx); err != nil {
+			return nil, status.Error(codes.Unauthenticated, "can't log in to robot API: "+err.Error())
+		}
+	}
+	return map[string]string{"authorization": "Bearer " + a.access}, nil
+}
+
+// RequireTransportSecurity - false: по умолчанию робот слушает gRPC только на 127.0.0.1
+func (a *robotAuth) RequireTransportSecurity() bool {
+	return false
+}
+
+// unary сбрасывает токен, который робот не принял (например, отозванный), чтобы следующий вызов получил новый
+func (a *robotAuth) unary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if status.Code(err) == codes.Unauthenticated {
+		a.mu.Lock()
+		a.access = ""
+		a.mu.Unlock()
+	}
+	return err
+}
+
+// renew обновляет токены по refresh токену, а если это не удалось - входит заново. Вызывается под mu
+func (a *robotAuth) renew(ctx context.Context) error {
+	if a.refresh != "" && a.getTokens(ctx, "/refresh", map[string]string{"refresh_token": a.refresh}) == nil {
+		return nil
+	}
+	if a.user == "" || a.passwd == "" {
+		return errors.New("robot user and password are not set")
+	}
+	return a.getTokens(ctx, "/login", map[string]string{"login": a.user, "passwd": a.passwd})
+}
+
+func (a *robotAuth) getTokens(ctx context.Context, path string, in interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.api+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed: %s", strings.TrimPrefix(path, "/"), strings.TrimSpace(string(data)))
+	}
+	var t struct {
+		Access    string `json:"access_token"`
+		Refresh   string `json:"refresh_token"`
+		ExpiresIn int64  `json:"expires_in"`
+	}
+	if err = json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	a.access, a.refresh = t.Access, t.Refresh
+	a.expires = time.Now().Add(time.Duration(t.ExpiresIn)*time.Second - tokenMargin)
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gRPC/controlpb"
+	"google.golang.org/grpc/status"
+
+	tgbotapi "github.com/Syfaro/telegram-bot-api"
+)
+
+const commandTimeout = 5 * time.Second
+
+const helpText = `Commands:
+/status - robot state and params
+/start - start trading
+/stop - stop trading
+/set PI_XBTUSD 2 0.05 buy - set ticker, size, profit and side (side is optional)
+/pnl - daily, current series and total P&L
+/killswitch - close positions and block new orders`
+
+// commands принимает команды из чатов из белого списка и передает их роботу по gRPC
+type commands struct {
+	bot     *tgbotapi.BotAPI
+	robot   controlpb.RobotControlClient
+	allowed map[int64]bool
+}
+
+func (c *commands) listen() {
+	updates, err := c.bot.GetUpdatesChan(tgbotapi.UpdateConfig{Timeout: 60})
+	if err != nil {
+		log.Println("Can't get updates from telegram: ", err)
+		return
+	}
+	for update := range updates {
+		if update.Message == nil || !update.Message.IsCommand() {
+			continue
+		}
+		chatID := update.Message.Chat.ID
+		if !c.allowed[chatID] {
+			log.Println("Command from unknown chat: ", chatID)
+			continue
+		}
+		reply := c.handle(update.Message.Command(), update.Message.CommandArguments())
+		_, err := c.bot.Send(tgbotapi.NewMessage(chatID, reply))
+		if err != nil {
+			log.Println("Can't send message to telegram: ", err)
+		}
+	}
+}
+
+// handle выполняет команду и возвращает текст ответа
+func (c *commands) handle(command, args string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var text string
+	var err error
+	switch command {
+	case "status":
+		var st *controlpb.Status
+		st, err = c.robot.GetStatus(ctx, &controlpb.Empty{})
+		if err == nil {
+			text = formatStatus(st)
+		}
+	case "start":
+		text, err = result(c.robot.Start(ctx, &controlpb.Empty{}))
+	case "stop":
+		text, err = result(c.robot.Stop(ctx, &controlpb.Empty{}))
+	case "set":
+		var params *controlpb.Params
+		params, text = parseParams(args)
+		if params != nil {
+			text, err = result(c.robot.SetParams(ctx, params))
+		}
+	case "pnl":
+		var pnl *controlpb.PnL
+		pnl, err = c.robot.GetPnL(ctx, &controlpb.Empty{})
+		if err == nil {
+			text = fmt.Sprintf("Daily P&L - %.2f, current series - %.2f, total - %.2f", pnl.Daily, pnl.Cycle, pnl.Total)
+		}
+	case "killswitch":
+		text, err = result(c.robot.KillSwitch(ctx, &controlpb.Empty{}))
+	default:
+		text = helpText
+	}
+	if err != nil {
+		return "Error: " + status.Convert(err).Message()
+	}
+	return text
+}
+
+func result(res *controlpb.Result, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return res.Message, nil
+}
+
+// parseParams разбирает аргументы /set. При ошибке возвращает nil и текст ошибки
+func parseParams(args string) (*controlpb.Params, string) {
+	fields := strings.Fields(args)
+	if len(fields) < 3 || len(fields) > 4 {
+		return nil, "Usage: /set PI_XBTUSD 2 0.05 buy"
+	}
+	size, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, "Size must be an integer"
+	}
+	profit, err := strconv.ParseFloat(fields[2], 32)
+	if err != nil {
+		return nil, "Profit must be a number"
+	}
+	params := &controlpb.Params{Ticker: strings.ToUpper(fields[0]), Size: int32(size), Profit: float32(profit)}
+	if len(fields) == 4 {
+		params.Side = strings.ToLower(fields[3])
+	}
+	return params, ""
+}
+
+func formatStatus(st *controlpb.Status) string {
+	p := st.Params
+	text := fmt.Sprintf("State - %s\nInstrument - %s, side - %s, size - %d, profit - %.2f\nCycle - %d, P&L - %.2f",
+		st.State, p.GetTicker(), p.GetSide(), p.GetSize(), p.GetProfit(), st.Cycle, st.Pnl)
+	if st.Killed {
+		text += "\nKill switch is engaged"
+	}
+	return text
+}
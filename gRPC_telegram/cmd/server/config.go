@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// destination - чат, в который отправляются сообщения канала
+type destination struct {
+	Chat        int64  `json:"chat"`
+	Topic       int    `json:"topic"`        // id темы в группе с темами, 0 - без темы
+	MinSeverity string `json:"min_severity"` // сообщения ниже этого уровня в чат не отправляются
+	Locale      string `json:"locale"`       // язык сообщений в этом чате, если отличается от общего
+}
+
+// config - настройки сервера. Routes сопоставляет каналу (trades, errors, summary) список получателей,
+// сообщения каналов без своих получателей отправляются в "default"
+type config struct {
+	Token     string                   `json:"token"`
+	Admins    []int64                  `json:"admins"` // чаты, из которых принимаются команды
+	Routes    map[string][]destination `json:"routes"`
+	RobotAddr string                   `json:"robot_addr"`
+	// REST API робота и пользователь, от имени которого бот получает токен для gRPC API
+	RobotAPI      string `json:"robot_api"`
+	RobotUser     string `json:"robot_user"`
+	RobotPassword string `json:"robot_password"`
+	Locale        string `json:"locale"` // язык сообщений: ru или en
+}
+
+var severities = map[string]int{"": 0, "info": 0, "warning": 1, "error": 2}
+
+// loadConfig читает настройки из json файла path (если задан) и переменных окружения:
+// TELEGRAM_TOKEN, TELEGRAM_CHATS (получатели по умолчанию в формате chat[:topic] через запятую),
+// TELEGRAM_ADMINS (чаты для команд через запятую), TELEGRAM_LOCALE, ROBOT_ADDR, ROBOT_API, ROBOT_USER и ROBOT_PASSWORD.
+// Переменные окружения важнее файла
+func loadConfig(path string) (config, error) {
+	var cfg config
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		err = json.Unmarshal(b, &cfg)
+		if err != nil {
+			return cfg, fmt.Errorf("can't parse config %s: %w", path, err)
+		}
+	}
+	if cfg.Routes == nil {
+		cfg.Routes = make(map[string][]destination)
+	}
+	if token := os.Getenv("TELEGRAM_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	if chats := os.Getenv("TELEGRAM_CHATS"); chats != "" {
+		var dests []destination
+		for _, s := range strings.Split(chats, ",") {
+			d, err := parseDestination(s)
+			if err != nil {
+				return cfg, err
+			}
+			dests = append(dests, d)
+		}
+		cfg.Routes["default"] = dests
+	}
+	if admins := os.Getenv("TELEGRAM_ADMINS"); admins != "" {
+		cfg.Admins = nil
+		for _, s := range strings.Split(admins, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return cfg, fmt.Errorf("bad chat id in TELEGRAM_ADMINS: %s", s)
+			}
+			cfg.Admins = append(cfg.Admins, id)
+		}
+	}
+	if locale := os.Getenv("TELEGRAM_LOCALE"); locale != "" {
+		cfg.Locale = locale
+	}
+	if addr := os.Getenv("ROBOT_ADDR"); addr != "" {
+		cfg.RobotAddr = addr
+	}
+	if cfg.RobotAddr == "" {
+		cfg.RobotAddr = "localhost:5006"
+	}
+	if api := os.Getenv("ROBOT_API"); api != "" {
+		cfg.RobotAPI = api
+	}
+	if cfg.RobotAPI == "" {
+		cfg.RobotAPI = "http://localhost:5000"
+	}
+	if user := os.Getenv("ROBOT_USER"); user != "" {
+		cfg.RobotUser = user
+	}
+	if passwd := os.Getenv("ROBOT_PASSWORD"); passwd != "" {
+		cfg.RobotPassword = passwd
+	}
+
+	if cfg.Token == "" {
+		return cfg, errors.New("bot token is not set")
+	}
+	if len(cfg.Routes) == 0 {
+		return cfg, errors.New("no chats to send messages to")
+	}
+	if len(cfg.Admins) > 0 && (cfg.RobotUser == "" || cfg.RobotPassword == "") {
+		return cfg, errors.New("robot user and password are required to pass commands to the robot")
+	}
+	if _, ok := templates[cfg.Locale]; cfg.Locale != "" && !ok {
+		return cfg, fmt.Errorf("unknown locale %q", cfg.Locale)
+	}
+	for channel, dests := range cfg.Routes {
+		for _, d := range dests {
+			if _, ok := severities[d.MinSeverity]; !ok {
+				return cfg, fmt.Errorf("unknown severity %q for channel %s", d.MinSeverity, channel)
+			}
+			if _, ok := templates[d.Locale]; d.Locale != "" && !ok {
+				return cfg, fmt.Errorf("unknown locale %q for channel %s", d.Locale, channel)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+func parseDestination(s string) (destination, error) {
+	var d destination
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	chat, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return d, fmt.Errorf("bad chat id in TELEGRAM_CHATS: %s", s)
+	}
+	d.Chat = chat
+	if len(parts) == 2 {
+		d.Topic, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return d, fmt.Errorf("bad topic id in TELEGRAM_CHATS: %s", s)
+		}
+	}
+	return d, nil
+}
+
+// route возвращает получателей сообщения канала channel с уровнем severity
+func (c config) route(channel, severity string) []destination {
+	dests, ok := c.Routes[channel]
+	if !ok {
+		dests = c.Routes["default"]
+	}
+	var res []destination
+	for _, d := range dests {
+		if severities[severity] >= severities[d.MinSeverity] {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+// locale - язык сообщений для получателя d
+func (c config) locale(d destination) string {
+	switch {
+	case d.Locale != "":
+		return d.Locale
+	case c.Locale != "":
+		return c.Locale
+	}
+	return defaultLocale
+}
+
+// allowed - чаты, из которых принимаются команды. Если администраторы не заданы, команды не принимаются
+// ни из одного чата: получатели уведомлений не получают права управлять роботом
+func (c config) allowed() map[int64]bool {
+	res := make(map[int64]bool)
+	for _, id := range c.Admins {
+		res[id] = true
+	}
+	return res
+}
@@ -2,43 +2,133 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"gRPC/controlpb"
 	"gRPC/datapb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"log"
 	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/Syfaro/telegram-bot-api"
 )
 
+// seenTTL - сколько помнятся отправленные сообщения outbox, чтобы повторная отправка не дублировала их
+const seenTTL = 24 * time.Hour
+
 type server struct {
 	datapb.UnsafeMessageServiceServer
-	bot *tgbotapi.BotAPI
+	bot  *tgbotapi.BotAPI
+	cfg  config
+	seen map[string]time.Time
+	mu   sync.Mutex
 }
 
+// SendMessage отправляет сообщение всем получателям канала. Если кому-то отправить не удалось, возвращается
+// ошибка, и робот повторяет отправку. Получателям, которые уже получили сообщение с тем же id, оно не отправляется
 func (s *server) SendMessage(ctx context.Context, req *datapb.Request) (*datapb.Response, error) {
-	fmt.Println(req.Req)
-	msg1 := tgbotapi.NewMessage(1689529148, req.Req)
-	_, err := s.bot.Send(msg1)
-	if err != nil {
-		log.Println("Can't send message to telegram: ", err)
+	var failed error
+	for _, d := range s.cfg.route(req.Channel, req.Severity) {
+		key := fmt.Sprintf("%d:%d:%d", req.Id, d.Chat, d.Topic)
+		if req.Id != 0 && s.delivered(key) {
+			continue
+		}
+		text, markdown := render(s.cfg.locale(d), req)
+		err := s.send(d, text, markdown)
+		if err != nil {
+			log.Println("Can't send message to telegram: ", err)
+			failed = err
+			continue
+		}
+		if req.Id != 0 {
+			s.markDelivered(key)
+		}
+	}
+	if failed != nil {
+		return nil, status.Error(codes.Unavailable, failed.Error())
 	}
 	return &datapb.Response{Resp: 1}, nil
 }
 
+func (s *server) delivered(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.seen[key]
+	return ok
+}
+
+func (s *server) markDelivered(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, t := range s.seen {
+		if now.Sub(t) > seenTTL {
+			delete(s.seen, k)
+		}
+	}
+	s.seen[key] = now
+}
+
+// send отправляет сообщение в чат. Темы в этой версии библиотеки не поддерживаются,
+// поэтому сообщения в тему отправляются запросом к API напрямую
+func (s *server) send(d destination, text string, markdown bool) error {
+	if d.Topic == 0 {
+		msg := tgbotapi.NewMessage(d.Chat, text)
+		if markdown {
+			msg.ParseMode = tgbotapi.ModeMarkdown
+		}
+		_, err := s.bot.Send(msg)
+		return err
+	}
+	params := url.Values{}
+	params.Add("chat_id", strconv.FormatInt(d.Chat, 10))
+	params.Add("message_thread_id", strconv.Itoa(d.Topic))
+	params.Add("text", text)
+	if markdown {
+		params.Add("parse_mode", tgbotapi.ModeMarkdown)
+	}
+	_, err := s.bot.MakeRequest("sendMessage", params)
+	return err
+}
+
 func main() {
+	configPath := flag.String("config", "", "path to json config")
+	flag.Parse()
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalln("Can't load config: ", err)
+	}
+
 	listen, err := net.Listen("tcp", ":5005")
 	if err != nil {
 		log.Fatalf("can't listen on port: %v", err)
 	}
 	s := grpc.NewServer()
 
-	bot, err := tgbotapi.NewBotAPI("2146099871:AAF3T4RRFw6UhlG07i4e31O7grLwvuXXLH4")
+	bot, err := tgbotapi.NewBotAPI(cfg.Token)
 	if err != nil {
 		log.Fatalln("Can't connect to telegram: ", err)
 		return
 	}
-	serv := server{bot: bot}
+	serv := server{bot: bot, cfg: cfg, seen: make(map[string]time.Time)}
+
+	// Команды из Telegram передаются роботу через его gRPC API с токеном пользователя робота
+	auth := newRobotAuth(cfg.RobotAPI, cfg.RobotUser, cfg.RobotPassword)
+	conn, err := grpc.Dial(cfg.RobotAddr, grpc.WithInsecure(), grpc.WithPerRPCCredentials(auth), grpc.WithUnaryInterceptor(auth.unary))
+	if err != nil {
+		log.Fatalln("Can't connect to robot: ", err)
+	}
+	cmd := commands{bot: bot, robot: controlpb.NewRobotControlClient(conn), allowed: cfg.allowed()}
+	if len(cmd.allowed) == 0 {
+		log.Println("Admin chats are not set, commands from telegram are disabled")
+	}
+	go cmd.listen()
 
 	datapb.RegisterMessageServiceServer(s, &serv)
 	if err := s.Serve(listen); err != nil {
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"gRPC/datapb"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Шаблоны сообщений о событиях робота в разметке Markdown. Имя шаблона - тип события, набор шаблонов
+// у всех языков одинаковый. Все строковые поля события экранируются через md и стоят вне выделения
+// жирным: внутри сущности Markdown экранирование не работает и обратная косая черта видна в тексте
+var locales = map[string]string{
+	"ru": `
+{{define "order_opened"}}*Сделка открыта*
+{{md .Ticker}}: {{side .Side}} {{.Size}} по {{price .Price}}
+Стоп-лосс {{price .StopLoss}}, тейк-профит {{price .TakeProfit}}{{end}}
+{{define "order_closed"}}*Сделка закрыта*
+{{md .Ticker}}: {{side .Side}} {{.Size}}, вход {{price .OpenPrice}}, выход {{price .Price}}
+Прибыль {{price .Profit}}, всего {{price .TotalProfit}}{{end}}
+{{define "order_rejected"}}*Ордер не размещен*
+{{md .Ticker}}: {{side .Side}} {{.Size}}
+Причина: {{md .Reason}}{{end}}
+{{define "risk_limit_hit"}}*Ордер заблокирован риск-менеджером*
+{{md .Ticker}}: {{side .Side}} {{.Size}}
+Причина: {{md .Reason}}{{end}}
+{{define "safety_order_filled"}}*Страховочный ордер #{{.Number}} исполнен*
+{{md .Ticker}}: {{side .Side}} {{.Size}} по {{price .Price}}
+Позиция {{.PositionSize}}, средняя цена {{price .OpenPrice}}, тейк-профит {{price .TakeProfit}}{{end}}
+{{define "robot_error"}}*Ошибка робота*{{if .Ticker}} ({{md .Ticker}}){{end}}
+{{md .Reason}}{{end}}
+{{define "grid_started"}}*Сетка запущена*
+{{md .Ticker}}: диапазон {{price .Lower}} - {{price .Upper}}, уровней {{.Number}}, размер {{.Size}}{{end}}
+{{define "grid_stopped"}}*Сетка остановлена*
+{{md .Ticker}}: сделок {{.Number}}, прибыль {{price .Profit}}{{end}}
+{{define "pairs_started"}}*Парная торговля запущена*
+{{range .Legs}}{{md .Ticker}} размер {{.Size}}
+{{end}}z-score входа/выхода {{price .EntryZ}}/{{price .ExitZ}}{{end}}
+{{define "pairs_stopped"}}*Парная торговля остановлена*
+{{legs .Legs}}: сделок {{.Number}}, прибыль {{price .Profit}}{{end}}
+{{define "pair_opened"}}*Пара открыта*
+{{range .Legs}}{{side .Side}} {{md .Ticker}} {{.Size}} по {{price .Price}}
+{{end}}z-score {{price .ZScore}}{{end}}
+{{define "pair_closed"}}*Пара закрыта*
+{{legs .Legs}}: z-score {{price .ZScore}}, прибыль {{price .Profit}}{{end}}
+{{define "signal_accepted"}}*Сигнал принят*
+{{md .SignalId}}: {{side .Side}} {{md .Ticker}}{{end}}
+{{define "report"}}*Отчет*
+{{pre .Text}}{{end}}
+`,
+	"en": `
+{{define "order_opened"}}*Order opened*
+{{md .Ticker}}: {{side .Side}} {{.Size}} at {{price .Price}}
+Stop-loss {{price .StopLoss}}, take-profit {{price .TakeProfit}}{{end}}
+{{define "order_closed"}}*Order closed*
+{{md .Ticker}}: {{side .Side}} {{.Size}}, open {{price .OpenPrice}}, close {{price .Price}}
+Profit {{price .Profit}}, total {{price .TotalProfit}}{{end}}
+{{define "order_rejected"}}*Order rejected*
+{{md .Ticker}}: {{side .Side}} {{.Size}}
+Reason: {{md .Reason}}{{end}}
+{{define "risk_limit_hit"}}*Order blocked by risk manager*
+{{md .Ticker}}: {{side .Side}} {{.Size}}
+Reason: {{md .Reason}}{{end}}
+{{define "safety_order_filled"}}*Safety order #{{.Number}} filled*
+{{md .Ticker}}: {{side .Side}} {{.Size}} at {{price .Price}}
+Position {{.PositionSize}}, average price {{price .OpenPrice}}, take-profit {{price .TakeProfit}}{{end}}
+{{define "robot_error"}}*Robot error*{{if .Ticker}} ({{md .Ticker}}){{end}}
+{{md .Reason}}{{end}}
+{{define "grid_started"}}*Grid started*
+{{md .Ticker}}: range {{price .Lower}} - {{price .Upper}}, {{.Number}} levels, size {{.Size}}{{end}}
+{{define "grid_stopped"}}*Grid stopped*
+{{md .Ticker}}: {{.Number}} trades, profit {{price .Profit}}{{end}}
+{{define "pairs_started"}}*Pairs trading started*
+{{range .Legs}}{{md .Ticker}} size {{.Size}}
+{{end}}entry/exit z-score {{price .EntryZ}}/{{price .ExitZ}}{{end}}
+{{define "pairs_stopped"}}*Pairs trading stopped*
+{{legs .Legs}}: {{.Number}} trades, profit {{price .Profit}}{{end}}
+{{define "pair_opened"}}*Pair opened*
+{{range .Legs}}{{side .Side}} {{md .Ticker}} {{.Size}} at {{price .Price}}
+{{end}}z-score {{price .ZScore}}{{end}}
+{{define "pair_closed"}}*Pair closed*
+{{legs .Legs}}: z-score {{price .ZScore}}, profit {{price .Profit}}{{end}}
+{{define "signal_accepted"}}*Signal accepted*
+{{md .SignalId}}: {{side .Side}} {{md .Ticker}}{{end}}
+{{define "report"}}*Report*
+{{pre .Text}}{{end}}
+`,
+}
+
+const defaultLocale = "ru"
+
+var sidesRu = map[string]string{"buy": "покупка", "sell": "продажа", "close": "закрытие"}
+
+var templates = parseTemplates()
+
+func parseTemplates() map[string]*template.Template {
+	funcs := template.FuncMap{
+		"md": md,
+		// Отчет выровнен пробелами, поэтому отправляется моноширинным блоком
+		"pre":   func(text string) string { return "```\n" + strings.TrimSpace(text) + "\n```" },
+		"price": func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 32) },
+		"legs": func(legs []*datapb.Leg) string {
+			tickers := make([]string, len(legs))
+			for i, l := range legs {
+				tickers[i] = md(l.Ticker)
+			}
+			return strings.Join(tickers, "/")
+		},
+	}
+	res := make(map[string]*template.Template, len(locales))
+	for locale, text := range locales {
+		// Сторона сделки переводится, в английских шаблонах остается как есть
+		f := template.FuncMap{"side": func(s string) string { return s }}
+		if locale == "ru" {
+			f["side"] = func(s string) string {
+				if ru, ok := sidesRu[s]; ok {
+					return ru
+				}
+				return s
+			}
+		}
+		res[locale] = template.Must(template.New(locale).Funcs(funcs).Funcs(f).Parse(text))
+	}
+	return res
+}
+
+// md экранирует символы разметки Markdown, например подчеркивания в тикерах PI_XBTUSD
+var md = strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`).Replace
+
+// render строит текст сообщения по шаблону события на языке locale. Если события нет или для него нет
+// шаблона, отправляется текст req без разметки
+func render(locale string, req *datapb.Request) (string, bool) {
+	if req.Event == nil {
+		return req.Req, false
+	}
+	t, ok := templates[locale]
+	if !ok {
+		t = templates[defaultLocale]
+	}
+	if t.Lookup(req.Event.Type) == nil {
+		return req.Req, false
+	}
+	var buf bytes.Buffer
+	err := t.ExecuteTemplate(&buf, req.Event.Type, req.Event)
+	if err != nil {
+		return req.Req, false
+	}
+	return strings.TrimSpace(buf.String()), true
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"gRPC/datapb"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	legs := []*datapb.Leg{{Ticker: "PI_ETHUSD", Side: "sell", Size: 1, Price: 120}, {Ticker: "PI_XRPUSD", Side: "buy", Size: 2, Price: 1.5}}
+	// Test Table
+	type Test struct {
+		Event    *datapb.Event
+		ExpectRu string
+		ExpectEn string
+	}
+	tests := [...]Test{
+		{
+			&datapb.Event{Type: "order_opened", Ticker: "PI_XBTUSD", Side: "buy", Size: 1, Price: 50000, StopLoss: 49500, TakeProfit: 50500},
+			"*Сделка открыта*\nPI\\_XBTUSD: покупка 1 по 50000\nСтоп-лосс 49500, тейк-профит 50500",
+			"*Order opened*\nPI\\_XBTUSD: buy 1 at 50000\nStop-loss 49500, take-profit 50500",
+		},
+		{
+			&datapb.Event{Type: "order_closed", Ticker: "PI_XBTUSD", Side: "sell", Size: 1, Price: 55000, OpenPrice: 50000, Profit: 0.1, TotalProfit: 50.1},
+			"*Сделка закрыта*\nPI\\_XBTUSD: продажа 1, вход 50000, выход 55000\nПрибыль 0.1, всего 50.1",
+			"*Order closed*\nPI\\_XBTUSD: sell 1, open 50000, close 55000\nProfit 0.1, total 50.1",
+		},
+		{
+			&datapb.Event{Type: "order_rejected", Ticker: "PI_XBTUSD", Side: "buy", Size: 1, Reason: "insufficient_funds"},
+			"*Ордер не размещен*\nPI\\_XBTUSD: покупка 1\nПричина: insufficient\\_funds",
+			"*Order rejected*\nPI\\_XBTUSD: buy 1\nReason: insufficient\\_funds",
+		},
+		{
+			&datapb.Event{Type: "risk_limit_hit", Ticker: "PI_XBTUSD", Side: "sell", Size: 3, Reason: "max position is 2"},
+			"*Ордер заблокирован риск-менеджером*\nPI\\_XBTUSD: продажа 3\nПричина: max position is 2",
+			"*Order blocked by risk manager*\nPI\\_XBTUSD: sell 3\nReason: max position is 2",
+		},
+		{
+			&datapb.Event{Type: "safety_order_filled", Ticker: "PI_XBTUSD", Side: "buy", Size: 2, Price: 49400, Number: 1, PositionSize: 3, OpenPrice: 49600, TakeProfit: 49900},
+			"*Страховочный ордер #1 исполнен*\nPI\\_XBTUSD: покупка 2 по 49400\nПозиция 3, средняя цена 49600, тейк-профит 49900",
+			"*Safety order #1 filled*\nPI\\_XBTUSD: buy 2 at 49400\nPosition 3, average price 49600, take-profit 49900",
+		},
+		{
+			&datapb.Event{Type: "robot_error", Ticker: "PI_XBTUSD", Reason: "can't *close* [position]"},
+			"*Ошибка робота* (PI\\_XBTUSD)\ncan't \\*close\\* \\[position]",
+			"*Robot error* (PI\\_XBTUSD)\ncan't \\*close\\* \\[position]",
+		},
+		{
+			&datapb.Event{Type: "robot_error", Reason: "notification backlog is 100 messages"},
+			"*Ошибка робота*\nnotification backlog is 100 messages",
+			"*Robot error*\nnotification backlog is 100 messages",
+		},
+		{
+			&datapb.Event{Type: "grid_started", Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Number: 5, Size: 1},
+			"*Сетка запущена*\nPI\\_XBTUSD: диапазон 49000 - 51000, уровней 5, размер 1",
+			"*Grid started*\nPI\\_XBTUSD: range 49000 - 51000, 5 levels, size 1",
+		},
+		{
+			&datapb.Event{Type: "grid_stopped", Ticker: "PI_XBTUSD", Number: 4, Profit: 0.4},
+			"*Сетка остановлена*\nPI\\_XBTUSD: сделок 4, прибыль 0.4",
+			"*Grid stopped*\nPI\\_XBTUSD: 4 trades, profit 0.4",
+		},
+		{
+			&datapb.Event{Type: "pairs_started", Legs: legs, EntryZ: 2, ExitZ: 0.5},
+			"*Парная торговля запущена*\nPI\\_ETHUSD размер 1\nPI\\_XRPUSD размер 2\nz-score входа/выхода 2/0.5",
+			"*Pairs trading started*\nPI\\_ETHUSD size 1\nPI\\_XRPUSD size 2\nentry/exit z-score 2/0.5",
+		},
+		{
+			&datapb.Event{Type: "pairs_stopped", Legs: legs, Number: 3, Profit: -1.5},
+			"*Парная торговля остановлена*\nPI\\_ETHUSD/PI\\_XRPUSD: сделок 3, прибыль -1.5",
+			"*Pairs trading stopped*\nPI\\_ETHUSD/PI\\_XRPUSD: 3 trades, profit -1.5",
+		},
+		{
+			&datapb.Event{Type: "pair_opened", Legs: legs, ZScore: 2.5},
+			"*Пара открыта*\nпродажа PI\\_ETHUSD 1 по 120\nпокупка PI\\_XRPUSD 2 по 1.5\nz-score 2.5",
+			"*Pair opened*\nsell PI\\_ETHUSD 1 at 120\nbuy PI\\_XRPUSD 2 at 1.5\nz-score 2.5",
+		},
+		{
+			&datapb.Event{Type: "pair_closed", Legs: legs, ZScore: 0.25, Profit: 2},
+			"*Пара закрыта*\nPI\\_ETHUSD/PI\\_XRPUSD: z-score 0.25, прибыль 2",
+			"*Pair closed*\nPI\\_ETHUSD/PI\\_XRPUSD: z-score 0.25, profit 2",
+		},
+		{
+			&datapb.Event{Type: "signal_accepted", SignalId: "tv_42", Side: "buy", Ticker: "PI_XBTUSD"},
+			"*Сигнал принят*\ntv\\_42: покупка PI\\_XBTUSD",
+			"*Signal accepted*\ntv\\_42: buy PI\\_XBTUSD",
+		},
+		{
+			&datapb.Event{Type: "report", Text: "PI_XBTUSD  2  0.2\n"},
+			"*Отчет*\n```\nPI_XBTUSD  2  0.2\n```",
+			"*Report*\n```\nPI_XBTUSD  2  0.2\n```",
+		},
+	}
+
+	for _, test := range tests {
+		for locale, expect := range map[string]string{"ru": test.ExpectRu, "en": test.ExpectEn} {
+			t.Run(test.Event.Type+"/"+locale, func(t *testing.T) {
+				text, markdown := render(locale, &datapb.Request{Req: "plain", Event: test.Event})
+				if !markdown || text != expect {
+					t.Errorf("Expect %q got %q (markdown %v)", expect, text, markdown)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderFallback(t *testing.T) {
+	ev := &datapb.Event{Type: "grid_stopped", Ticker: "PI_XBTUSD", Number: 4, Profit: 0.4}
+	// Для неизвестного языка используются шаблоны языка по умолчанию
+	ru, _ := render(defaultLocale, &datapb.Request{Event: ev})
+	if got, _ := render("de", &datapb.Request{Event: ev}); got != ru {
+		t.Errorf("Expect %q got %q", ru, got)
+	}
+	// Без события или для события без шаблона отправляется текст без разметки
+	if got, markdown := render("en", &datapb.Request{Req: "a_b"}); got != "a_b" || markdown {
+		t.Errorf("Expect plain text, got %q (markdown %v)", got, markdown)
+	}
+	if got, markdown := render("en", &datapb.Request{Req: "a_b", Event: &datapb.Event{Type: "state_changed"}}); got != "a_b" || markdown {
+		t.Errorf("Expect plain text, got %q (markdown %v)", got, markdown)
+	}
+}
+
+// Все языки описывают одни и те же события, а внутри выделения жирным нет полей события
+func TestLocalesAligned(t *testing.T) {
+	names := func(locale string) []string {
+		var res []string
+		for _, tmpl := range templates[locale].Templates() {
+			if tmpl.Name() != locale {
+				res = append(res, tmpl.Name())
+			}
+		}
+		sort.Strings(res)
+		return res
+	}
+	for locale, text := range locales {
+		if got, expect := names(locale), names(defaultLocale); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: expect templates %v got %v", locale, expect, got)
+		}
+		for _, line := range strings.Split(text, "\n") {
+			parts := strings.Split(line, "*")
+			for i := 1; i < len(parts); i += 2 {
+				if strings.Contains(parts[i], "{{md") || strings.Contains(parts[i], "{{legs") {
+					t.Errorf("%s: escaped field inside bold text: %s", locale, line)
+				}
+			}
+		}
+	}
+}
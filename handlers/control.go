@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/pkg/robotpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RobotControl - gRPC API управления роботом. Повторяет проверки REST обработчиков,
+// через него роботом управляет Telegram бот
+type RobotControl struct {
+	robotpb.UnimplementedRobotControlServer
+	p *SetParams
+}
+
+func NewRobotControl(p *SetParams) *RobotControl {
+	return &RobotControl{p: p}
+}
+
+// rpcRoles - роль, которая нужна для вызова метода. Совпадает с ролью соответствующего REST запроса,
+// методы, которых нет в списке, запрещены
+var rpcRoles = map[string]string{
+	"/robot.RobotControl/GetStatus":   domain.RoleViewer,
+	"/robot.RobotControl/GetPnL":      domain.RoleTrader,
+	"/robot.RobotControl/ListTrades":  domain.RoleViewer,
+	"/robot.RobotControl/WatchTicks":  domain.RoleViewer,
+	"/robot.RobotControl/WatchEvents": domain.RoleViewer,
+	"/robot.RobotControl/SetParams":   domain.RoleTrader,
+	"/robot.RobotControl/Start":       domain.RoleTrader,
+	"/robot.RobotControl/Stop":        domain.RoleTrader,
+	"/robot.RobotControl/KillSwitch":  domain.RoleAdmin,
+}
+
+// ServerOptions - перехватчики, которые проверяют access токен и роль пользователя для каждого вызова
+func (c *RobotControl) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := c.authorize(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := c.authorize(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+// authorize проверяет токен из метаданных "authorization: Bearer {token}" так же, как Auth и RequireRole
+func (c *RobotControl) authorize(ctx context.Context, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := md.Get("authorization")
+	if len(header) == 0 || !strings.HasPrefix(header[0], "Bearer ") {
+		return status.Error(codes.Unauthenticated, "Bearer token is required")
+	}
+	tk, err := c.p.parseToken(strings.TrimPrefix(header[0], "Bearer "), tokenAccess)
+	if err != nil {
+		c.p.logger.Debugf("Invalid token: %v", err)
+		return status.Error(codes.Unauthenticated, "Invalid token")
+	}
+	role, ok := rpcRoles[method]
+	if !ok || roleLevels[tk.Role] < roleLevels[role] {
+		c.p.logger.Debugf("User %q isn't allowed to call %s", tk.Login, method)
+		return status.Errorf(codes.PermissionDenied, "Forbidden: %s role is required", role)
+	}
+	return nil
+}
+
+func (c *RobotControl) GetStatus(ctx context.Context, _ *robotpb.Empty) (*robotpb.Status, error) {
+	st := c.p.Service.GetStatus()
+	return &robotpb.Status{
+		State: st.State,
+		Params: &robotpb.Params{
+			Ticker: st.Params.Ticker,
+			Size:   int32(st.Params.Size),
+			Profit: st.Params.Profit,
+			Side:   st.Params.Side,
+		},
+		Cycle:  int32(st.Cycle),
+		Pnl:    st.PnL,
+		Killed: c.p.Service.GetRiskStatus().Killed,
+	}, nil
+}
+
+// SetParams меняет инструмент, размер, прибыль и направление сделки, остальные параметры остаются прежними
+func (c *RobotControl) SetParams(ctx context.Context, req *robotpb.Params) (*robotpb.Result, error) {
+	options := c.p.Service.GetParams()
+	options.Ticker, options.Size, options.Profit, options.Side = req.Ticker, int(req.Size), req.Profit, req.Side
+	err := checkInput(options, c.p.Service.GetInstrument)
+	if err == nil {
+		err = c.p.checkScript(options.Strategy)
+	}
+	if err != nil {
+		c.p.logger.WithError(err).Error("Error, while setting parm's")
+		return nil, status.Error(codes.InvalidArgument, "Bad params: "+err.Error())
+	}
+	c.p.Service.SetParamsWithoutStart(options.Size, options.Profit, options.Ticker, options.Side)
+	return &robotpb.Result{Message: "Parameters had been set"}, nil
+}
+
+func (c *RobotControl) Start(ctx context.Context, _ *robotpb.Empty) (*robotpb.Result, error) {
+	err := c.p.checkStart()
+	switch {
+	case startConflict(err):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, "Bad params: "+err.Error())
+	}
+	c.p.Service.SetStart(1)
+	return &robotpb.Result{Message: "The signal to start had been sent"}, nil
+}
+
+func (c *RobotControl) Stop(ctx context.Context, _ *robotpb.Empty) (*robotpb.Result, error) {
+	c.p.Service.SetStart(0)
+	return &robotpb.Result{Message: "The signal to stop had been sent"}, nil
+}
+
+func (c *RobotControl) GetPnL(ctx context.Context, _ *robotpb.Empty) (*robotpb.PnL, error) {
+	total, err := c.p.Service.GetTotalProfit()
+	if err != nil {
+		c.p.logger.WithError(err).Error("Can't get total profit")
+		return nil, status.Error(codes.Unavailable, "Can't get total profit: "+err.Error())
+	}
+	return &robotpb.PnL{
+		Daily: c.p.Service.GetRiskStatus().DailyPnL,
+		Cycle: c.p.Service.GetStatus().PnL,
+		Total: total,
+	}, nil
+}
+
+func (c *RobotControl) KillSwitch(ctx context.Context, _ *robotpb.Empty) (*robotpb.Result, error) {
+	c.p.Service.KillSwitch()
+	return &robotpb.Result{Message: "Kill switch is engaged. Open positions are closing, new orders are blocked"}, nil
+}
+
+func (c *RobotControl) ListTrades(ctx context.Context, req *robotpb.TradesRequest) (*robotpb.Trades, error) {
+	now := time.Now().UTC()
+	from, to := time.Unix(req.From, 0).UTC(), time.Unix(req.To, 0).UTC()
+	if req.From == 0 {
+		from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if req.To == 0 {
+		to = now
+	}
+	if !from.Before(to) {
+		return nil, status.Error(codes.InvalidArgument, "Bad params: 'from' must be before 'to'")
+	}
+	trades, err := c.p.Service.ListTrades(from, to)
+	if err != nil {
+		c.p.logger.WithError(err).Error("Can't get trades")
+		return nil, status.Error(codes.Unavailable, "Can't get trades: "+err.Error())
+	}
+	res := &robotpb.Trades{}
+	for _, t := range trades {
+		if req.Ticker != "" && !strings.EqualFold(req.Ticker, t.Ticker) {
+			continue
+		}
+		res.Trades = append(res.Trades, &robotpb.Trade{
+			Ticker: t.Ticker,
+			Side:   t.Side,
+			Size:   int32(t.Size),
+			Price:  t.Price,
+			Profit: t.Profit,
+			Time:   t.Time.Unix(),
+		})
+	}
+	return res, nil
+}
+
+// tickTime - время тика на бирже, для тика без времени - время его получения
+func tickTime(tick domain.WsResponse) int64 {
+	if tick.Time != 0 {
+		return tick.Time
+	}
+	return time.Now().UnixMilli()
+}
+
+// WatchTicks передает тики инструмента, пока клиент не закроет поток. Клиенты одного инструмента
+// получают тики из одного соединения с биржей
+func (c *RobotControl) WatchTicks(req *robotpb.TicksRequest, stream robotpb.RobotControl_WatchTicksServer) error {
+	ticks, cancel, err := c.p.Service.WatchTicks(strings.ToUpper(req.Ticker))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "Bad params: "+err.Error())
+	}
+	defer cancel()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case tick, ok := <-ticks:
+			if !ok {
+				return status.Error(codes.Unavailable, "Tick stream is closed")
+			}
+			err = stream.Send(&robotpb.Tick{
+				Ticker: strings.ToUpper(tick.ProductID),
+				Bid:    tick.Bid,
+				Ask:    tick.Ask,
+				Time:   tickTime(tick),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WatchEvents передает события робота: сделки, ошибки, срабатывания риск-менеджера и т.д.
+func (c *RobotControl) WatchEvents(_ *robotpb.Empty, stream robotpb.RobotControl_WatchEventsServer) error {
+	events, cancel := c.p.Service.WatchEvents()
+	defer cancel()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev := <-events:
+			err := stream.Send(eventToProto(ev))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func eventToProto(ev domain.Event) *robotpb.Event {
+	legs := make([]*robotpb.Leg, len(ev.Legs))
+	for i, l := range ev.Legs {
+		legs[i] = &robotpb.Leg{Ticker: l.Ticker, Side: l.Side, Size: int32(l.Size), Price: l.Price}
+	}
+	return &robotpb.Event{
+		Type:         ev.Type,
+		Channel:      ev.Channel(),
+		Severity:     ev.Severity(),
+		Ticker:       ev.Ticker,
+		Side:         ev.Side,
+		Size:         int32(ev.Size),
+		Price:        ev.Price,
+		OpenPrice:    ev.OpenPrice,
+		StopLoss:     ev.StopLoss,
+		TakeProfit:   ev.TakeProfit,
+		Profit:       ev.Profit,
+		TotalProfit:  ev.TotalProfit,
+		PositionSize: int32(ev.PositionSize),
+		Number:       int32(ev.Number),
+		Lower:        ev.Lower,
+		Upper:        ev.Upper,
+		ZScore:       ev.ZScore,
+		EntryZ:       ev.EntryZ,
+		ExitZ:        ev.ExitZ,
+		Legs:         legs,
+		SignalId:     ev.SignalID,
+		Reason:       ev.Reason,
+		Text:         ev.Text,
+		State:        ev.State,
+		Time:         time.Now().UnixMilli(),
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/pkg/robotpb"
+	"github.com/Marseek/tfs-go-hw/course/repository"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRobotControlSetParams(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name       string
+		In         *robotpb.Params
+		ExpectCode codes.Code
+		ExpectMsg  string
+	}
+	tests := [...]Test{
+		{"OK", &robotpb.Params{Ticker: "PI_XBTUSD", Size: 2, Profit: 0.05, Side: "buy"}, codes.OK, "Parameters had been set"},
+		{"Size error", &robotpb.Params{Ticker: "PI_XBTUSD", Size: -2, Profit: 0.05}, codes.InvalidArgument, "Bad params: 'size' option must be more than 0"},
+		{"Profit error", &robotpb.Params{Ticker: "PI_XBTUSD", Size: 2}, codes.InvalidArgument, "Bad params: 'profit' must be more than 0"},
+	}
+	// Init Dependencies
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	control := NewRobotControl(NewParamsSetter(logger, serv))
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			res, err := control.SetParams(context.Background(), test.In)
+			if test.ExpectCode == codes.OK {
+				assert.NoError(t, err)
+				assert.Equal(t, test.ExpectMsg, res.Message)
+				return
+			}
+			assert.Equal(t, test.ExpectCode, status.Code(err))
+			assert.Equal(t, test.ExpectMsg, status.Convert(err).Message())
+		})
+	}
+
+	st, err := control.GetStatus(context.Background(), &robotpb.Empty{})
+	assert.NoError(t, err)
+	assert.Equal(t, "stopped", st.State)
+	assert.Equal(t, &robotpb.Params{Ticker: "PI_XBTUSD", Size: 2, Profit: 0.05, Side: "buy"}, st.Params)
+}
+
+func TestRobotControlStart(t *testing.T) {
+	// Init Dependencies
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	control := NewRobotControl(NewParamsSetter(logger, serv))
+
+	_, err := control.Start(context.Background(), &robotpb.Empty{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 0, serv.GetParams().Start)
+
+	_, err = control.KillSwitch(context.Background(), &robotpb.Empty{})
+	assert.NoError(t, err)
+	st, err := control.GetStatus(context.Background(), &robotpb.Empty{})
+	assert.NoError(t, err)
+	assert.True(t, st.Killed)
+	// С включенным kill switch робот не запускается даже с верными параметрами
+	serv.SetParamsWithoutStart(2, 0.05, "PI_XBTUSD", "buy")
+	_, err = control.Start(context.Background(), &robotpb.Empty{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, 0, serv.GetParams().Start)
+
+	res, err := control.Stop(context.Background(), &robotpb.Empty{})
+	assert.NoError(t, err)
+	assert.Equal(t, "The signal to stop had been sent", res.Message)
+}
+
+func TestRobotControlBadRequests(t *testing.T) {
+	// Init Dependencies
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	control := NewRobotControl(NewParamsSetter(logger, serv))
+
+	_, err := control.ListTrades(context.Background(), &robotpb.TradesRequest{From: 1638316800, To: 1638230400})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "Bad params: 'from' must be before 'to'", status.Convert(err).Message())
+
+	err = control.WatchTicks(&robotpb.TicksRequest{Ticker: "pi_foousd"}, nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "Bad params: unknown instrument PI_FOOUSD", status.Convert(err).Message())
+}
+
+// ticksStream - поток WatchTicks, полученные тики передаются в got
+type ticksStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	got chan *robotpb.Tick
+}
+
+func (s *ticksStream) Context() context.Context { return s.ctx }
+
+func (s *ticksStream) Send(tick *robotpb.Tick) error {
+	select {
+	case s.got <- tick:
+	default:
+	}
+	return nil
+}
+
+func TestRobotControlWatchTicks(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	logger := log.New()
+	control := NewRobotControl(NewParamsSetter(logger, service.NewRobotService(repo, logger)))
+
+	// Два клиента получают тики из одного соединения, время тика - время биржи
+	upstream := make(chan domain.WsResponse)
+	closed := make(chan struct{})
+	repo.EXPECT().SetWSConnection(gomock.Any(), "PI_XBTUSD").Return(upstream, func() { close(closed) }, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 2)
+	var streams []*ticksStream
+	for i := 0; i < 2; i++ {
+		s := &ticksStream{ctx: ctx, got: make(chan *robotpb.Tick, 100)}
+		streams = append(streams, s)
+		go func() { done <- control.WatchTicks(&robotpb.TicksRequest{Ticker: "pi_xbtusd"}, s) }()
+	}
+	tick := domain.WsResponse{ProductID: "pi_xbtusd", Bid: 50000, Ask: 50001, Time: 1638316800000}
+	for _, s := range streams {
+		// Тики передаются, пока клиент не подпишется и не получит свой
+		var got *robotpb.Tick
+		for got == nil {
+			select {
+			case upstream <- tick:
+			case got = <-s.got:
+			}
+		}
+		assert.Equal(t, &robotpb.Tick{Ticker: "PI_XBTUSD", Bid: 50000, Ask: 50001, Time: 1638316800000}, got)
+	}
+
+	// Соединение закрывается, когда уходят все клиенты
+	cancel()
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+	<-closed
+}
+
+func TestRobotControlAuth(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	repo.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	logger := log.New()
+	handler := NewParamsSetter(logger, service.NewRobotService(repo, logger))
+	control := NewRobotControl(handler)
+
+	revoked := testToken(t, handler, domain.RoleAdmin)
+	tk, err := handler.parseToken(revoked, tokenAccess)
+	assert.NoError(t, err)
+	assert.NoError(t, handler.Service.RevokeToken(tk.Id, time.Now().Add(time.Hour)))
+	refresh, err := handler.issueToken(domain.User{Login: "jlexie", Role: domain.RoleAdmin}, tokenRefresh, time.Hour)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		token  string // пустой - вызов без метаданных
+		code   codes.Code
+	}{
+		{"No token", "/robot.RobotControl/GetStatus", "", codes.Unauthenticated},
+		{"Bad token", "/robot.RobotControl/GetStatus", "Bearer abc", codes.Unauthenticated},
+		{"Revoked token", "/robot.RobotControl/GetStatus", "Bearer " + revoked, codes.Unauthenticated},
+		{"Refresh token", "/robot.RobotControl/GetStatus", "Bearer " + refresh, codes.Unauthenticated},
+		{"Token without role", "/robot.RobotControl/GetStatus", "Bearer " + testToken(t, handler, ""), codes.PermissionDenied},
+		{"Viewer reads status", "/robot.RobotControl/GetStatus", "Bearer " + testToken(t, handler, domain.RoleViewer), codes.OK},
+		{"Viewer watches events", "/robot.RobotControl/WatchEvents", "Bearer " + testToken(t, handler, domain.RoleViewer), codes.OK},
+		{"Viewer can't read PnL", "/robot.RobotControl/GetPnL", "Bearer " + testToken(t, handler, domain.RoleViewer), codes.PermissionDenied},
+		{"Viewer can't start", "/robot.RobotControl/Start", "Bearer " + testToken(t, handler, domain.RoleViewer), codes.PermissionDenied},
+		{"Trader starts", "/robot.RobotControl/Start", "Bearer " + testToken(t, handler, domain.RoleTrader), codes.OK},
+		{"Trader can't engage kill switch", "/robot.RobotControl/KillSwitch", "Bearer " + testToken(t, handler, domain.RoleTrader), codes.PermissionDenied},
+		{"Admin engages kill switch", "/robot.RobotControl/KillSwitch", "Bearer " + testToken(t, handler, domain.RoleAdmin), codes.OK},
+		{"Unknown method", "/robot.RobotControl/Unknown", "Bearer " + testToken(t, handler, domain.RoleAdmin), codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.token))
+			}
+			assert.Equal(t, tt.code, status.Code(control.authorize(ctx, tt.method)))
+		})
+	}
+
+	// Перехватчики подключены к серверу: вызов без токена отклоняется до обработчика
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	srv := grpc.NewServer(control.ServerOptions()...)
+	robotpb.RegisterRobotControlServer(srv, control)
+	go func() { _ = srv.Serve(listen) }()
+	defer srv.Stop()
+	conn, err := grpc.Dial(listen.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := robotpb.NewRobotControlClient(conn)
+	_, err = client.GetStatus(context.Background(), &robotpb.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	stream, err := client.WatchEvents(context.Background(), &robotpb.Empty{})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testToken(t, handler, domain.RoleViewer))
+	_, err = client.GetStatus(ctx, &robotpb.Empty{})
+	assert.NoError(t, err)
+}
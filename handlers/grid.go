@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+func (p *SetParams) StartGrid(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var params domain.GridParams
+	err = json.Unmarshal(body, &params)
+	if err != nil {
+		p.logger.Println("Unmarshall error")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	err = checkGridParams(params, p.Service.GetInstrument)
+	if err != nil {
+		p.logger.WithError(err).Error("Error, while setting grid parms")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+		return
+	}
+	err = p.Service.StartGrid(params)
+	if err != nil {
+		p.logger.WithError(err).Error("Can't start grid")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Can't start grid: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Grid had been started\n")
+}
+
+func (p *SetParams) StopGrid(w http.ResponseWriter, r *http.Request) {
+	err := p.Service.StopGrid()
+	if err != nil {
+		p.logger.WithError(err).Error("Can't stop grid")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Can't stop grid: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Grid had been stopped, grid orders are cancelled\n")
+}
+
+func (p *SetParams) GridStatus(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(p.Service.GetGridStatus())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func checkGridParams(params domain.GridParams, getInstrument func(string) (domain.Instrument, bool)) error {
+	inst, ok := getInstrument(params.Ticker)
+	if !ok {
+		return errors.New(`'ticker' option must be one of Kraken futures instruments`)
+	}
+	if !inst.Tradeable {
+		return errors.New(`'ticker' instrument is not tradeable now`)
+	}
+	if params.Lower <= 0 || params.Upper <= params.Lower {
+		return errors.New(`'lower' must be more than 0 and less than 'upper'`)
+	}
+	if params.Levels < 2 {
+		return errors.New(`'levels' must be at least 2`)
+	}
+	if (params.Upper-params.Lower)/float32(params.Levels-1) < inst.TickSize {
+		return errors.New(`distance between levels is less than tick size`)
+	}
+	if params.Size < 1 || float32(params.Size) < inst.MinOrderSize {
+		return errors.New(`'size' option must be more than 0`)
+	}
+	return nil
+}
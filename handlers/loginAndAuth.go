@@ -1,27 +1,50 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/Marseek/tfs-go-hw/course/domain"
 	"github.com/dgrijalva/jwt-go"
 )
 
-const (
-	signedString = "lksjdkfjois9845784hug"
-)
-
 type Usr struct {
 	Login  string `json:"login"`
 	Passwd string `json:"passwd"`
+	Role   string `json:"role"`
 }
 
 type TokenClaims struct {
 	jwt.StandardClaims
 	Login string `json:"Login"`
+	Role  string `json:"role"`
+	Type  string `json:"type"` // access или refresh
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (p *SetParams) writeTokens(w http.ResponseWriter, user domain.User) {
+	tokens, err := p.issueTokens(user)
+	if err != nil {
+		p.logger.WithError(err).Error("Can't sign token")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body, err := json.Marshal(tokens)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(body)
 }
 
 func (p *SetParams) Login(w http.ResponseWriter, r *http.Request) {
@@ -40,25 +63,98 @@ func (p *SetParams) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	usersMap := p.Service.GetUsersMap("users.json")
-	if usersMap[u.Login] != u.Passwd {
+	user, err := p.Service.Authenticate(u.Login, u.Passwd)
+	if errors.Is(err, domain.ErrBadCredentials) {
+		w.WriteHeader(http.StatusUnauthorized)
 		_, _ = io.WriteString(w, "Incorrect username or password")
 		return
 	}
+	if err != nil {
+		p.logger.WithError(err).Error("Can't check user")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Can't check user: "+err.Error())
+		return
+	}
+	p.writeTokens(w, user)
+}
+
+// Refresh выдает новую пару токенов по refresh токену. Старый refresh токен отзывается,
+// роль и блокировка пользователя перечитываются из базы. Повторно использованный refresh токен
+// отклоняется, даже если запросы пришли одновременно: отзыв в базе проходит только один раз
+func (p *SetParams) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Can't unmarshall data or empty refresh_token")
+		return
+	}
+	tk, err := p.parseToken(req.RefreshToken, tokenRefresh)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, "Invalid refresh token")
+		return
+	}
+	user, err := p.Service.GetUser(tk.Login)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		p.logger.WithError(err).Error("Can't check user")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Can't check user: "+err.Error())
+		return
+	}
+	// После смены пароля ранее выданные токены не обновляются
+	if err != nil || user.Disabled || tk.IssuedAt < user.TokensValidAfter.Unix() {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, "Invalid refresh token")
+		return
+	}
+	err = p.revoke(tk)
+	if errors.Is(err, domain.ErrTokenRevoked) {
+		p.logger.WithField("login", tk.Login).Warn("Refresh token is used again")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Can't revoke token: "+err.Error())
+		return
+	}
+	p.writeTokens(w, user)
+}
+
+// Logout отзывает access токен из заголовка Authorization и refresh токен из тела запроса, если он передан
+func (p *SetParams) Logout(w http.ResponseWriter, r *http.Request) {
+	var tokens []*TokenClaims
+	if tk, err := p.parseToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), tokenAccess); err == nil {
+		tokens = append(tokens, tk)
+	}
+	var req refreshRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	if tk, err := p.parseToken(req.RefreshToken, tokenRefresh); err == nil {
+		tokens = append(tokens, tk)
+	}
+	if len(tokens) == 0 {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, "No valid token to revoke\n")
+		return
+	}
+	for _, tk := range tokens {
+		if err := p.revoke(tk); err != nil && !errors.Is(err, domain.ErrTokenRevoked) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, "Can't revoke token: "+err.Error())
+			return
+		}
+	}
+	_, _ = io.WriteString(w, "Logged out\n")
+}
 
-	token := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		&TokenClaims{
-			jwt.StandardClaims{
-				ExpiresAt: time.Now().Add(time.Hour * 1024).Unix(),
-				IssuedAt:  time.Now().Unix(),
-			},
-			u.Login,
-		},
-	)
-	tokenString, _ := token.SignedString([]byte(signedString))
-	w.Header().Add("token", tokenString)
-	_, _ = io.WriteString(w, "Token generated\n")
+func (p *SetParams) revoke(tk *TokenClaims) error {
+	err := p.Service.RevokeToken(tk.Id, time.Unix(tk.ExpiresAt, 0))
+	if err != nil && !errors.Is(err, domain.ErrTokenRevoked) {
+		p.logger.WithError(err).Error("Can't revoke token")
+	}
+	return err
 }
 
 func (p *SetParams) Auth(handler http.Handler) http.Handler {
@@ -78,19 +174,14 @@ func (p *SetParams) Auth(handler http.Handler) http.Handler {
 			return
 		}
 
-		tokenPart := splitted[1]
-		tk := &TokenClaims{}
-
-		_, err := jwt.ParseWithClaims(tokenPart, tk, func(*jwt.Token) (interface{}, error) {
-			return []byte(signedString), nil
-		})
-		if err != nil { // Неправильный или несуществующий токен
-			w.WriteHeader(http.StatusBadRequest)
-			p.logger.Debugf("Invalid token.")
+		tk, err := p.parseToken(splitted[1], tokenAccess)
+		if err != nil { // Неправильный, истекший или отозванный токен
+			w.WriteHeader(http.StatusUnauthorized)
+			p.logger.Debugf("Invalid token: %v", err)
 			return
 		}
 
-		handler.ServeHTTP(w, r)
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, tk)))
 	}
 
 	return http.HandlerFunc(fn)
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+var resolutions = map[string]bool{"1m": true, "5m": true, "15m": true, "30m": true, "1h": true, "4h": true, "12h": true, "1d": true, "1w": true}
+
+func (p *SetParams) Optimize(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var req domain.OptimizeRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		p.logger.Println("Unmarshall error")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	err = checkOptimizeRequest(req, p.Service.GetInstrument)
+	if err == nil {
+		err = p.checkScript(req.Options.Strategy)
+	}
+	if err != nil {
+		p.logger.WithError(err).Error("Error, while setting optimization parms")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+		return
+	}
+
+	report, err := p.Service.Optimize(req)
+	if err != nil {
+		p.logger.WithError(err).Error("Can't optimize")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, "Can't optimize: "+err.Error())
+		return
+	}
+	body, err = json.Marshal(report)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func checkOptimizeRequest(req domain.OptimizeRequest, getInstrument func(string) (domain.Instrument, bool)) error {
+	if _, ok := getInstrument(req.Ticker); !ok {
+		return errors.New(`'ticker' option must be one of Kraken futures instruments`)
+	}
+	if !resolutions[req.Resolution] {
+		return errors.New(`'resolution' must be one of 1m, 5m, 15m, 30m, 1h, 4h, 12h, 1d, 1w`)
+	}
+	if req.From.IsZero() || !req.From.Before(req.To) {
+		return errors.New(`'from' must be before 'to'`)
+	}
+	switch req.Options.Name {
+	case "bollinger", "donchian", "script":
+	default:
+		return errors.New(`'strategy' option must be 'bollinger', 'donchian' or 'script'`)
+	}
+	if req.Options.Profit <= 0 && req.Ranges["profit"].From <= 0 {
+		return errors.New(`'profit' must be more than 0`)
+	}
+	switch req.Metric {
+	case "", "profit", "win_rate", "profit_factor", "sharpe", "drawdown":
+	default:
+		return errors.New(`'metric' must be 'profit', 'win_rate', 'profit_factor', 'sharpe' or 'drawdown'`)
+	}
+	if req.Search != "" && req.Search != "grid" && req.Search != "random" {
+		return errors.New(`'search' must be 'grid' or 'random'`)
+	}
+	if req.Samples < 0 || req.Workers < 0 {
+		return errors.New(`'samples' and 'workers' must not be negative`)
+	}
+	if req.File != "" && (filepath.Base(req.File) != req.File || strings.HasPrefix(req.File, ".")) {
+		return errors.New(`'file' must be a file name without directories`)
+	}
+	if req.Folds < 0 || (req.Folds > 0 && (req.TrainRatio <= 0 || req.TrainRatio >= 1)) {
+		return errors.New(`'folds' must not be negative and 'train_ratio' must be in range (0, 1)`)
+	}
+	return nil
+}
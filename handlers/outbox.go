@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+func (p *SetParams) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	status, err := p.Service.GetOutboxStatus()
+	if err != nil {
+		p.logger.WithError(err).Error("Can't get outbox status")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Can't get outbox status: "+err.Error())
+		return
+	}
+	body, err := json.Marshal(status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+func (p *SetParams) StartPairs(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var params domain.PairsParams
+	err = json.Unmarshal(body, &params)
+	if err != nil {
+		p.logger.Println("Unmarshall error")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	err = checkPairsParams(params, p.Service.GetInstrument)
+	if err != nil {
+		p.logger.WithError(err).Error("Error, while setting pairs parms")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+		return
+	}
+	err = p.Service.StartPairs(params)
+	if err != nil {
+		p.logger.WithError(err).Error("Can't start pairs trading")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Can't start pairs trading: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Pairs trading had been started\n")
+}
+
+func (p *SetParams) StopPairs(w http.ResponseWriter, r *http.Request) {
+	err := p.Service.StopPairs()
+	if err != nil {
+		p.logger.WithError(err).Error("Can't stop pairs trading")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Can't stop pairs trading: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Pairs trading had been stopped, positions are closed\n")
+}
+
+func (p *SetParams) PairsStatus(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(p.Service.GetPairsStatus())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func checkPairsParams(params domain.PairsParams, getInstrument func(string) (domain.Instrument, bool)) error {
+	if params.TickerA == params.TickerB {
+		return errors.New(`'ticker_a' and 'ticker_b' must differ`)
+	}
+	legs := [...]struct {
+		ticker string
+		size   int
+	}{{params.TickerA, params.SizeA}, {params.TickerB, params.SizeB}}
+	for _, leg := range legs {
+		inst, ok := getInstrument(leg.ticker)
+		if !ok {
+			return fmt.Errorf(`'%s' is not one of Kraken futures instruments`, leg.ticker)
+		}
+		if !inst.Tradeable {
+			return fmt.Errorf(`'%s' instrument is not tradeable now`, leg.ticker)
+		}
+		if leg.size < 1 || float32(leg.size) < inst.MinOrderSize {
+			return fmt.Errorf(`size for %s must be at least %v`, leg.ticker, inst.MinOrderSize)
+		}
+	}
+	if params.Interval < 1 {
+		return errors.New(`'interval' option must be at least 1 second`)
+	}
+	if params.Period < 2 {
+		return errors.New(`'period' option must be at least 2`)
+	}
+	if params.Entry <= 0 || params.Exit < 0 || params.Exit >= params.Entry {
+		return errors.New(`'entry' must be more than 0 and more than 'exit'`)
+	}
+	if params.StopZ != 0 && params.StopZ <= params.Entry {
+		return errors.New(`'stop_z' must be more than 'entry'`)
+	}
+	return nil
+}
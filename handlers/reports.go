@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetReport отдает текстовый отчет за текущий день или неделю файлом
+func (p *SetParams) GetReport(w http.ResponseWriter, r *http.Request) {
+	period := chi.URLParam(r, "period")
+	if period != "day" && period != "week" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Unknown period, use 'day' or 'week'\n")
+		return
+	}
+	report, err := p.Service.GetReport(period)
+	if err != nil {
+		p.logger.WithError(err).Error("Can't build report")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Can't build report: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="report-%s-%s.txt"`, period, time.Now().UTC().Format("2006-01-02")))
+	_, _ = io.WriteString(w, report)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/repository"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReportBadPeriod(t *testing.T) {
+	logger := log.New()
+	serv := service.NewRobotService(&repository.Repo{}, logger)
+	handler := NewParamsSetter(logger, serv)
+	r := chi.NewRouter()
+	r.Get("/api/reports/{period}", handler.GetReport)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/reports/month", nil))
+
+	assert.Equal(t, 400, w.Code)
+	assert.Equal(t, "Unknown period, use 'day' or 'week'\n", w.Body.String())
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+func (p *SetParams) KillSwitch(w http.ResponseWriter, r *http.Request) {
+	p.Service.KillSwitch()
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Kill switch is engaged. Open positions are closing, new orders are blocked\n")
+}
+
+func (p *SetParams) ResetKillSwitch(w http.ResponseWriter, r *http.Request) {
+	p.Service.ResetKillSwitch()
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Kill switch is reset\n")
+}
+
+func (p *SetParams) GetRisk(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(p.Service.GetRiskStatus())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (p *SetParams) SetRisk(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var limits domain.RiskLimits
+	err = json.Unmarshal(body, &limits)
+	if err != nil {
+		p.logger.Println("Unmarshall error")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	err = checkRiskLimits(limits)
+	if err != nil {
+		p.logger.WithError(err).Error("Error, while setting risk limits")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+		return
+	}
+	p.Service.SetRiskLimits(limits)
+	_, _ = io.WriteString(w, "Risk limits had been set\n")
+}
+
+func checkRiskLimits(limits domain.RiskLimits) error {
+	if limits.MaxPosition < 0 || limits.MaxExposure < 0 || limits.MaxDailyLoss < 0 || limits.MaxWeeklyLoss < 0 ||
+		limits.MaxTradesPerHour < 0 || limits.MaxConsecutiveLosses < 0 || limits.Cooldown < 0 {
+		return errors.New(`risk limits must not be negative`)
+	}
+	if limits.MaxConsecutiveLosses > 0 && limits.Cooldown == 0 {
+		return errors.New(`'cooldown' must be set together with 'max_consecutive_losses'`)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/repository"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKillSwitch(t *testing.T) {
+	// Init Dependencies
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	serv.SetParams(100, 2, 1, "PI_XBTUSD", "buy")
+	handler := NewParamsSetter(logger, serv)
+
+	// Init Endpoint
+	r := chi.NewRouter()
+	r.Post("/api/killswitch", handler.KillSwitch)
+	r.Delete("/api/killswitch", handler.ResetKillSwitch)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/killswitch", bytes.NewBufferString("")))
+	assert.Equal(t, 202, w.Code)
+	assert.Equal(t, 0, serv.GetParams().Start)
+	assert.True(t, serv.GetRiskStatus().Killed)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/killswitch", bytes.NewBufferString("")))
+	assert.Equal(t, 202, w.Code)
+	assert.False(t, serv.GetRiskStatus().Killed)
+}
+
+func TestSetRisk(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name         string
+		InBody       string
+		ExpectStCode int
+		ExpectBody   string
+		ExpectLimits domain.RiskLimits
+	}
+	tests := [...]Test{
+		{"Status OK", `{"max_position": 10, "max_daily_loss": 100}`, 200, "Risk limits had been set\n", domain.RiskLimits{MaxPosition: 10, MaxDailyLoss: 100}},
+		{"Unmarshall error", `{"max_position": "ten"}`, 400, "Json unmarshall error\n", domain.RiskLimits{MaxPosition: 10, MaxDailyLoss: 100}},
+		{"Negative limit", `{"max_position": -1}`, 400, "Bad params: risk limits must not be negative", domain.RiskLimits{MaxPosition: 10, MaxDailyLoss: 100}},
+		{"Losses without cooldown", `{"max_consecutive_losses": 3}`, 400, "Bad params: 'cooldown' must be set together with 'max_consecutive_losses'", domain.RiskLimits{MaxPosition: 10, MaxDailyLoss: 100}},
+	}
+	// Init Dependencies
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	handler := NewParamsSetter(logger, serv)
+
+	// Init Endpoint
+	r := chi.NewRouter()
+	r.Post("/api/risk", handler.SetRisk)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/risk", bytes.NewBufferString(test.InBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.ExpectStCode, w.Code)
+			assert.Equal(t, test.ExpectBody, w.Body.String())
+			assert.Equal(t, test.ExpectLimits, serv.GetRiskStatus().Limits)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// claimsKey - ключ контекста запроса, под которым Auth сохраняет проверенный токен
+type claimsKey struct{}
+
+// roleLevels - уровни ролей: роль с большим уровнем может все, что и роль с меньшим
+var roleLevels = map[string]int{
+	domain.RoleViewer: 1,
+	domain.RoleTrader: 2,
+	domain.RoleAdmin:  3,
+}
+
+func validRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RequireRole пропускает запрос, только если у пользователя роль role или выше.
+// Должен стоять после Auth. Токены без роли (выданные до появления ролей) не пропускаются
+func (p *SetParams) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tk, _ := r.Context().Value(claimsKey{}).(*TokenClaims)
+			if tk == nil || roleLevels[tk.Role] < roleLevels[role] {
+				login := ""
+				if tk != nil {
+					login = tk.Login
+				}
+				p.logger.Debugf("User %q isn't allowed to %s %s", login, r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = io.WriteString(w, fmt.Sprintf("Forbidden: %s role is required\n", role))
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/repository"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoles(t *testing.T) {
+	logger := log.New()
+	handler := NewParamsSetter(logger, service.NewRobotService(&repository.Repo{}, logger))
+	r := handler.Routes()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		role   string // пустая - запрос без токена
+		code   int
+	}{
+		{"No token", "GET", "/api/status", "", 401},
+		{"Token without role", "GET", "/api/status", "-", 403},
+		{"Viewer reads status", "GET", "/api/status", domain.RoleViewer, 200},
+		{"Viewer reads trades", "GET", "/api/trades?from=2021-12-01T00:00:00Z&to=2021-11-01T00:00:00Z", domain.RoleViewer, 400},
+		{"Viewer reads stream", "GET", "/api/stream?ticks=pi_foousd", domain.RoleViewer, 400},
+		{"Viewer can't read risk", "GET", "/api/risk", domain.RoleViewer, 403},
+		{"Viewer can't read schedules", "GET", "/api/schedules", domain.RoleViewer, 403},
+		{"Viewer can't read grid", "GET", "/api/grid", domain.RoleViewer, 403},
+		{"Viewer can't read pairs", "GET", "/api/pairs", domain.RoleViewer, 403},
+		{"Viewer can't read scripts", "GET", "/api/scripts", domain.RoleViewer, 403},
+		{"Viewer can't read outbox", "GET", "/api/outbox", domain.RoleViewer, 403},
+		{"Viewer can't read reports", "GET", "/api/reports/month", domain.RoleViewer, 403},
+		{"Viewer can't stop", "POST", "/api/stop", domain.RoleViewer, 403},
+		{"Trader reads risk", "GET", "/api/risk", domain.RoleTrader, 200},
+		{"Trader reads schedules", "GET", "/api/schedules", domain.RoleTrader, 200},
+		{"Trader reads grid", "GET", "/api/grid", domain.RoleTrader, 200},
+		{"Trader reads pairs", "GET", "/api/pairs", domain.RoleTrader, 200},
+		{"Trader reads scripts", "GET", "/api/scripts", domain.RoleTrader, 200},
+		{"Trader reads reports", "GET", "/api/reports/month", domain.RoleTrader, 400},
+		{"Trader stops", "POST", "/api/stop", domain.RoleTrader, 202},
+		{"Trader can't set risk", "POST", "/api/risk", domain.RoleTrader, 403},
+		{"Trader can't engage kill switch", "POST", "/api/killswitch", domain.RoleTrader, 403},
+		{"Trader can't manage users", "POST", "/api/users", domain.RoleTrader, 403},
+		{"Admin resets kill switch", "DELETE", "/api/killswitch", domain.RoleAdmin, 202},
+		{"Admin reads status", "GET", "/api/status", domain.RoleAdmin, 200},
+		{"Admin reads risk", "GET", "/api/risk", domain.RoleAdmin, 200},
+		{"Signals don't need token", "POST", "/api/signals", "", 503},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			switch tt.role {
+			case "":
+			case "-":
+				req.Header.Set("Authorization", "Bearer "+testToken(t, handler, ""))
+			default:
+				req.Header.Set("Authorization", "Bearer "+testToken(t, handler, tt.role))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
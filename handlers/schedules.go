@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+func (p *SetParams) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(p.Service.GetSchedules())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (p *SetParams) SetSchedule(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var sch domain.Schedule
+	err = json.Unmarshal(body, &sch)
+	if err != nil {
+		p.logger.Println("Unmarshall error")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	err = p.Service.SetSchedule(sch)
+	if err != nil {
+		p.logger.WithError(err).Error("Error, while setting schedule")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad schedule: "+err.Error())
+		return
+	}
+	_, _ = io.WriteString(w, "Schedule had been set\n")
+}
+
+func (p *SetParams) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if !p.Service.DeleteSchedule(chi.URLParam(r, "id")) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "Schedule not found\n")
+		return
+	}
+	_, _ = io.WriteString(w, "Schedule had been deleted\n")
+}
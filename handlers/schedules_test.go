@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/repository"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedules(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name         string
+		Method       string
+		Path         string
+		InBody       string
+		ExpectStCode int
+		ExpectBody   string
+	}
+	tests := [...]Test{
+		{"Set schedule", "POST", "/api/schedules", `{"id":"work", "start":"0 9 * * 1-5", "stop":"0 18 * * 1-5", "timezone":"UTC"}`, 200, "Schedule had been set\n"},
+		{"Bad cron", "POST", "/api/schedules", `{"id":"bad", "start":"0 25 * * *"}`, 400, "Bad schedule: cron field is out of range: 25"},
+		{"Bad timezone", "POST", "/api/schedules", `{"id":"bad", "start":"0 9 * * *", "timezone":"Mars/Olympus"}`, 400, "Bad schedule: unknown timezone: Mars/Olympus"},
+		{"List schedules", "GET", "/api/schedules", "", 200, `[{"id":"work","start":"0 9 * * 1-5","stop":"0 18 * * 1-5","timezone":"UTC","blackouts":null}]`},
+		{"Delete schedule", "DELETE", "/api/schedules/work", "", 200, "Schedule had been deleted\n"},
+		{"Delete unknown schedule", "DELETE", "/api/schedules/work", "", 404, "Schedule not found\n"},
+	}
+	// Init Dependencies
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	handler := NewParamsSetter(logger, serv)
+
+	// Init Endpoint
+	r := chi.NewRouter()
+	r.Get("/api/schedules", handler.GetSchedules)
+	r.Post("/api/schedules", handler.SetSchedule)
+	r.Delete("/api/schedules/{id}", handler.DeleteSchedule)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.Method, test.Path, bytes.NewBufferString(test.InBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.ExpectStCode, w.Code)
+			assert.Equal(t, test.ExpectBody, w.Body.String())
+		})
+	}
+}
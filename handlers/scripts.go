@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+func (p *SetParams) GetScripts(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(p.Service.GetScripts())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (p *SetParams) SetScript(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var sc domain.Script
+	err = json.Unmarshal(body, &sc)
+	if err != nil {
+		p.logger.Println("Unmarshall error")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	err = p.Service.SetScript(sc)
+	if err != nil {
+		p.logger.WithError(err).Error("Error, while loading script")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad script: "+err.Error())
+		return
+	}
+	_, _ = io.WriteString(w, "Script had been loaded\n")
+}
+
+func (p *SetParams) DeleteScript(w http.ResponseWriter, r *http.Request) {
+	if !p.Service.DeleteScript(chi.URLParam(r, "name")) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "Script not found\n")
+		return
+	}
+	_, _ = io.WriteString(w, "Script had been deleted\n")
+}
+
+// checkScript проверяет, что скрипт для стратегии script загружен
+func (p *SetParams) checkScript(st domain.Strategy) error {
+	if st.Name != "script" {
+		return nil
+	}
+	for _, sc := range p.Service.GetScripts() {
+		if sc.Name == st.Script {
+			return nil
+		}
+	}
+	return fmt.Errorf(`script '%s' is not loaded`, st.Script)
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/sirupsen/logrus"
@@ -15,47 +18,192 @@ import (
 type RobotService interface {
 	SetParams(start, size int, profit float32, ticker, side string)
 	SetParamsWithoutStart(size int, profit float32, ticker, side string)
+	SetSizing(sizing domain.Sizing)
+	SetLoop(loop domain.Loop)
+	SetDCA(dca domain.DCA)
+	SetStrategy(params domain.Strategy)
+	SetExits(exits domain.Exits)
+	GetStatus() domain.RobotStatus
 	GetParams() domain.Options
 	SetStart(start int)
-	GetUsersMap(string) map[string]string
+	Authenticate(login, passwd string) (domain.User, error)
+	ListUsers() ([]domain.User, error)
+	CreateUser(login, passwd, role string) error
+	SetUserDisabled(login string, disabled bool) error
+	SetUserRole(login, role string) error
+	ResetPassword(login, passwd string) error
+	GetUser(login string) (domain.User, error)
+	RevokeToken(id string, expires time.Time) error
+	IsTokenRevoked(id string) bool
+	GetInstrument(ticker string) (domain.Instrument, bool)
+	KillSwitch()
+	ResetKillSwitch()
+	SetRiskLimits(limits domain.RiskLimits)
+	GetRiskStatus() domain.RiskStatus
+	GetTotalProfit() (float32, error)
+	GetOutboxStatus() (domain.OutboxStatus, error)
+	GetReport(period string) (string, error)
+	ListTrades(from, to time.Time) ([]domain.Trade, error)
+	WatchEvents() (chan domain.Event, func())
+	WatchTicks(ticker string) (chan domain.WsResponse, func(), error)
+	SetSchedule(sch domain.Schedule) error
+	DeleteSchedule(id string) bool
+	GetSchedules() []domain.Schedule
+	CheckStart(trading string) error
+	StartGrid(params domain.GridParams) error
+	StopGrid() error
+	GetGridStatus() domain.GridStatus
+	StartPairs(params domain.PairsParams) error
+	StopPairs() error
+	GetPairsStatus() domain.PairsStatus
+	SetScript(sc domain.Script) error
+	DeleteScript(name string) bool
+	GetScripts() []domain.Script
+	Optimize(req domain.OptimizeRequest) (domain.OptimizeReport, error)
+	HandleSignal(sig domain.Signal) error
 }
 
 type SetParams struct {
-	Service RobotService
-	logger  logrus.FieldLogger
+	Service      RobotService
+	logger       logrus.FieldLogger
+	signalSecret []byte
+	keys         tokenKeys
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
 }
 
+// NewParamsSetter подписывает токены случайным секретом, пока не задан постоянный через SetJWTSecret или SetJWTRSAKey
 func NewParamsSetter(logger logrus.FieldLogger, service RobotService) *SetParams {
+	secret := randomSecret()
 	return &SetParams{
-		Service: service,
-		logger:  logger,
+		Service:    service,
+		logger:     logger,
+		keys:       tokenKeys{jwt.SigningMethodHS256, secret, secret},
+		accessTTL:  defaultAccessTTL,
+		refreshTTL: defaultRefreshTTL,
 	}
 }
 
 func (p *SetParams) Routes() chi.Router {
 	root := chi.NewRouter()
-	root.Use(middleware.Logger)
+	root.Use(hideToken, middleware.Logger)
 
 	root.HandleFunc("/login", p.Login)
+	root.Post("/refresh", p.Refresh)
+	root.Post("/logout", p.Logout)
+	root.Get("/ui", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ui/", http.StatusMovedPermanently)
+	})
+	root.Handle("/ui/*", UI())
 
 	r := chi.NewRouter()
-	// r.Use(p.Auth)
-	r.Post("/", p.SetAndStart)
-	r.Post("/set", p.SetParam)
-	r.Post("/start", p.Start)
-	r.Post("/stop", p.Stop)
+	// Сигналы подписываются HMAC на общем секрете, JWT для них не нужен
+	r.Post("/signals", p.Signal)
+	// Поток читается из браузера, где токен можно передать только параметром
+	r.Group(func(r chi.Router) {
+		r.Use(p.StreamAuth, p.RequireRole(domain.RoleViewer))
+		r.Get("/stream", p.Stream)
+		r.Get("/stream/ws", p.StreamWS)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(p.Auth)
+		r.Group(func(r chi.Router) {
+			r.Use(p.RequireRole(domain.RoleViewer))
+			r.Get("/status", p.Status)
+			r.Get("/trades", p.GetTrades)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(p.RequireRole(domain.RoleTrader))
+			r.Get("/risk", p.GetRisk)
+			r.Get("/schedules", p.GetSchedules)
+			r.Get("/grid", p.GridStatus)
+			r.Get("/pairs", p.PairsStatus)
+			r.Get("/scripts", p.GetScripts)
+			r.Get("/outbox", p.GetOutbox)
+			r.Get("/reports/{period}", p.GetReport)
+			r.Post("/", p.SetAndStart)
+			r.Post("/set", p.SetParam)
+			r.Post("/start", p.Start)
+			r.Post("/stop", p.Stop)
+			r.Post("/schedules", p.SetSchedule)
+			r.Delete("/schedules/{id}", p.DeleteSchedule)
+			r.Post("/grid", p.StartGrid)
+			r.Delete("/grid", p.StopGrid)
+			r.Post("/pairs", p.StartPairs)
+			r.Delete("/pairs", p.StopPairs)
+			r.Post("/scripts", p.SetScript)
+			r.Delete("/scripts/{name}", p.DeleteScript)
+			r.Post("/optimize", p.Optimize)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(p.RequireRole(domain.RoleAdmin))
+			r.Post("/risk", p.SetRisk)
+			r.Post("/killswitch", p.KillSwitch)
+			r.Delete("/killswitch", p.ResetKillSwitch)
+			r.Get("/users", p.GetUsers)
+			r.Post("/users", p.CreateUser)
+			r.Post("/users/{login}/disable", p.DisableUser)
+			r.Post("/users/{login}/enable", p.EnableUser)
+			r.Put("/users/{login}/role", p.SetUserRole)
+			r.Put("/users/{login}/password", p.ResetPassword)
+		})
+	})
 	root.Mount("/api", r)
 
 	return root
 }
 
-func (p *SetParams) Start(w http.ResponseWriter, r *http.Request) {
+// checkStart проверяет текущие параметры и состояние робота перед стартом
+func (p *SetParams) checkStart() error {
 	par := p.Service.GetParams()
-	err := checkInput(par)
+	err := checkInput(par, p.Service.GetInstrument)
+	if err == nil {
+		err = p.checkScript(par.Strategy)
+	}
 	if err != nil {
+		return err
+	}
+	return p.checkStartAllowed()
+}
+
+// checkStartAllowed - проверки запуска, которые не зависят от параметров: kill switch, сетка, пары и расписание
+func (p *SetParams) checkStartAllowed() error {
+	return p.Service.CheckStart(domain.TradingRobot)
+}
+
+// startConflict - true, если запуск отклонен из-за состояния робота, а не из-за параметров
+func startConflict(err error) bool {
+	return errors.Is(err, domain.ErrKillSwitch) || errors.Is(err, domain.ErrGridActive) || errors.Is(err, domain.ErrPairsActive) ||
+		errors.Is(err, domain.ErrTradingNotAllowed)
+}
+
+// writeStartError отвечает на ошибку проверки запуска. Возвращает false, если ошибки нет
+func (p *SetParams) writeStartError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, domain.ErrKillSwitch):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Kill switch is engaged, reset it before starting the robot\n")
+	case errors.Is(err, domain.ErrGridActive):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Grid strategy is active, stop it before starting the robot\n")
+	case errors.Is(err, domain.ErrPairsActive):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Pairs trading is active, stop it before starting the robot\n")
+	case errors.Is(err, domain.ErrTradingNotAllowed):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Trading is not allowed now by schedule\n")
+	case err != nil:
 		p.logger.WithError(err).Error("Error, while setting parm's")
 		w.WriteHeader(http.StatusBadRequest)
 		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func (p *SetParams) Start(w http.ResponseWriter, r *http.Request) {
+	if p.writeStartError(w, p.checkStart()) {
 		return
 	}
 	p.Service.SetStart(1)
@@ -86,14 +234,23 @@ func (p *SetParams) SetAndStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = checkInputWithStart(options)
-	if err != nil {
-		p.logger.WithError(err).Error("Error, while setting parms")
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+	err = checkInputWithStart(options, p.Service.GetInstrument)
+	if err == nil {
+		err = p.checkScript(options.Strategy)
+	}
+	// Запуск через /api проходит те же проверки, что и /api/start
+	if err == nil && options.Start == 1 {
+		err = p.checkStartAllowed()
+	}
+	if p.writeStartError(w, err) {
 		return
 	}
 	_, _ = io.WriteString(w, "Parameters had been set\n")
+	p.Service.SetSizing(options.Sizing)
+	p.Service.SetLoop(options.Loop)
+	p.Service.SetDCA(options.DCA)
+	p.Service.SetStrategy(options.Strategy)
+	p.Service.SetExits(options.Exits)
 	p.Service.SetParams(options.Start, options.Size, options.Profit, options.Ticker, options.Side)
 }
 
@@ -113,7 +270,10 @@ func (p *SetParams) SetParam(w http.ResponseWriter, r *http.Request) {
 		_, _ = io.WriteString(w, "Json unmarshall error\n")
 		return
 	}
-	err = checkInput(options)
+	err = checkInput(options, p.Service.GetInstrument)
+	if err == nil {
+		err = p.checkScript(options.Strategy)
+	}
 	if err != nil {
 		p.logger.WithError(err).Error("Error, while setting parm's")
 		w.WriteHeader(http.StatusBadRequest)
@@ -121,34 +281,161 @@ func (p *SetParams) SetParam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	_, _ = io.WriteString(w, "Parameters had been set\n")
+	p.Service.SetSizing(options.Sizing)
+	p.Service.SetLoop(options.Loop)
+	p.Service.SetDCA(options.DCA)
+	p.Service.SetStrategy(options.Strategy)
+	p.Service.SetExits(options.Exits)
 	p.Service.SetParamsWithoutStart(options.Size, options.Profit, options.Ticker, options.Side)
 }
 
+func (p *SetParams) Status(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(p.Service.GetStatus())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
 func (p *SetParams) Getparams() domain.Options {
 	return p.Service.GetParams()
 }
 
-func checkInput(opt domain.Options) error {
+func checkInput(opt domain.Options, getInstrument func(string) (domain.Instrument, bool)) error {
 	if opt.Side != "buy" && opt.Side != "sell" && opt.Side != "" {
 		return errors.New(`'side' option must be 'buy' or 'sell'`)
 	}
-	if opt.Size < 1 {
-		return errors.New(`'size' option must be more than 0`)
+	err := checkSizing(opt)
+	if err != nil {
+		return err
 	}
-	if opt.Profit <= 0 {
+	// Если заданы обе цены выхода, "profit" не используется
+	if opt.Profit <= 0 && (opt.StopPrice == 0 || opt.TargetPrice == 0) {
 		return errors.New(`'profit' must be more than 0`)
 	}
-	if opt.Ticker != "PI_XBTUSD" && opt.Ticker != "PI_ETHUSD" && opt.Ticker != "PI_LTCUSD" && opt.Ticker != "PI_XRPUSD" && opt.Ticker != "PI_BCHUSD" {
-		return errors.New(`'ticker' option must be 'PI_XBTUSD' or 'PI_ETHUSD' or 'PI_LTCUSD' or 'PI_XRPUSD' or 'PI_BCHUSD'`)
+	if opt.Cycles < 0 || opt.LoopCooldown < 0 || opt.TargetPnL < 0 || opt.LossLimit < 0 {
+		return errors.New(`loop options must not be negative`)
+	}
+	err = checkDCA(opt.DCA)
+	if err != nil {
+		return err
+	}
+	err = checkStrategy(opt.Strategy)
+	if err != nil {
+		return err
+	}
+	err = checkExits(opt.Side, opt.Exits)
+	if err != nil {
+		return err
+	}
+	inst, ok := getInstrument(opt.Ticker)
+	if !ok {
+		return errors.New(`'ticker' option must be one of Kraken futures instruments`)
+	}
+	if !inst.Tradeable {
+		return errors.New(`'ticker' instrument is not tradeable now`)
+	}
+	if (opt.SizeMode == "" || opt.SizeMode == "contracts") && float32(opt.Size) < inst.MinOrderSize {
+		return fmt.Errorf(`'size' option must be at least %v for %s`, inst.MinOrderSize, inst.Symbol)
+	}
+	return nil
+}
+
+func checkSizing(opt domain.Options) error {
+	switch opt.SizeMode {
+	case "", "contracts":
+		if opt.Size < 1 {
+			return errors.New(`'size' option must be more than 0`)
+		}
+	case "notional":
+		if opt.Notional <= 0 {
+			return errors.New(`'notional' option must be more than 0`)
+		}
+	case "equity":
+		if opt.EquityPercent <= 0 || opt.EquityPercent > 100 {
+			return errors.New(`'equity_percent' option must be in range (0, 100]`)
+		}
+	case "risk":
+		if opt.Risk <= 0 {
+			return errors.New(`'risk' option must be more than 0`)
+		}
+		if opt.AtrPeriod < 0 {
+			return errors.New(`'atr_period' option must not be negative`)
+		}
+		if opt.AtrResolution != "" && !resolutions[opt.AtrResolution] {
+			return errors.New(`'atr_resolution' option must be one of 1m, 5m, 15m, 30m, 1h, 4h, 12h, 1d, 1w`)
+		}
+	default:
+		return errors.New(`'size_mode' option must be 'contracts', 'notional', 'equity' or 'risk'`)
+	}
+	return nil
+}
+
+func checkDCA(dca domain.DCA) error {
+	if dca.SafetyOrders < 0 {
+		return errors.New(`'safety_orders' option must not be negative`)
+	}
+	if dca.SafetyOrders > 0 && dca.Deviation <= 0 {
+		return errors.New(`'deviation' option must be more than 0`)
+	}
+	if dca.StepScale < 0 || dca.SizeMultiplier < 0 {
+		return errors.New(`'step_scale' and 'size_multiplier' options must not be negative`)
+	}
+	return nil
+}
+
+func checkExits(side string, exits domain.Exits) error {
+	if exits.StopPrice < 0 || exits.TargetPrice < 0 {
+		return errors.New(`'stop_price' and 'target_price' must not be negative`)
+	}
+	if exits.StopPrice == 0 && exits.TargetPrice == 0 {
+		return nil
+	}
+	// Цены выхода имеют смысл только при заданном направлении сделки
+	if side == "" {
+		return errors.New(`'side' must be set together with 'stop_price' or 'target_price'`)
+	}
+	if exits.StopPrice == 0 || exits.TargetPrice == 0 {
+		return nil
+	}
+	if (side == "buy" && exits.StopPrice >= exits.TargetPrice) || (side == "sell" && exits.StopPrice <= exits.TargetPrice) {
+		return errors.New(`'stop_price' must be on the loss side of 'target_price'`)
+	}
+	return nil
+}
+
+func checkStrategy(st domain.Strategy) error {
+	switch st.Name {
+	case "", "midpoint":
+		return nil
+	case "bollinger":
+		if st.StdDev <= 0 {
+			return errors.New(`'std_dev' option must be more than 0`)
+		}
+	case "donchian":
+	case "script":
+		if st.Script == "" {
+			return errors.New(`'script' option must be set for 'script' strategy`)
+		}
+	default:
+		return errors.New(`'strategy' option must be 'midpoint', 'bollinger', 'donchian' or 'script'`)
+	}
+	if st.Interval < 1 {
+		return errors.New(`'interval' option must be at least 1 second`)
+	}
+	if st.Period < 2 {
+		return errors.New(`'period' option must be at least 2`)
 	}
 	return nil
 }
 
-func checkInputWithStart(opt domain.Options) error {
+func checkInputWithStart(opt domain.Options, getInstrument func(string) (domain.Instrument, bool)) error {
 	if opt.Start != 0 && opt.Start != 1 {
 		return errors.New(`'start' option must be '1' or '0'`)
 	}
-	err := checkInput(opt)
+	err := checkInput(opt, getInstrument)
 	if err != nil {
 		return err
 	}
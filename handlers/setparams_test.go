@@ -80,6 +80,55 @@ func TestSetAndStart(t *testing.T) {
 	}
 }
 
+func TestSetAndStartBlackout(t *testing.T) {
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	err := serv.SetSchedule(domain.Schedule{ID: "night", Start: "0 9 * * *", Blackouts: []domain.Blackout{{From: "00:00", To: "24:00"}}})
+	assert.NoError(t, err)
+	handler := NewParamsSetter(logger, serv)
+
+	r := chi.NewRouter()
+	r.Post("/api/", handler.SetAndStart)
+
+	// Запуск через /api проверяет расписание так же, как /api/start
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"start":1, "ticker":"PI_XBTUSD", "size":2, "profit":0.05, "side":"buy"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 409, w.Code)
+	assert.Equal(t, "Trading is not allowed now by schedule\n", w.Body.String())
+	assert.Equal(t, 0, serv.GetParams().Start)
+
+	// Параметры без запуска задаются и во время запрета
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"start":0, "ticker":"PI_XBTUSD", "size":2, "profit":0.05, "side":"buy"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestStartKilled(t *testing.T) {
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	serv.SetParamsWithoutStart(2, 0.05, "PI_XBTUSD", "buy")
+	serv.KillSwitch()
+	handler := NewParamsSetter(logger, serv)
+
+	r := chi.NewRouter()
+	r.Post("/api/start", handler.Start)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/start", bytes.NewBufferString("")))
+	assert.Equal(t, 409, w.Code)
+	assert.Equal(t, "Kill switch is engaged, reset it before starting the robot\n", w.Body.String())
+	assert.Equal(t, 0, serv.GetParams().Start)
+
+	serv.ResetKillSwitch()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/start", bytes.NewBufferString("")))
+	assert.Equal(t, 202, w.Code)
+}
+
 func TestSetParam(t *testing.T) {
 	// Test Table
 	type Test struct {
@@ -93,6 +142,16 @@ func TestSetParam(t *testing.T) {
 		{"Unmarshall error", `{"side":2}`, 400, "Json unmarshall error\n"}, //
 		{"Size error", `{"start":1, "ticker":"PI_XBTUSD", "size":-2, "profit":0.05, "side":"buy"}`, 400, "Bad params: 'size' option must be more than 0"},
 		{"Profit param error", `{"start":1, "ticker":"PI_XBTUSD", "size":2, "profit":-0.05, "side":"buy"}`, 400, "Bad params: 'profit' must be more than 0"},
+		{"Notional size mode", `{"ticker":"PI_XBTUSD", "size_mode":"notional", "notional":500, "profit":0.05, "side":"buy"}`, 200, "Parameters had been set\n"},
+		{"Size mode error", `{"ticker":"PI_XBTUSD", "size_mode":"risk", "profit":0.05, "side":"buy"}`, 400, "Bad params: 'risk' option must be more than 0"},
+		{"DCA params", `{"ticker":"PI_XBTUSD", "size":1, "profit":0.5, "safety_orders":3, "deviation":1, "size_multiplier":2}`, 200, "Parameters had been set\n"},
+		{"Exit prices", `{"ticker":"PI_XBTUSD", "size":1, "side":"buy", "stop_price":48000, "target_price":52000}`, 200, "Parameters had been set\n"},
+		{"Exit prices error", `{"ticker":"PI_XBTUSD", "size":1, "side":"sell", "stop_price":48000, "target_price":52000}`, 400, "Bad params: 'stop_price' must be on the loss side of 'target_price'"},
+		{"DCA deviation error", `{"ticker":"PI_XBTUSD", "size":1, "profit":0.5, "safety_orders":3}`, 400, "Bad params: 'deviation' option must be more than 0"},
+		{"Bollinger strategy", `{"ticker":"PI_XBTUSD", "size":1, "profit":0.5, "strategy":"bollinger", "interval":60, "period":20, "std_dev":2}`, 200, "Parameters had been set\n"},
+		{"Strategy name error", `{"ticker":"PI_XBTUSD", "size":1, "profit":0.5, "strategy":"macd"}`, 400, "Bad params: 'strategy' option must be 'midpoint', 'bollinger', 'donchian' or 'script'"},
+		{"Script is not loaded", `{"ticker":"PI_XBTUSD", "size":1, "profit":0.5, "strategy":"script", "script":"rsi", "interval":60, "period":20}`, 400, "Bad params: script 'rsi' is not loaded"},
+		{"Strategy period error", `{"ticker":"PI_XBTUSD", "size":1, "profit":0.5, "strategy":"donchian", "interval":60}`, 400, "Bad params: 'period' option must be at least 2"},
 	}
 	// Init Dependencies
 	logger := log.New()
@@ -131,7 +190,7 @@ func TestStart(t *testing.T) {
 	}
 	tests := [...]Test{
 		{Name: "Status Accepted", In: domain.Options{Start: 10, Ticker: "PI_XBTUSD", Size: 1, Profit: 0.1, Side: "buy"}, ExpectStCode: 202, ExpectParamSt: 1, ExpectBody: "The signal to start had been sent\n"},
-		{Name: "Bad Params", In: domain.Options{Start: 10, Ticker: "Wrong_Ticker", Size: 1, Profit: 0.1, Side: "buy"}, ExpectStCode: 400, ExpectParamSt: 10, ExpectBody: "Bad params: 'ticker' option must be 'PI_XBTUSD' or 'PI_ETHUSD' or 'PI_LTCUSD' or 'PI_XRPUSD' or 'PI_BCHUSD'"},
+		{Name: "Bad Params", In: domain.Options{Start: 10, Ticker: "Wrong_Ticker", Size: 1, Profit: 0.1, Side: "buy"}, ExpectStCode: 400, ExpectParamSt: 10, ExpectBody: "Bad params: 'ticker' option must be one of Kraken futures instruments"},
 	}
 	// Init Dependencies
 	logger := log.New()
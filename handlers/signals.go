@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// signatureHeader - заголовок с HMAC-SHA256 тела запроса в hex, посчитанным на общем секрете
+const signatureHeader = "X-Signature"
+
+// SetSignalSecret задает общий секрет для подписи сигналов. Пока секрет пуст, сигналы не принимаются
+func (p *SetParams) SetSignalSecret(secret string) {
+	p.signalSecret = []byte(secret)
+}
+
+func (p *SetParams) Signal(w http.ResponseWriter, r *http.Request) {
+	if len(p.signalSecret) == 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Signals are disabled\n")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	if !validSignature(p.signalSecret, body, r.Header.Get(signatureHeader)) {
+		p.logger.Println("Signal with invalid signature")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, "Invalid signature\n")
+		return
+	}
+	var sig domain.Signal
+	err = json.Unmarshal(body, &sig)
+	if err != nil {
+		p.logger.Println("Unmarshall error")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+
+	err = p.Service.HandleSignal(sig)
+	if err != nil {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "Signal rejected: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Signal had been accepted\n")
+}
+
+func validSignature(secret, body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/repository"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignal(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name         string
+		Secret       string
+		InBody       string
+		Signature    string
+		ExpectStCode int
+		ExpectBody   string
+	}
+	tests := [...]Test{
+		{"Signals are disabled", "", `{"id": "1"}`, sign("", `{"id": "1"}`), 503, "Signals are disabled\n"},
+		{"No signature", "secret", `{"id": "1"}`, "", 401, "Invalid signature\n"},
+		{"Wrong secret", "secret", `{"id": "1"}`, sign("other", `{"id": "1"}`), 401, "Invalid signature\n"},
+		{"Body changed", "secret", `{"id": "2"}`, sign("secret", `{"id": "1"}`), 401, "Invalid signature\n"},
+		{"Unmarshall error", "secret", `{"id": 1}`, sign("secret", `{"id": 1}`), 400, "Json unmarshall error\n"},
+	}
+	// Init Dependencies
+	logger := log.New()
+	rep := &repository.Repo{}
+	serv := service.NewRobotService(rep, logger)
+	handler := NewParamsSetter(logger, serv)
+
+	// Init Endpoint
+	r := chi.NewRouter()
+	r.Post("/api/signals", handler.Signal)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			handler.SetSignalSecret(test.Secret)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/signals", bytes.NewBufferString(test.InBody))
+			req.Header.Set("X-Signature", test.Signature)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.ExpectStCode, w.Code)
+			assert.Equal(t, test.ExpectBody, w.Body.String())
+		})
+	}
+}
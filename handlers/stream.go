@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// pnlPeriod - как часто проверяется, изменилась ли прибыль
+	pnlPeriod = 5 * time.Second
+	// pingPeriod - как часто в поток без сообщений отправляется пустое сообщение, чтобы прокси не закрывали соединение
+	pingPeriod = 15 * time.Second
+)
+
+// streamMessage - сообщение потока: tick, state, event или pnl
+type streamMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+type tickMessage struct {
+	Ticker string  `json:"ticker"`
+	Bid    float32 `json:"bid"`
+	Ask    float32 `json:"ask"`
+	Time   int64   `json:"time"` // время тика на бирже, unix время в миллисекундах
+}
+
+type pnlMessage struct {
+	Daily  float32 `json:"daily"`
+	Weekly float32 `json:"weekly"`
+	Cycle  float32 `json:"cycle"`
+}
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// queryTokenKey - ключ контекста запроса, под которым hideToken сохраняет токен из параметра token
+type queryTokenKey struct{}
+
+// hideToken убирает параметр token из адреса запроса, чтобы токен не попал в лог запросов.
+// Должен стоять перед middleware.Logger. Токен сохраняется в контексте запроса для StreamAuth
+func hideToken(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if !q.Has("token") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		token := q.Get("token")
+		q.Del("token")
+		r = r.WithContext(context.WithValue(r.Context(), queryTokenKey{}, token))
+		u := *r.URL
+		u.RawQuery = q.Encode()
+		r.URL = &u
+		r.RequestURI = u.RequestURI()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// StreamAuth проверяет тот же JWT, что и REST API. Браузер не может задать заголовок для EventSource
+// и WebSocket, поэтому токен можно передать параметром token
+func (p *SetParams) StreamAuth(handler http.Handler) http.Handler {
+	auth := p.Auth(handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := r.Context().Value(queryTokenKey{}).(string)
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		auth.ServeHTTP(w, r)
+	})
+}
+
+// streamTickers - инструменты из параметра ticks через запятую, по умолчанию - инструмент робота
+func (p *SetParams) streamTickers(r *http.Request) []string {
+	var res []string
+	for _, t := range strings.Split(r.URL.Query().Get("ticks"), ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			res = append(res, t)
+		}
+	}
+	if len(res) == 0 && p.Service.GetParams().Ticker != "" {
+		res = append(res, p.Service.GetParams().Ticker)
+	}
+	return res
+}
+
+// subscribe собирает в один канал тики инструментов, события робота и изменения прибыли.
+// Первыми отправляются текущее состояние и прибыль. Подписки закрываются, когда отменяется ctx.
+// Тики инструмента приходят из общего для всех подписчиков соединения с биржей
+func (p *SetParams) subscribe(ctx context.Context, tickers []string) (chan streamMessage, error) {
+	type tickSub struct {
+		ch     chan domain.WsResponse
+		cancel func()
+	}
+	var subs []tickSub
+	for _, ticker := range tickers {
+		ch, cancel, err := p.Service.WatchTicks(ticker)
+		if err != nil {
+			for _, s := range subs {
+				s.cancel()
+			}
+			return nil, err
+		}
+		subs = append(subs, tickSub{ch, cancel})
+	}
+	events, cancelEvents := p.Service.WatchEvents()
+
+	out := make(chan streamMessage, 16)
+	send := func(msg streamMessage) bool {
+		select {
+		case out <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for _, s := range subs {
+		go func(ticks chan domain.WsResponse, cancel func()) {
+			defer cancel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case tick, ok := <-ticks:
+					if !ok || !send(streamMessage{"tick", tickMessage{strings.ToUpper(tick.ProductID), tick.Bid, tick.Ask, tickTime(tick)}}) {
+						return
+					}
+				}
+			}
+		}(s.ch, s.cancel)
+	}
+	go func() {
+		defer cancelEvents()
+		pnl := p.pnl()
+		if !send(streamMessage{"state", p.Service.GetStatus()}) || !send(streamMessage{"pnl", pnl}) {
+			return
+		}
+		ticker := time.NewTicker(pnlPeriod)
+		defer ticker.Stop()
+		for {
+			var msg streamMessage
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-events:
+				msg = streamMessage{"event", ev}
+				if ev.Type == domain.EventStateChanged {
+					msg = streamMessage{"state", p.Service.GetStatus()}
+				}
+			case <-ticker.C:
+				cur := p.pnl()
+				if cur == pnl {
+					continue
+				}
+				pnl = cur
+				msg = streamMessage{"pnl", pnl}
+			}
+			if !send(msg) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (p *SetParams) pnl() pnlMessage {
+	risk := p.Service.GetRiskStatus()
+	return pnlMessage{Daily: risk.DailyPnL, Weekly: risk.WeeklyPnL, Cycle: p.Service.GetStatus().PnL}
+}
+
+// Stream передает сообщения в формате Server-Sent Events, пока клиент не закроет соединение
+func (p *SetParams) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, "Streaming is not supported\n")
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	messages, err := p.subscribe(ctx, p.streamTickers(r))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error()+"\n")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ping := time.NewTicker(pingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			_, err = io.WriteString(w, ": ping\n\n")
+		case msg := <-messages:
+			var data []byte
+			data, err = json.Marshal(msg.Data)
+			if err == nil {
+				_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, data)
+			}
+		}
+		if err != nil {
+			p.logger.WithError(err).Debug("Stream is closed")
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// StreamWS передает те же сообщения через WebSocket в виде {"type": "tick", "data": {...}}
+func (p *SetParams) StreamWS(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	messages, err := p.subscribe(ctx, p.streamTickers(r))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error()+"\n")
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		p.logger.WithError(err).Error("Can't upgrade to websocket")
+		return
+	}
+	defer conn.Close()
+
+	// Сообщения от клиента не ожидаются, чтение нужно, чтобы узнать о закрытии соединения
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	ping := time.NewTicker(pingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second))
+		case msg := <-messages:
+			err = conn.WriteJSON(msg)
+		}
+		if err != nil {
+			p.logger.WithError(err).Debug("Stream is closed")
+			return
+		}
+	}
+}
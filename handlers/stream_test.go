@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func testToken(t *testing.T, p *SetParams, role string) string {
+	token, err := p.issueToken(domain.User{Login: "jlexie", Role: role}, tokenAccess, time.Hour)
+	assert.NoError(t, err)
+	return token
+}
+
+func streamServer(t *testing.T) (*httptest.Server, *SetParams, chan domain.WsResponse) {
+	c := gomock.NewController(t)
+	t.Cleanup(c.Finish)
+	repo := mock_service.NewMockrepoInterface(c)
+	ticks := make(chan domain.WsResponse)
+	repo.EXPECT().SetWSConnection("wss://demo-futures.kraken.com/ws/v1", "PI_XBTUSD").Return(ticks, func() {}, nil).AnyTimes()
+
+	logger := log.New()
+	handler := NewParamsSetter(logger, service.NewRobotService(repo, logger))
+	srv := httptest.NewServer(handler.Routes())
+	t.Cleanup(srv.Close)
+	return srv, handler, ticks
+}
+
+func TestStreamSSE(t *testing.T) {
+	srv, handler, ticks := streamServer(t)
+
+	resp, err := http.Get(srv.URL + "/api/stream")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/api/stream?ticks=pi_foousd&token=" + testToken(t, handler, domain.RoleViewer))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/stream?ticks=pi_xbtusd", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, handler, domain.RoleViewer))
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	go func() {
+		ticks <- domain.WsResponse{ProductID: "PI_XBTUSD", Bid: 50000, Ask: 50001, Time: 1638316800000}
+	}()
+	events := map[string]string{}
+	scanner := bufio.NewScanner(resp.Body)
+	var event string
+	for len(events) < 3 && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			events[event] = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	assert.Contains(t, events["state"], `"state":"stopped"`)
+	assert.JSONEq(t, `{"daily": 0, "weekly": 0, "cycle": 0}`, events["pnl"])
+	var tick tickMessage
+	assert.NoError(t, json.Unmarshal([]byte(events["tick"]), &tick))
+	assert.Equal(t, "PI_XBTUSD", tick.Ticker)
+	assert.Equal(t, float32(50001), tick.Ask)
+	assert.Equal(t, int64(1638316800000), tick.Time)
+}
+
+func TestStreamSharedFeed(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	// Все потоки одного инструмента получают тики из одного соединения с биржей
+	ticks := make(chan domain.WsResponse)
+	repo.EXPECT().SetWSConnection("wss://demo-futures.kraken.com/ws/v1", "PI_XBTUSD").Return(ticks, func() {}, nil)
+	logger := log.New()
+	handler := NewParamsSetter(logger, service.NewRobotService(repo, logger))
+	srv := httptest.NewServer(handler.Routes())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got := make(chan string, 100)
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/stream?ticks=PI_XBTUSD&token="+testToken(t, handler, domain.RoleViewer), nil)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		go func(i int) {
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if scanner.Text() == "event: tick" {
+					got <- fmt.Sprint(i)
+					return
+				}
+			}
+		}(i)
+	}
+	received := map[string]bool{}
+	for len(received) < 2 {
+		select {
+		case ticks <- domain.WsResponse{ProductID: "PI_XBTUSD", Bid: 50000, Ask: 50001}:
+		case i := <-got:
+			received[i] = true
+		case <-ctx.Done():
+			t.Fatal("ticks weren't received")
+		}
+	}
+}
+
+// logLines - лог запросов, каждая запись передается в канал
+type logLines chan string
+
+func (l logLines) Write(b []byte) (int, error) {
+	l <- string(b)
+	return len(b), nil
+}
+
+func TestHideToken(t *testing.T) {
+	// Токен из параметра не попадает в лог запросов
+	lines := make(logLines, 10)
+	defaultLogger := middleware.DefaultLogger
+	middleware.DefaultLogger = middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: stdlog.New(lines, "", 0), NoColor: true})
+	defer func() { middleware.DefaultLogger = defaultLogger }()
+	srv, handler, _ := streamServer(t)
+
+	token := testToken(t, handler, domain.RoleViewer)
+	resp, err := http.Get(srv.URL + "/api/stream?ticks=pi_foousd&token=" + token)
+	assert.NoError(t, err)
+	// Токен из параметра по-прежнему принимается
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+	line := <-lines
+	assert.Contains(t, line, "/api/stream?ticks=pi_foousd ")
+	assert.NotContains(t, line, token)
+}
+
+func TestStreamWS(t *testing.T) {
+	srv, handler, _ := streamServer(t)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/stream/ws?ticks=PI_XBTUSD&token="
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"bad", nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+testToken(t, handler, domain.RoleViewer), nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	var msg struct {
+		Type string             `json:"type"`
+		Data domain.RobotStatus `json:"data"`
+	}
+	assert.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, "state", msg.Type)
+	assert.Equal(t, "stopped", msg.Data.State)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 7 * 24 * time.Hour
+	// minSecretLen - минимальная длина секрета HS256, 256 бит
+	minSecretLen = 32
+
+	tokenAccess  = "access"
+	tokenRefresh = "refresh"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// tokenKeys - ключи подписи и проверки токенов. Auth принимает только токены, подписанные алгоритмом method
+type tokenKeys struct {
+	method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// tokenResponse - ответ /login и /refresh
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // время жизни access токена в секундах
+}
+
+func randomSecret() []byte {
+	b := make([]byte, minSecretLen)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func randomID() string {
+	return hex.EncodeToString(randomSecret()[:16])
+}
+
+// SetJWTSecret задает секрет HS256
+func (p *SetParams) SetJWTSecret(secret string) error {
+	if len(secret) < minSecretLen {
+		return fmt.Errorf("JWT secret must be at least %d bytes long", minSecretLen)
+	}
+	p.keys = tokenKeys{jwt.SigningMethodHS256, []byte(secret), []byte(secret)}
+	return nil
+}
+
+// SetJWTRSAKey переключает подпись на RS256: токены подписываются закрытым ключом, проверяются открытым
+func (p *SetParams) SetJWTRSAKey(privatePEM []byte) error {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+	if err != nil {
+		return err
+	}
+	p.keys = tokenKeys{jwt.SigningMethodRS256, key, &key.PublicKey}
+	return nil
+}
+
+// SetTokenTTL задает время жизни access и refresh токенов, нулевое значение оставляет текущее
+func (p *SetParams) SetTokenTTL(access, refresh time.Duration) {
+	if access > 0 {
+		p.accessTTL = access
+	}
+	if refresh > 0 {
+		p.refreshTTL = refresh
+	}
+}
+
+func (p *SetParams) issueToken(user domain.User, typ string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return jwt.NewWithClaims(p.keys.method, &TokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        randomID(),
+			ExpiresAt: now.Add(ttl).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		Login: user.Login,
+		Role:  user.Role,
+		Type:  typ,
+	}).SignedString(p.keys.sign)
+}
+
+func (p *SetParams) issueTokens(user domain.User) (tokenResponse, error) {
+	access, err := p.issueToken(user, tokenAccess, p.accessTTL)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	refresh, err := p.issueToken(user, tokenRefresh, p.refreshTTL)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	return tokenResponse{access, refresh, "Bearer", int64(p.accessTTL / time.Second)}, nil
+}
+
+// parseToken проверяет подпись, алгоритм, срок действия, тип и отзыв токена
+func (p *SetParams) parseToken(token, typ string) (*TokenClaims, error) {
+	tk := &TokenClaims{}
+	parser := jwt.Parser{ValidMethods: []string{p.keys.method.Alg()}}
+	_, err := parser.ParseWithClaims(token, tk, func(*jwt.Token) (interface{}, error) {
+		return p.keys.verify, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// jwt-go не требует exp, токен без срока действия не принимается
+	if tk.ExpiresAt == 0 || tk.Id == "" || tk.Type != typ || p.Service.IsTokenRevoked(tk.Id) {
+		return nil, errInvalidToken
+	}
+	return tk, nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// GetTrades отдает сделки за период from - to в формате RFC3339. По умолчанию - с начала текущего дня
+func (p *SetParams) GetTrades(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	from, to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), now
+	var err error
+	if s := r.URL.Query().Get("from"); s != "" {
+		from, err = time.Parse(time.RFC3339, s)
+	}
+	if s := r.URL.Query().Get("to"); s != "" && err == nil {
+		to, err = time.Parse(time.RFC3339, s)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: 'from' and 'to' must be in RFC3339 format\n")
+		return
+	}
+	if !from.Before(to) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: 'from' must be before 'to'\n")
+		return
+	}
+
+	trades, err := p.Service.ListTrades(from, to)
+	if err != nil {
+		p.logger.WithError(err).Error("Can't get trades")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Can't get trades: "+err.Error())
+		return
+	}
+	ticker := r.URL.Query().Get("ticker")
+	res := []domain.Trade{}
+	for _, t := range trades {
+		if ticker == "" || strings.EqualFold(ticker, t.Ticker) {
+			res = append(res, t)
+		}
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
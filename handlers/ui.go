@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// uiFiles - веб-панель робота. Файлы встраиваются в бинарник, отдельная сборка фронтенда не нужна
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// UI отдает статические файлы панели. Данные панель получает через /api с токеном из /login
+func UI() http.Handler {
+	sub, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/ui/", http.FileServer(http.FS(sub)))
+}
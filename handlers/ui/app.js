@@ -0,0 +1,348 @@
+'use strict';
+
+// Панель работает только с существующим API: токены из /login хранятся в sessionStorage,
+// access токен передается в заголовке Authorization, а для EventSource - параметром token.
+// Истекший access токен обновляется по refresh токену через /refresh
+const tokenKey = 'robot-token';
+const refreshKey = 'robot-refresh';
+const historyDays = 30;
+const refreshPeriod = 30000;
+
+const $ = (id) => document.getElementById(id);
+
+let source = null;
+let timer = null;
+let renewing = null;
+
+function token() {
+  return sessionStorage.getItem(tokenKey);
+}
+
+function saveTokens(tokens) {
+  sessionStorage.setItem(tokenKey, tokens.access_token);
+  sessionStorage.setItem(refreshKey, tokens.refresh_token);
+}
+
+// renew обновляет токены. Refresh токен одноразовый, поэтому параллельные запросы ждут одно обновление.
+// Адрес потока содержит access токен, поэтому после обновления поток переподключается с новым
+function renew() {
+  if (!renewing) {
+    renewing = fetch('/refresh', {
+      method: 'POST',
+      body: JSON.stringify({ refresh_token: sessionStorage.getItem(refreshKey) }),
+    }).then(async (resp) => {
+      if (!resp.ok) {
+        return false;
+      }
+      saveTokens(await resp.json());
+      if (source) {
+        connect();
+      }
+      return true;
+    }).catch(() => false).finally(() => {
+      renewing = null;
+    });
+  }
+  return renewing;
+}
+
+async function api(method, path, body, retried) {
+  const resp = await fetch('/api' + path, {
+    method,
+    headers: { 'Authorization': 'Bearer ' + token(), 'Content-Type': 'application/json' },
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  if (resp.status === 401) {
+    if (!retried && await renew()) {
+      return api(method, path, body, true);
+    }
+    logout();
+    throw new Error('Unauthorized');
+  }
+  const type = resp.headers.get('Content-Type') || '';
+  const data = type.startsWith('application/json') ? await resp.json() : await resp.text();
+  if (!resp.ok) {
+    throw new Error(typeof data === 'string' ? data.trim() : resp.statusText);
+  }
+  return data;
+}
+
+function fmt(value, digits = 2) {
+  return value === undefined || value === null ? '-' : Number(value).toFixed(digits);
+}
+
+function signed(el, value) {
+  el.textContent = fmt(value);
+  el.className = value > 0 ? 'profit' : value < 0 ? 'loss' : '';
+}
+
+function message(text, error) {
+  $('message').textContent = text;
+  $('message').className = error ? 'error' : '';
+}
+
+// Статус, параметры и P&L
+
+function showState(status) {
+  $('state').textContent = status.state || '-';
+  $('state').className = 'badge ' + (status.state || '');
+  $('cycle').textContent = status.cycle || '-';
+  signed($('pnl-cycle'), status.pnl);
+  if (status.params) {
+    const params = status.params;
+    $('ticker').textContent = params.ticker || '-';
+    const form = $('params-form');
+    if (!form.contains(document.activeElement)) {
+      form.ticker.value = params.ticker || '';
+      form.side.value = params.side || 'buy';
+      form.size.value = params.size || '';
+      form.profit.value = params.profit || '';
+      form.json.value = JSON.stringify(params, null, 2);
+    }
+  }
+}
+
+function showPnL(pnl) {
+  signed($('pnl-cycle'), pnl.cycle);
+  signed($('pnl-daily'), pnl.daily);
+  signed($('pnl-weekly'), pnl.weekly);
+}
+
+function showRisk(risk) {
+  const rows = Object.entries(risk.positions || {}).filter(([, size]) => size !== 0);
+  $('positions').innerHTML = rows.length ? '' : '<tr><td colspan="2">No open positions</td></tr>';
+  for (const [ticker, size] of rows) {
+    const tr = document.createElement('tr');
+    tr.innerHTML = '<td></td><td></td>';
+    tr.cells[0].textContent = ticker;
+    tr.cells[1].textContent = size;
+    $('positions').appendChild(tr);
+  }
+  $('killed').classList.toggle('hidden', !risk.killed);
+  signed($('pnl-daily'), risk.daily_pnl);
+  signed($('pnl-weekly'), risk.weekly_pnl);
+}
+
+// История сделок и кривая доходности
+
+function showTrades(trades) {
+  const body = $('trades');
+  body.innerHTML = trades.length ? '' : '<tr><td colspan="6">No trades</td></tr>';
+  for (const t of trades.slice().reverse()) {
+    const tr = document.createElement('tr');
+    tr.innerHTML = '<td></td><td></td><td></td><td></td><td></td><td></td>';
+    const values = [new Date(t.time).toLocaleString(), t.ticker, t.side, t.size, fmt(t.price, 4)];
+    values.forEach((v, i) => { tr.cells[i].textContent = v; });
+    signed(tr.cells[5], t.profit);
+    body.appendChild(tr);
+  }
+  drawEquity(trades);
+}
+
+// drawEquity рисует накопленную прибыль по сделкам всех инструментов. Прибыль сделок в API всегда в долларах
+// (для инверсных контрактов она пересчитывается по цене закрытия), поэтому ее можно складывать между инструментами
+function drawEquity(trades) {
+  const canvas = $('equity');
+  canvas.width = canvas.clientWidth;
+  const ctx = canvas.getContext('2d');
+  const w = canvas.width, h = canvas.height, pad = 30;
+  ctx.clearRect(0, 0, w, h);
+
+  const points = [{ time: Date.now() - historyDays * 86400000, equity: 0 }];
+  let equity = 0;
+  for (const t of trades) {
+    equity += t.profit;
+    points.push({ time: new Date(t.time).getTime(), equity });
+  }
+  points.push({ time: Date.now(), equity });
+
+  const minT = points[0].time, maxT = points[points.length - 1].time;
+  const minE = Math.min(0, ...points.map((p) => p.equity));
+  const maxE = Math.max(0, ...points.map((p) => p.equity));
+  const x = (t) => pad + (w - 2 * pad) * (t - minT) / Math.max(maxT - minT, 1);
+  const y = (e) => h - pad - (h - 2 * pad) * (e - minE) / Math.max(maxE - minE, 1e-9);
+
+  ctx.strokeStyle = '#ccc';
+  ctx.beginPath();
+  ctx.moveTo(pad, y(0));
+  ctx.lineTo(w - pad, y(0));
+  ctx.stroke();
+  ctx.fillStyle = '#888';
+  ctx.fillText(fmt(maxE), 2, y(maxE) + 4);
+  ctx.fillText(fmt(minE), 2, y(minE) + 4);
+
+  ctx.strokeStyle = equity >= 0 ? '#2e7d32' : '#c62828';
+  ctx.lineWidth = 2;
+  ctx.beginPath();
+  points.forEach((p, i) => {
+    // Кривая ступенчатая: баланс меняется только в момент сделки
+    if (i > 0) {
+      ctx.lineTo(x(p.time), y(points[i - 1].equity));
+    }
+    ctx[i ? 'lineTo' : 'moveTo'](x(p.time), y(p.equity));
+  });
+  ctx.stroke();
+}
+
+function showEvent(ev) {
+  const li = document.createElement('li');
+  const parts = [new Date().toLocaleTimeString(), ev.type, ev.ticker, ev.side, ev.size, ev.price && fmt(ev.price, 4)];
+  if (ev.profit) {
+    parts.push('profit ' + fmt(ev.profit));
+  }
+  if (ev.reason) {
+    parts.push(ev.reason);
+  }
+  li.textContent = parts.filter((p) => p !== undefined && p !== '' && p !== 0).join(' ');
+  if (ev.type === 'order_rejected' || ev.type === 'risk_limit_hit' || ev.type === 'robot_error') {
+    li.className = 'critical';
+  } else if (ev.profit < 0) {
+    li.className = 'warning';
+  }
+  $('events').prepend(li);
+  while ($('events').children.length > 100) {
+    $('events').lastChild.remove();
+  }
+}
+
+async function refresh() {
+  try {
+    const from = new Date(Date.now() - historyDays * 86400000).toISOString().replace(/\.\d+Z$/, 'Z');
+    // Риск-менеджер доступен с роли trader, для viewer панель показывает только состояние и сделки
+    const [status, risk, trades] = await Promise.all([
+      api('GET', '/status'), api('GET', '/risk').catch(() => null), api('GET', '/trades?from=' + from),
+    ]);
+    showState(status);
+    if (risk) {
+      showRisk(risk);
+    }
+    showTrades(trades);
+  } catch (e) {
+    message(e.message, true);
+  }
+}
+
+// Поток с живой ценой, состоянием и событиями
+
+function connect() {
+  if (source) {
+    source.close();
+  }
+  source = new EventSource('/api/stream?token=' + encodeURIComponent(token()));
+  // На 401 EventSource закрывается без повторов: обновляем токен, renew подключит поток заново
+  source.addEventListener('error', async (e) => {
+    if (e.target !== source || source.readyState !== EventSource.CLOSED) {
+      return;
+    }
+    if (!await renew()) {
+      logout();
+    }
+  });
+  source.addEventListener('tick', (e) => {
+    const tick = JSON.parse(e.data);
+    $('ticker').textContent = tick.ticker;
+    $('bid').textContent = fmt(tick.bid, 4);
+    $('ask').textContent = fmt(tick.ask, 4);
+  });
+  source.addEventListener('state', (e) => showState(JSON.parse(e.data)));
+  source.addEventListener('pnl', (e) => showPnL(JSON.parse(e.data)));
+  source.addEventListener('event', (e) => {
+    const ev = JSON.parse(e.data);
+    showEvent(ev);
+    if (ev.type === 'order_closed' || ev.type === 'pair_closed') {
+      refresh();
+    }
+  });
+}
+
+// Формы
+
+$('params-form').addEventListener('submit', async (e) => {
+  e.preventDefault();
+  const form = e.target;
+  let opts;
+  try {
+    opts = JSON.parse(form.json.value || '{}');
+  } catch (err) {
+    message('Bad JSON: ' + err.message, true);
+    return;
+  }
+  // /api/set заменяет все параметры, поэтому поля формы накладываются на полный набор
+  Object.assign(opts, {
+    ticker: form.ticker.value.trim().toUpperCase(),
+    side: form.side.value,
+    size: Number(form.size.value),
+    profit: Number(form.profit.value),
+  });
+  try {
+    message(await api('POST', '/set', opts));
+    refresh();
+    connect();
+  } catch (err) {
+    message(err.message, true);
+  }
+});
+
+for (const action of ['start', 'stop']) {
+  $(action).addEventListener('click', async () => {
+    try {
+      message(await api('POST', '/' + action));
+    } catch (err) {
+      message(err.message, true);
+    }
+  });
+}
+
+// Вход и выход
+
+$('login-form').addEventListener('submit', async (e) => {
+  e.preventDefault();
+  const form = e.target;
+  const resp = await fetch('/login', {
+    method: 'POST',
+    body: JSON.stringify({ login: form.login.value, passwd: form.passwd.value }),
+  });
+  if (!resp.ok) {
+    $('login-error').textContent = (await resp.text()).trim() || 'Login failed';
+    return;
+  }
+  saveTokens(await resp.json());
+  form.reset();
+  $('login-error').textContent = '';
+  show();
+});
+
+$('logout').addEventListener('click', logout);
+
+// logout отзывает токены на сервере. Ошибку не ждем: токены в любом случае удаляются из браузера
+function logout() {
+  if (token()) {
+    fetch('/logout', {
+      method: 'POST',
+      headers: { 'Authorization': 'Bearer ' + token() },
+      body: JSON.stringify({ refresh_token: sessionStorage.getItem(refreshKey) }),
+    }).catch(() => {});
+  }
+  sessionStorage.removeItem(tokenKey);
+  sessionStorage.removeItem(refreshKey);
+  if (source) {
+    source.close();
+    source = null;
+  }
+  clearInterval(timer);
+  show();
+}
+
+function show() {
+  const logged = Boolean(token());
+  $('login').classList.toggle('hidden', logged);
+  $('dashboard').classList.toggle('hidden', !logged);
+  if (logged) {
+    refresh();
+    connect();
+    clearInterval(timer);
+    timer = setInterval(refresh, refreshPeriod);
+  }
+}
+
+show();
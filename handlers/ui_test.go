@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/repository"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUI(t *testing.T) {
+	logger := log.New()
+	handler := NewParamsSetter(logger, service.NewRobotService(&repository.Repo{}, logger))
+	r := handler.Routes()
+
+	tests := []struct {
+		name        string
+		path        string
+		code        int
+		contentType string
+	}{
+		{"Redirect", "/ui", 301, ""},
+		{"Index", "/ui/", 200, "text/html; charset=utf-8"},
+		{"Script", "/ui/app.js", 200, "text/javascript; charset=utf-8"},
+		{"Not found", "/ui/missing.js", 404, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.code, w.Code)
+			if tt.contentType != "" {
+				assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestGetTradesBadParams(t *testing.T) {
+	logger := log.New()
+	handler := NewParamsSetter(logger, service.NewRobotService(&repository.Repo{}, logger))
+	r := handler.Routes()
+
+	tests := []struct {
+		name  string
+		query string
+		body  string
+	}{
+		{"Bad format", "?from=yesterday", "Bad params: 'from' and 'to' must be in RFC3339 format\n"},
+		{"Bad range", "?from=2022-01-02T00:00:00Z&to=2022-01-01T00:00:00Z", "Bad params: 'from' must be before 'to'\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/trades"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+testToken(t, handler, domain.RoleViewer))
+			r.ServeHTTP(w, req)
+			assert.Equal(t, 400, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
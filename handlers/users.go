@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	minPasswordLen = 8
+	// maxPasswordLen - bcrypt учитывает только первые 72 байта пароля
+	maxPasswordLen = 72
+)
+
+func checkPassword(passwd string) error {
+	if len(passwd) < minPasswordLen || len(passwd) > maxPasswordLen {
+		return errors.New(`'passwd' must be from 8 to 72 bytes long`)
+	}
+	return nil
+}
+
+func checkRole(role string) error {
+	if !validRole(role) {
+		return errors.New(`'role' must be 'viewer', 'trader' or 'admin'`)
+	}
+	return nil
+}
+
+func checkUser(u Usr) error {
+	if u.Login == "" || strings.ContainsAny(u.Login, " \t\n/") {
+		return errors.New(`'login' must not be empty or contain spaces and slashes`)
+	}
+	if err := checkRole(u.Role); err != nil {
+		return err
+	}
+	return checkPassword(u.Passwd)
+}
+
+// writeUserError отвечает на ошибку хранилища пользователей
+func (p *SetParams) writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "User is not found\n")
+	case errors.Is(err, domain.ErrUserExists):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, "User already exists\n")
+	default:
+		p.logger.WithError(err).Error("Users store error")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "Can't update users: "+err.Error())
+	}
+}
+
+func (p *SetParams) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := p.Service.ListUsers()
+	if err != nil {
+		p.writeUserError(w, err)
+		return
+	}
+	if users == nil {
+		users = []domain.User{}
+	}
+	body, err := json.Marshal(users)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (p *SetParams) CreateUser(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var u Usr
+	err = json.Unmarshal(body, &u)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	if u.Role == "" {
+		u.Role = domain.RoleViewer
+	}
+	if err = checkUser(u); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+		return
+	}
+	if err = p.Service.CreateUser(u.Login, u.Passwd, u.Role); err != nil {
+		p.writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, "User had been created\n")
+}
+
+func (p *SetParams) DisableUser(w http.ResponseWriter, r *http.Request) {
+	if err := p.Service.SetUserDisabled(chi.URLParam(r, "login"), true); err != nil {
+		p.writeUserError(w, err)
+		return
+	}
+	_, _ = io.WriteString(w, "User had been disabled\n")
+}
+
+func (p *SetParams) EnableUser(w http.ResponseWriter, r *http.Request) {
+	if err := p.Service.SetUserDisabled(chi.URLParam(r, "login"), false); err != nil {
+		p.writeUserError(w, err)
+		return
+	}
+	_, _ = io.WriteString(w, "User had been enabled\n")
+}
+
+func (p *SetParams) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var u Usr
+	err = json.Unmarshal(body, &u)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	if err = checkRole(u.Role); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+		return
+	}
+	if err = p.Service.SetUserRole(chi.URLParam(r, "login"), u.Role); err != nil {
+		p.writeUserError(w, err)
+		return
+	}
+	_, _ = io.WriteString(w, "Role had been changed\n")
+}
+
+func (p *SetParams) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var u Usr
+	err = json.Unmarshal(body, &u)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Json unmarshall error\n")
+		return
+	}
+	if err = checkPassword(u.Passwd); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "Bad params: "+err.Error())
+		return
+	}
+	if err = p.Service.ResetPassword(chi.URLParam(r, "login"), u.Passwd); err != nil {
+		p.writeUserError(w, err)
+		return
+	}
+	_, _ = io.WriteString(w, "Password had been reset\n")
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/service"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsersAPI(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockrepoInterface)
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		mockBehavior mockBehavior
+		expectCode   int
+		expectBody   string
+	}{
+		{
+			"Create",
+			"POST", "/api/users", `{"login": "trader", "passwd": "long password", "role": "trader"}`,
+			func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, u domain.User) error {
+					assert.Equal(t, "trader", u.Login)
+					assert.Equal(t, domain.RoleTrader, u.Role)
+					assert.NotEqual(t, "long password", u.PasswordHash)
+					return nil
+				})
+			},
+			201, "User had been created\n",
+		},
+		{
+			"Create existing",
+			"POST", "/api/users", `{"login": "jlexie", "passwd": "long password"}`,
+			func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(domain.ErrUserExists)
+			},
+			409, "User already exists\n",
+		},
+		{
+			"Short password",
+			"POST", "/api/users", `{"login": "trader", "passwd": "short"}`,
+			func(r *mock_service.MockrepoInterface) {},
+			400, "Bad params: 'passwd' must be from 8 to 72 bytes long",
+		},
+		{
+			"Bad login",
+			"POST", "/api/users", `{"login": "tra der", "passwd": "long password"}`,
+			func(r *mock_service.MockrepoInterface) {},
+			400, "Bad params: 'login' must not be empty or contain spaces and slashes",
+		},
+		{
+			"Unknown role",
+			"POST", "/api/users", `{"login": "trader", "passwd": "long password", "role": "root"}`,
+			func(r *mock_service.MockrepoInterface) {},
+			400, "Bad params: 'role' must be 'viewer', 'trader' or 'admin'",
+		},
+		{
+			"Change role",
+			"PUT", "/api/users/jlexie/role", `{"role": "viewer"}`,
+			func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().SetUserRole(gomock.Any(), "jlexie", domain.RoleViewer).Return(nil)
+			},
+			200, "Role had been changed\n",
+		},
+		{
+			"Disable",
+			"POST", "/api/users/jlexie/disable", "",
+			func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().SetUserDisabled(gomock.Any(), "jlexie", true).Return(nil)
+			},
+			200, "User had been disabled\n",
+		},
+		{
+			"Enable unknown",
+			"POST", "/api/users/nobody/enable", "",
+			func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().SetUserDisabled(gomock.Any(), "nobody", false).Return(domain.ErrUserNotFound)
+			},
+			404, "User is not found\n",
+		},
+		{
+			"Reset password",
+			"PUT", "/api/users/jlexie/password", `{"passwd": "new long password"}`,
+			func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().SetUserPassword(gomock.Any(), "jlexie", gomock.Any()).Return(nil)
+			},
+			200, "Password had been reset\n",
+		},
+		{
+			"List",
+			"GET", "/api/users", "",
+			func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().ListUsers(gomock.Any()).Return([]domain.User{{Login: "jlexie", PasswordHash: "hash", Role: "admin", Disabled: true}}, nil)
+			},
+			200, `[{"login":"jlexie","role":"admin","disabled":true,"created":"0001-01-01T00:00:00Z"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			logger := log.New()
+			repo := mock_service.NewMockrepoInterface(c)
+			tt.mockBehavior(repo)
+			handler := NewParamsSetter(logger, service.NewRobotService(repo, logger))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "Bearer "+testToken(t, handler, domain.RoleAdmin))
+			handler.Routes().ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Equal(t, tt.expectBody, w.Body.String())
+		})
+	}
+}
@@ -1 +1,7 @@
-create table orders(instrument text, size numeric, side text, price numeric, ts timestamp, type text, profit numeric, stop_loss numeric);
+create table orders(instrument text, size numeric, side text, price numeric, ts timestamptz, type text, profit numeric, stop_loss numeric);
+create table optimizations(ticker text, strategy text, metric text, ts timestamp, report jsonb);
+create table signals(id text, ticker text, side text, size numeric, stop numeric, target numeric, expiry timestamp, status text, reason text, ts timestamp);
+create unique index signals_accepted_id on signals(id) where status = 'accepted';
+create table outbox(id bigserial primary key, channel text, severity text, text text, event jsonb, dedup_key text unique, attempts int not null default 0, next_try timestamp not null default now(), created timestamp not null default now(), sent timestamp, last_error text);
+create table users(login text primary key, password_hash text not null, role text not null default 'viewer', disabled boolean not null default false, created timestamp not null default now(), updated timestamp not null default now(), tokens_valid_after timestamptz);
+create table revoked_tokens(id text primary key, expires timestamptz not null);
@@ -0,0 +1,448 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.6.1
+// source: pkg/robotpb/robotcontrol.proto
+
+package robotpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// RobotControlClient is the client API for RobotControl service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RobotControlClient interface {
+	GetStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Status, error)
+	SetParams(ctx context.Context, in *Params, opts ...grpc.CallOption) (*Result, error)
+	Start(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Result, error)
+	Stop(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Result, error)
+	GetPnL(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PnL, error)
+	KillSwitch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Result, error)
+	ListTrades(ctx context.Context, in *TradesRequest, opts ...grpc.CallOption) (*Trades, error)
+	WatchTicks(ctx context.Context, in *TicksRequest, opts ...grpc.CallOption) (RobotControl_WatchTicksClient, error)
+	WatchEvents(ctx context.Context, in *Empty, opts ...grpc.CallOption) (RobotControl_WatchEventsClient, error)
+}
+
+type robotControlClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRobotControlClient(cc grpc.ClientConnInterface) RobotControlClient {
+	return &robotControlClient{cc}
+}
+
+func (c *robotControlClient) GetStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, "/robot.RobotControl/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *robotControlClient) SetParams(ctx context.Context, in *Params, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/robot.RobotControl/SetParams", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *robotControlClient) Start(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/robot.RobotControl/Start", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *robotControlClient) Stop(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/robot.RobotControl/Stop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *robotControlClient) GetPnL(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PnL, error) {
+	out := new(PnL)
+	err := c.cc.Invoke(ctx, "/robot.RobotControl/GetPnL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *robotControlClient) KillSwitch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/robot.RobotControl/KillSwitch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *robotControlClient) ListTrades(ctx context.Context, in *TradesRequest, opts ...grpc.CallOption) (*Trades, error) {
+	out := new(Trades)
+	err := c.cc.Invoke(ctx, "/robot.RobotControl/ListTrades", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *robotControlClient) WatchTicks(ctx context.Context, in *TicksRequest, opts ...grpc.CallOption) (RobotControl_WatchTicksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RobotControl_ServiceDesc.Streams[0], "/robot.RobotControl/WatchTicks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &robotControlWatchTicksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RobotControl_WatchTicksClient interface {
+	Recv() (*Tick, error)
+	grpc.ClientStream
+}
+
+type robotControlWatchTicksClient struct {
+	grpc.ClientStream
+}
+
+func (x *robotControlWatchTicksClient) Recv() (*Tick, error) {
+	m := new(Tick)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *robotControlClient) WatchEvents(ctx context.Context, in *Empty, opts ...grpc.CallOption) (RobotControl_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RobotControl_ServiceDesc.Streams[1], "/robot.RobotControl/WatchEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &robotControlWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RobotControl_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type robotControlWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *robotControlWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RobotControlServer is the server API for RobotControl service.
+// All implementations must embed UnimplementedRobotControlServer
+// for forward compatibility
+type RobotControlServer interface {
+	GetStatus(context.Context, *Empty) (*Status, error)
+	SetParams(context.Context, *Params) (*Result, error)
+	Start(context.Context, *Empty) (*Result, error)
+	Stop(context.Context, *Empty) (*Result, error)
+	GetPnL(context.Context, *Empty) (*PnL, error)
+	KillSwitch(context.Context, *Empty) (*Result, error)
+	ListTrades(context.Context, *TradesRequest) (*Trades, error)
+	WatchTicks(*TicksRequest, RobotControl_WatchTicksServer) error
+	WatchEvents(*Empty, RobotControl_WatchEventsServer) error
+	mustEmbedUnimplementedRobotControlServer()
+}
+
+// UnimplementedRobotControlServer must be embedded to have forward compatible implementations.
+type UnimplementedRobotControlServer struct {
+}
+
+func (UnimplementedRobotControlServer) GetStatus(context.Context, *Empty) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedRobotControlServer) SetParams(context.Context, *Params) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetParams not implemented")
+}
+func (UnimplementedRobotControlServer) Start(context.Context, *Empty) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+}
+func (UnimplementedRobotControlServer) Stop(context.Context, *Empty) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedRobotControlServer) GetPnL(context.Context, *Empty) (*PnL, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPnL not implemented")
+}
+func (UnimplementedRobotControlServer) KillSwitch(context.Context, *Empty) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KillSwitch not implemented")
+}
+func (UnimplementedRobotControlServer) ListTrades(context.Context, *TradesRequest) (*Trades, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrades not implemented")
+}
+func (UnimplementedRobotControlServer) WatchTicks(*TicksRequest, RobotControl_WatchTicksServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTicks not implemented")
+}
+func (UnimplementedRobotControlServer) WatchEvents(*Empty, RobotControl_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedRobotControlServer) mustEmbedUnimplementedRobotControlServer() {}
+
+// UnsafeRobotControlServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RobotControlServer will
+// result in compilation errors.
+type UnsafeRobotControlServer interface {
+	mustEmbedUnimplementedRobotControlServer()
+}
+
+func RegisterRobotControlServer(s grpc.ServiceRegistrar, srv RobotControlServer) {
+	s.RegisterService(&RobotControl_ServiceDesc, srv)
+}
+
+func _RobotControl_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RobotControlServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/robot.RobotControl/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RobotControlServer).GetStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RobotControl_SetParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Params)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RobotControlServer).SetParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/robot.RobotControl/SetParams",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RobotControlServer).SetParams(ctx, req.(*Params))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RobotControl_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RobotControlServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/robot.RobotControl/Start",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RobotControlServer).Start(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RobotControl_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RobotControlServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/robot.RobotControl/Stop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RobotControlServer).Stop(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RobotControl_GetPnL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RobotControlServer).GetPnL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/robot.RobotControl/GetPnL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RobotControlServer).GetPnL(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RobotControl_KillSwitch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RobotControlServer).KillSwitch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/robot.RobotControl/KillSwitch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RobotControlServer).KillSwitch(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RobotControl_ListTrades_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TradesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RobotControlServer).ListTrades(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/robot.RobotControl/ListTrades",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RobotControlServer).ListTrades(ctx, req.(*TradesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RobotControl_WatchTicks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TicksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RobotControlServer).WatchTicks(m, &robotControlWatchTicksServer{stream})
+}
+
+type RobotControl_WatchTicksServer interface {
+	Send(*Tick) error
+	grpc.ServerStream
+}
+
+type robotControlWatchTicksServer struct {
+	grpc.ServerStream
+}
+
+func (x *robotControlWatchTicksServer) Send(m *Tick) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _RobotControl_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RobotControlServer).WatchEvents(m, &robotControlWatchEventsServer{stream})
+}
+
+type RobotControl_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type robotControlWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *robotControlWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RobotControl_ServiceDesc is the grpc.ServiceDesc for RobotControl service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RobotControl_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "robot.RobotControl",
+	HandlerType: (*RobotControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _RobotControl_GetStatus_Handler,
+		},
+		{
+			MethodName: "SetParams",
+			Handler:    _RobotControl_SetParams_Handler,
+		},
+		{
+			MethodName: "Start",
+			Handler:    _RobotControl_Start_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _RobotControl_Stop_Handler,
+		},
+		{
+			MethodName: "GetPnL",
+			Handler:    _RobotControl_GetPnL_Handler,
+		},
+		{
+			MethodName: "KillSwitch",
+			Handler:    _RobotControl_KillSwitch_Handler,
+		},
+		{
+			MethodName: "ListTrades",
+			Handler:    _RobotControl_ListTrades_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTicks",
+			Handler:       _RobotControl_WatchTicks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _RobotControl_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/robotpb/robotcontrol.proto",
+}
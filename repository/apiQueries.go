@@ -6,6 +6,7 @@ import (
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -34,6 +35,22 @@ func (r *Repo) SendOrder(symbol, side string, size int, addr string) (domain.API
 	v.Add("symbol", symbol)
 	v.Add("side", side)
 	v.Add("size", strconv.Itoa(size))
+
+	return r.sendOrder(v, addr)
+}
+
+func (r *Repo) SendLimitOrder(symbol, side string, size int, price float32, addr string) (domain.APIResp, error) {
+	v := url.Values{}
+	v.Add("orderType", "lmt")
+	v.Add("symbol", symbol)
+	v.Add("side", side)
+	v.Add("size", strconv.Itoa(size))
+	v.Add("limitPrice", strconv.FormatFloat(float64(price), 'f', -1, 32))
+
+	return r.sendOrder(v, addr)
+}
+
+func (r *Repo) sendOrder(v url.Values, addr string) (domain.APIResp, error) {
 	queryString := v.Encode()
 
 	req, err := http.NewRequest(http.MethodPost, addr+"?"+queryString, nil)
@@ -64,3 +81,110 @@ func (r *Repo) SendOrder(symbol, side string, size int, addr string) (domain.API
 
 	return respStruct, nil
 }
+
+func (r *Repo) CancelOrder(orderID string, addr string) error {
+	v := url.Values{}
+	v.Add("order_id", orderID)
+	queryString := v.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, addr+"?"+queryString, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("APIKey", r.secrets["public"])
+	authent := GenerateAuthent(queryString, "/api/v3/cancelorder", r.secrets["privat"])
+	req.Header.Add("Authent", authent)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	var respStruct domain.CancelResp
+	err = json.Unmarshal(b, &respStruct)
+	if err != nil {
+		return err
+	}
+	if respStruct.Result != "success" {
+		return errors.New("cancel order request failed: " + respStruct.Error)
+	}
+	// Ордер мог исполниться до отмены, это не ошибка
+	if respStruct.CancelStatus.Status != "cancelled" && respStruct.CancelStatus.Status != "filled" {
+		return errors.New("order hadn't been cancelled: " + respStruct.CancelStatus.Status)
+	}
+
+	return nil
+}
+
+func (r *Repo) GetFills(addr string) ([]domain.Fill, error) {
+	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("APIKey", r.secrets["public"])
+	authent := GenerateAuthent("", "/api/v3/fills", r.secrets["privat"])
+	req.Header.Add("Authent", authent)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	var respStruct domain.FillsResp
+	err = json.Unmarshal(b, &respStruct)
+	if err != nil {
+		return nil, err
+	}
+	if respStruct.Result != "success" {
+		return nil, errors.New("fills request failed: " + respStruct.Error)
+	}
+
+	return respStruct.Fills, nil
+}
+
+func (r *Repo) GetAvailableMargin(addr string) (float32, error) {
+	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Add("APIKey", r.secrets["public"])
+	authent := GenerateAuthent("", "/api/v3/accounts", r.secrets["privat"])
+	req.Header.Add("Authent", authent)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	var respStruct domain.AccountsResp
+	err = json.Unmarshal(b, &respStruct)
+	if err != nil {
+		return 0, err
+	}
+	if respStruct.Result != "success" {
+		return 0, errors.New("accounts request failed: " + respStruct.Error)
+	}
+
+	return respStruct.Accounts.Flex.AvailableMargin, nil
+}
@@ -118,3 +118,64 @@ func GenerateAuthent2(postData, endpoint, apiSecret string) string {
 
 	return res
 }
+
+func TestGetAvailableMargin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authent") != GenerateAuthent("", "/api/v3/accounts", "") {
+			_, _ = resp.Write([]byte(`{"result":"error","error":"authenticationError"}`))
+			return
+		}
+		_, _ = resp.Write([]byte(`{"result":"success","accounts":{"flex":{"availableMargin":1520.5,"portfolioValue":2000}}}`))
+	}))
+	defer server.Close()
+
+	r := Repo{}
+	got, err := r.GetAvailableMargin(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(1520.5), got)
+}
+
+func TestSendLimitOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if query.Get("orderType") != "lmt" || query.Get("limitPrice") != "49500.5" {
+			_, _ = resp.Write([]byte(`{"result":"success","sendStatus":{"status":"invalidPrice"}}`))
+			return
+		}
+		_, _ = resp.Write([]byte(`{"result":"success","sendStatus":{"order_id":"id1","status":"placed"}}`))
+	}))
+	defer server.Close()
+
+	r := Repo{}
+	got, err := r.SendLimitOrder("pi_xbtusd", "buy", 1, 49500.5, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "placed", got.SendStatus.Status)
+	assert.Equal(t, "id1", got.SendStatus.OrderID)
+}
+
+func TestCancelOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("order_id") != "id1" {
+			_, _ = resp.Write([]byte(`{"result":"success","cancelStatus":{"status":"notFound"}}`))
+			return
+		}
+		_, _ = resp.Write([]byte(`{"result":"success","cancelStatus":{"status":"cancelled","order_id":"id1"}}`))
+	}))
+	defer server.Close()
+
+	r := Repo{}
+	assert.NoError(t, r.CancelOrder("id1", server.URL))
+	assert.Error(t, r.CancelOrder("id2", server.URL))
+}
+
+func TestGetFills(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		_, _ = resp.Write([]byte(`{"result":"success","fills":[{"fill_id":"f1","order_id":"id1","symbol":"pi_xbtusd","side":"buy","size":1,"price":49500,"fillTime":"2021-12-01T12:00:00.000Z"}]}`))
+	}))
+	defer server.Close()
+
+	r := Repo{}
+	got, err := r.GetFills(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Fill{{FillID: "f1", OrderID: "id1", Symbol: "pi_xbtusd", Side: "buy", Size: 1, Price: 49500, FillTime: "2021-12-01T12:00:00.000Z"}}, got)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// GetCandles загружает исторические свечи инструмента из charts API
+func (r *Repo) GetCandles(symbol, resolution string, from, to time.Time, addr string) ([]domain.Candle, error) {
+	url := fmt.Sprintf("%s/%s/%s?from=%d&to=%d", addr, symbol, resolution, from.Unix(), to.Unix())
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("charts request failed: %s", resp.Status)
+	}
+
+	var respStruct domain.ChartsResp
+	err = json.Unmarshal(b, &respStruct)
+	if err != nil {
+		return nil, err
+	}
+	candles := make([]domain.Candle, 0, len(respStruct.Candles))
+	for _, c := range respStruct.Candles {
+		candle, err := toCandle(c)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
+func toCandle(c domain.ChartCandle) (domain.Candle, error) {
+	var prices [4]float32
+	for i, s := range [...]string{c.Open, c.High, c.Low, c.Close} {
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return domain.Candle{}, err
+		}
+		prices[i] = float32(v)
+	}
+	return domain.Candle{
+		Time:  time.Unix(0, c.Time*int64(time.Millisecond)).UTC(),
+		Open:  prices[0],
+		High:  prices[1],
+		Low:   prices[2],
+		Close: prices[3],
+	}, nil
+}
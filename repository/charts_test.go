@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCandles(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name      string
+		Body      string
+		Expect    []domain.Candle
+		ExpectErr bool
+	}
+	tests := [...]Test{
+		{
+			Name: "All is OK",
+			Body: `{"candles":[{"time":1622505600000,"open":"36000.5","high":"36500","low":"35800","close":"36100","volume":10}],"more_candles":false}`,
+			Expect: []domain.Candle{
+				{Time: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), Open: 36000.5, High: 36500, Low: 35800, Close: 36100},
+			},
+		},
+		{
+			Name:      "Bad price",
+			Body:      `{"candles":[{"time":1622505600000,"open":"abc","high":"1","low":"1","close":"1"}]}`,
+			ExpectErr: true,
+		},
+		{
+			Name:      "Invalid json",
+			Body:      `{"candles":`,
+			ExpectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				path = req.URL.String()
+				_, _ = resp.Write([]byte(test.Body))
+			}))
+			defer server.Close()
+
+			r := Repo{}
+			from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+			got, err := r.GetCandles("PI_XBTUSD", "1h", from, from.Add(time.Hour), server.URL)
+			assert.Equal(t, "/PI_XBTUSD/1h?from=1622505600&to=1622509200", path)
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expect, got)
+		})
+	}
+}
@@ -2,6 +2,12 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/jackc/pgconn"
 )
 
 func (r *Repo) WriteOrderToDb(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit float32, stoploss float32) error { // ($1, $2)
@@ -24,3 +30,49 @@ func (r *Repo) GetTotalProfitDb(ctx context.Context) (float32, error) {
 
 	return res, nil
 }
+
+// GetTrades возвращает закрытые сделки с from до to. Для сетки сделкой считается исполнение, закрывшее уровень с прибылью или убытком.
+// Границы передаются как timestamptz, поэтому сравниваются с временем сделки в одном часовом поясе, даже если
+// в старой базе ts - timestamp без часового пояса, заполненный now() в часовом поясе сервера
+func (r *Repo) GetTrades(ctx context.Context, from, to time.Time) ([]domain.Trade, error) {
+	rows, err := r.pool.Query(ctx, `SELECT instrument, size, side, price, profit, ts FROM orders WHERE (type = 'close' OR (type = 'grid' AND profit <> 0)) AND ts >= $1::timestamptz AND ts < $2::timestamptz ORDER BY ts`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []domain.Trade
+	for rows.Next() {
+		var t domain.Trade
+		err = rows.Scan(&t.Ticker, &t.Size, &t.Side, &t.Price, &t.Profit, &t.Time)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repo) SaveOptimization(ctx context.Context, report domain.OptimizeReport) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO optimizations (ticker, strategy, metric, ts, report) VALUES ($1, $2, $3, now(), $4)`, report.Ticker, report.Strategy, report.Metric, b)
+	return err
+}
+
+func (r *Repo) WriteSignalToDb(ctx context.Context, sig domain.Signal, status, reason string) error {
+	var expiry *time.Time
+	if !sig.Expiry.IsZero() {
+		expiry = &sig.Expiry
+	}
+	// Принятый ID уникален (индекс signals_accepted_id), поэтому повтор сигнала отклоняется и после перезапуска
+	_, err := r.pool.Exec(ctx, `INSERT INTO signals (id, ticker, side, size, stop, target, expiry, status, reason, ts) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())`,
+		sig.ID, sig.Ticker, sig.Side, sig.Size, sig.Stop, sig.Target, expiry, status, reason)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrDuplicateSignal
+	}
+	return err
+}
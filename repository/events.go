@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/pkg/telegrampb"
+)
+
+// eventTemplates - текст уведомлений для получателей без своих шаблонов (Slack, почта, вебхук).
+// Сервер Телеграм строит сообщения по своим шаблонам с разметкой и выбором языка
+var eventTemplates = template.Must(template.New("events").Funcs(template.FuncMap{"price": formatPrice}).Parse(`
+{{define "order_opened"}}Order had been opened.
+Instrument - {{.Ticker}}, side - {{.Side}}, size - {{.Size}}, price - {{price .Price}}
+Stop-loss/take-profit is {{price .StopLoss}}/{{price .TakeProfit}}{{end}}
+{{define "order_closed"}}Order had been closed.
+Instrument - {{.Ticker}}, side - {{.Side}}, size - {{.Size}}, open price - {{price .OpenPrice}}, close price - {{price .Price}}, profit is {{price .Profit}}
+Total profit is {{price .TotalProfit}}{{end}}
+{{define "order_rejected"}}Order hadn't been placed: {{.Reason}}
+Instrument - {{.Ticker}}, side - {{.Side}}, size - {{.Size}}{{end}}
+{{define "risk_limit_hit"}}Order blocked by risk manager: {{.Reason}}
+Instrument - {{.Ticker}}, side - {{.Side}}, size - {{.Size}}{{end}}
+{{define "safety_order_filled"}}Safety order #{{.Number}} had been filled.
+Instrument - {{.Ticker}}, side - {{.Side}}, size - {{.Size}}, price - {{price .Price}}
+Position size - {{.PositionSize}}, average price - {{price .OpenPrice}}, take-profit is {{price .TakeProfit}}{{end}}
+{{define "robot_error"}}Error{{if .Ticker}} on {{.Ticker}}{{end}}: {{.Reason}}{{end}}
+{{define "grid_started"}}Grid had been started.
+Instrument - {{.Ticker}}, range - {{price .Lower}}/{{price .Upper}}, levels - {{.Number}}, size - {{.Size}}{{end}}
+{{define "grid_stopped"}}Grid had been stopped.
+Instrument - {{.Ticker}}, trades - {{.Number}}, profit is {{price .Profit}}{{end}}
+{{define "pairs_started"}}Pairs trading had been started.
+{{range .Legs}}{{.Ticker}} size - {{.Size}}; {{end}}entry/exit z-score - {{price .EntryZ}}/{{price .ExitZ}}{{end}}
+{{define "pairs_stopped"}}Pairs trading had been stopped.
+Instruments -{{range .Legs}} {{.Ticker}}{{end}}, trades - {{.Number}}, profit is {{price .Profit}}{{end}}
+{{define "pair_opened"}}Pair had been opened.
+{{range .Legs}}{{.Side}} {{.Ticker}} {{.Size}} at {{price .Price}}; {{end}}z-score is {{price .ZScore}}{{end}}
+{{define "pair_closed"}}Pair had been closed.
+Instruments -{{range .Legs}} {{.Ticker}}{{end}}, z-score is {{price .ZScore}}, profit is {{price .Profit}}{{end}}
+{{define "signal_accepted"}}Signal {{.SignalID}} had been accepted: {{.Side}} {{.Ticker}}{{end}}
+{{define "report"}}{{.Text}}{{end}}
+`))
+
+func formatPrice(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+// RenderEvent возвращает текст уведомления о событии
+func RenderEvent(ev domain.Event) string {
+	var buf bytes.Buffer
+	if eventTemplates.Lookup(ev.Type) == nil {
+		return ev.Type + ": " + ev.Reason
+	}
+	err := eventTemplates.ExecuteTemplate(&buf, ev.Type, ev)
+	if err != nil {
+		return ev.Type + ": " + err.Error()
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func eventToProto(ev *domain.Event) *telegrampb.Event {
+	if ev == nil {
+		return nil
+	}
+	legs := make([]*telegrampb.Leg, len(ev.Legs))
+	for i, l := range ev.Legs {
+		legs[i] = &telegrampb.Leg{Ticker: l.Ticker, Side: l.Side, Size: int32(l.Size), Price: float64(l.Price)}
+	}
+	return &telegrampb.Event{
+		Type:         ev.Type,
+		Ticker:       ev.Ticker,
+		Side:         ev.Side,
+		Size:         int32(ev.Size),
+		Price:        float64(ev.Price),
+		OpenPrice:    float64(ev.OpenPrice),
+		StopLoss:     float64(ev.StopLoss),
+		TakeProfit:   float64(ev.TakeProfit),
+		Profit:       float64(ev.Profit),
+		TotalProfit:  float64(ev.TotalProfit),
+		PositionSize: int32(ev.PositionSize),
+		Number:       int32(ev.Number),
+		Lower:        float64(ev.Lower),
+		Upper:        float64(ev.Upper),
+		ZScore:       float64(ev.ZScore),
+		EntryZ:       float64(ev.EntryZ),
+		ExitZ:        float64(ev.ExitZ),
+		Legs:         legs,
+		SignalId:     ev.SignalID,
+		Reason:       ev.Reason,
+		Text:         ev.Text,
+	}
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderEvent(t *testing.T) {
+	type Test struct {
+		Name   string
+		Event  domain.Event
+		Expect string
+	}
+	tests := [...]Test{
+		{"Opened", domain.Event{Type: domain.EventOrderOpened, Ticker: "PI_XBTUSD", Side: "buy", Size: 1, Price: 50000, StopLoss: 49500, TakeProfit: 50500},
+			"Order had been opened.\nInstrument - PI_XBTUSD, side - buy, size - 1, price - 50000\nStop-loss/take-profit is 49500/50500"},
+		{"Rejected", domain.Event{Type: domain.EventOrderRejected, Ticker: "PI_XBTUSD", Side: "sell", Size: 2, Reason: "insufficientAvailableFunds"},
+			"Order hadn't been placed: insufficientAvailableFunds\nInstrument - PI_XBTUSD, side - sell, size - 2"},
+		{"Pair opened", domain.Event{Type: domain.EventPairOpened, ZScore: 1.5, Legs: []domain.PairLeg{
+			{Ticker: "PI_ETHUSD", Side: "sell", Size: 1, Price: 3000}, {Ticker: "PI_XRPUSD", Side: "buy", Size: 2, Price: 0.75}}},
+			"Pair had been opened.\nsell PI_ETHUSD 1 at 3000; buy PI_XRPUSD 2 at 0.75; z-score is 1.5"},
+		{"Unknown type", domain.Event{Type: "margin_call", Reason: "margin is low"}, "margin_call: margin is low"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expect, RenderEvent(test.Event))
+		})
+	}
+}
+
+func TestEventChannel(t *testing.T) {
+	msg := eventMessage(domain.Event{Type: domain.EventRiskLimitHit, Reason: "daily loss limit"})
+	assert.Equal(t, domain.ChannelErrors, msg.Channel)
+	assert.Equal(t, domain.SeverityWarning, msg.Severity)
+	assert.Equal(t, domain.EventRiskLimitHit, eventToProto(msg.Event).Type)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+func (r *Repo) GetInstruments(addr string) ([]domain.Instrument, error) {
+	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	var respStruct domain.InstrumentsResp
+	err = json.Unmarshal(b, &respStruct)
+	if err != nil {
+		return nil, err
+	}
+	if respStruct.Result != "success" {
+		return nil, errors.New("instruments request failed: " + respStruct.Error)
+	}
+
+	instruments := make([]domain.Instrument, 0, len(respStruct.Instruments))
+	for _, spec := range respStruct.Instruments {
+		instruments = append(instruments, toInstrument(spec))
+	}
+	return instruments, nil
+}
+
+func toInstrument(spec domain.InstrumentSpec) domain.Instrument {
+	inst := domain.Instrument{
+		Symbol:       strings.ToUpper(spec.Symbol),
+		Type:         spec.Type,
+		Tradeable:    spec.Tradeable,
+		TickSize:     spec.TickSize,
+		ContractSize: spec.ContractSize,
+		MinOrderSize: float32(math.Pow10(-spec.ContractValueTradePrecision)),
+	}
+	// Максимальное плечо определяется начальной маржой на первом уровне
+	if len(spec.MarginLevels) > 0 && spec.MarginLevels[0].InitialMargin > 0 {
+		inst.MaxLeverage = 1 / spec.MarginLevels[0].InitialMargin
+	}
+	return inst
+}
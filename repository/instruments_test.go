@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetInstruments(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name      string
+		Body      string
+		Expect    []domain.Instrument
+		ExpectErr bool
+	}
+	tests := [...]Test{
+		{
+			Name: "All is OK",
+			Body: `{"result":"success","instruments":[{"symbol":"pi_xbtusd","type":"futures_inverse","tradeable":true,"tickSize":0.5,"contractSize":1,"contractValueTradePrecision":0,"marginLevels":[{"contracts":0,"initialMargin":0.02,"maintenanceMargin":0.01}]}]}`,
+			Expect: []domain.Instrument{
+				{Symbol: "PI_XBTUSD", Type: "futures_inverse", Tradeable: true, TickSize: 0.5, ContractSize: 1, MinOrderSize: 1, MaxLeverage: 50},
+			},
+		},
+		{
+			Name:      "Api error",
+			Body:      `{"result":"error","error":"apiLimitExceeded"}`,
+			ExpectErr: true,
+		},
+		{
+			Name:      "Invalid json",
+			Body:      `{"result":`,
+			ExpectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				_, _ = resp.Write([]byte(test.Body))
+			}))
+			defer server.Close()
+
+			r := Repo{}
+			got, err := r.GetInstruments(server.URL)
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expect, got)
+		})
+	}
+}
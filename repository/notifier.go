@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/Marseek/tfs-go-hw/course/pkg/telegrampb"
+)
+
+// Notifier доставляет уведомление в один внешний сервис
+type Notifier interface {
+	Notify(ctx context.Context, msg domain.OutboxMessage) error
+}
+
+type TelegramNotifier struct {
+	client telegrampb.MessageServiceClient
+}
+
+func NewTelegramNotifier(client telegrampb.MessageServiceClient) *TelegramNotifier {
+	return &TelegramNotifier{client: client}
+}
+
+func (n *TelegramNotifier) Notify(ctx context.Context, msg domain.OutboxMessage) error {
+	_, err := n.client.SendMessage(ctx, &telegrampb.Request{Req: msg.Text, Channel: msg.Channel, Severity: msg.Severity, Id: msg.ID, Event: eventToProto(msg.Event)})
+	return err
+}
+
+// WebhookNotifier отправляет уведомление POST запросом с телом
+// {"id": 1, "channel": "trades", "severity": "info", "text": "...", "event": {"type": "order_opened", ...}}
+type WebhookNotifier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewWebhookNotifier(url string, headers map[string]string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, headers: headers, client: &http.Client{Timeout: sendTimeout}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, msg domain.OutboxMessage) error {
+	data := map[string]interface{}{"id": msg.ID, "channel": msg.Channel, "severity": msg.Severity, "text": msg.Text}
+	if msg.Event != nil {
+		data["event"] = msg.Event
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.client, n.url, n.headers, body)
+}
+
+// SlackNotifier отправляет уведомление во входящий вебхук Slack или совместимого мессенджера
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{url: url, client: &http.Client{Timeout: sendTimeout}}
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, msg domain.OutboxMessage) error {
+	text := msg.Text
+	if msg.Severity == domain.SeverityWarning || msg.Severity == domain.SeverityError {
+		text = fmt.Sprintf("*%s*: %s", strings.ToUpper(msg.Severity), text)
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.client, n.url, nil, body)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier отправляет уведомление письмом через SMTP сервер
+type EmailNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+	send func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmailNotifier(addr, username, password, from string, to []string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, strings.Split(addr, ":")[0])
+	}
+	return &EmailNotifier{addr: addr, auth: auth, from: from, to: to, send: sendMail}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, msg domain.OutboxMessage) error {
+	subject := strings.SplitN(strings.TrimSpace(msg.Text), "\n", 2)[0]
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: [robot %s/%s] %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		n.from, strings.Join(n.to, ", "), msg.Channel, msg.Severity, subject, strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	return n.send(ctx, n.addr, n.auth, n.from, n.to, []byte(body))
+}
+
+// sendMail работает как smtp.SendMail, но соединение ограничено sendTimeout и дедлайном ctx,
+// поэтому зависший SMTP сервер не задерживает отправку остальных уведомлений
+func sendMail(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	dialer := net.Dialer{Timeout: sendTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if err = c.Auth(a); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err = c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Router рассылает уведомление получателям его типа события, если они заданы, иначе всем получателям
+// его канала, уведомления каналов без своих получателей - получателям "default". Получатель, уже
+// получивший уведомление, при повторной отправке того же сообщения outbox пропускается
+type Router struct {
+	routes    map[string][]string
+	events    map[string][]string
+	notifiers map[string]Notifier
+	delivered map[string]time.Time
+	mu        sync.Mutex
+}
+
+func NewRouter(notifiers map[string]Notifier, routes, events map[string][]string) *Router {
+	return &Router{routes: routes, events: events, notifiers: notifiers, delivered: make(map[string]time.Time)}
+}
+
+// recipients - имена получателей сообщения
+func (r *Router) recipients(msg domain.OutboxMessage) []string {
+	if msg.Event != nil {
+		if names, ok := r.events[msg.Event.Type]; ok {
+			return names
+		}
+	}
+	if names, ok := r.routes[msg.Channel]; ok {
+		return names
+	}
+	return r.routes["default"]
+}
+
+func (r *Router) Notify(ctx context.Context, msg domain.OutboxMessage) error {
+	names := r.recipients(msg)
+	var errs []string
+	for _, name := range names {
+		key := fmt.Sprintf("%d:%s", msg.ID, name)
+		if msg.ID != 0 && r.isDelivered(key) {
+			continue
+		}
+		err := r.notifiers[name].Notify(ctx, msg)
+		if err != nil {
+			errs = append(errs, name+": "+err.Error())
+			continue
+		}
+		if msg.ID != 0 {
+			r.markDelivered(key)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (r *Router) isDelivered(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.delivered[key]
+	return ok
+}
+
+func (r *Router) markDelivered(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for k, t := range r.delivered {
+		if now.Sub(t) > 24*time.Hour {
+			delete(r.delivered, k)
+		}
+	}
+	r.delivered[key] = now
+}
+
+type SinkConfig struct {
+	Type     string            `json:"type"` // telegram, webhook, slack, email
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers"`
+	SMTP     string            `json:"smtp"` // host:port
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	From     string            `json:"from"`
+	To       []string          `json:"to"`
+}
+
+// NotifierConfig - получатели уведомлений по именам, имена получателей для каждого канала
+// и для отдельных типов событий, которые нужно отправлять не всем получателям канала
+type NotifierConfig struct {
+	Sinks  map[string]SinkConfig `json:"sinks"`
+	Routes map[string][]string   `json:"routes"`
+	Events map[string][]string   `json:"events"`
+}
+
+// LoadNotifierConfig читает настройки из json файла. Без файла все уведомления отправляются в Телеграм
+func LoadNotifierConfig(path string) (NotifierConfig, error) {
+	if path == "" {
+		return NotifierConfig{
+			Sinks:  map[string]SinkConfig{"telegram": {Type: "telegram"}},
+			Routes: map[string][]string{"default": {"telegram"}},
+		}, nil
+	}
+	var cfg NotifierConfig
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(b, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("can't parse notifiers config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// NewNotifier создает получателей из настроек и проверяет, что каналы ссылаются на существующих получателей
+func NewNotifier(cfg NotifierConfig, tg telegrampb.MessageServiceClient) (*Router, error) {
+	notifiers := make(map[string]Notifier, len(cfg.Sinks))
+	for name, s := range cfg.Sinks {
+		switch s.Type {
+		case "telegram":
+			notifiers[name] = NewTelegramNotifier(tg)
+		case "webhook":
+			if s.URL == "" {
+				return nil, fmt.Errorf("sink %s: 'url' must be set", name)
+			}
+			notifiers[name] = NewWebhookNotifier(s.URL, s.Headers)
+		case "slack":
+			if s.URL == "" {
+				return nil, fmt.Errorf("sink %s: 'url' must be set", name)
+			}
+			notifiers[name] = NewSlackNotifier(s.URL)
+		case "email":
+			if s.SMTP == "" || s.From == "" || len(s.To) == 0 {
+				return nil, fmt.Errorf("sink %s: 'smtp', 'from' and 'to' must be set", name)
+			}
+			notifiers[name] = NewEmailNotifier(s.SMTP, s.Username, s.Password, s.From, s.To)
+		default:
+			return nil, fmt.Errorf("sink %s: unknown type %q", name, s.Type)
+		}
+	}
+	if len(cfg.Routes["default"]) == 0 {
+		return nil, errors.New("'default' route must be set")
+	}
+	for channel, names := range cfg.Routes {
+		for _, name := range names {
+			if _, ok := notifiers[name]; !ok {
+				return nil, fmt.Errorf("route %s: unknown sink %s", channel, name)
+			}
+		}
+	}
+	for event, names := range cfg.Events {
+		for _, name := range names {
+			if _, ok := notifiers[name]; !ok {
+				return nil, fmt.Errorf("event %s: unknown sink %s", event, name)
+			}
+		}
+	}
+	return NewRouter(notifiers, cfg.Routes, cfg.Events), nil
+}
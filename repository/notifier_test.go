@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNotifier struct {
+	got  []int64
+	fail bool
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, msg domain.OutboxMessage) error {
+	n.got = append(n.got, msg.ID)
+	if n.fail {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	tg, slack, mail := &fakeNotifier{}, &fakeNotifier{fail: true}, &fakeNotifier{}
+	router := NewRouter(map[string]Notifier{"tg": tg, "slack": slack, "mail": mail}, map[string][]string{
+		"default": {"tg"},
+		"errors":  {"tg", "slack", "mail"},
+	}, map[string][]string{
+		domain.EventRiskLimitHit: {"mail"},
+	})
+
+	assert.NoError(t, router.Notify(context.Background(), domain.OutboxMessage{ID: 1, Channel: domain.ChannelTrades}))
+	assert.EqualError(t, router.Notify(context.Background(), domain.OutboxMessage{ID: 2, Channel: domain.ChannelErrors}), "slack: unavailable")
+	// При повторе сообщение получает только тот, кому его не удалось доставить
+	slack.fail = false
+	assert.NoError(t, router.Notify(context.Background(), domain.OutboxMessage{ID: 2, Channel: domain.ChannelErrors}))
+
+	// Для типа события со своими получателями маршрут канала не используется
+	assert.NoError(t, router.Notify(context.Background(), domain.OutboxMessage{ID: 3, Channel: domain.ChannelErrors, Event: &domain.Event{Type: domain.EventRiskLimitHit}}))
+	assert.NoError(t, router.Notify(context.Background(), domain.OutboxMessage{ID: 4, Channel: domain.ChannelErrors, Event: &domain.Event{Type: domain.EventRobotError}}))
+
+	assert.Equal(t, []int64{1, 2, 4}, tg.got)
+	assert.Equal(t, []int64{2, 2, 4}, slack.got)
+	assert.Equal(t, []int64{2, 3, 4}, mail.got)
+}
+
+func TestWebhookNotifiers(t *testing.T) {
+	var body string
+	var auth string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	msg := domain.OutboxMessage{ID: 7, Channel: domain.ChannelErrors, Severity: domain.SeverityError, Text: "Order hadn't been placed"}
+
+	err := NewWebhookNotifier(srv.URL, map[string]string{"Authorization": "Bearer token"}).Notify(context.Background(), msg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id": 7, "channel": "errors", "severity": "error", "text": "Order hadn't been placed"}`, body)
+	assert.Equal(t, "Bearer token", auth)
+
+	err = NewSlackNotifier(srv.URL).Notify(context.Background(), msg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"text": "*ERROR*: Order hadn't been placed"}`, body)
+
+	status = http.StatusInternalServerError
+	err = NewSlackNotifier(srv.URL).Notify(context.Background(), msg)
+	assert.Error(t, err)
+}
+
+func TestEmailNotifier(t *testing.T) {
+	n := NewEmailNotifier("smtp.example.com:587", "", "", "robot@example.com", []string{"ops@example.com"})
+	var sent string
+	n.send = func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.Equal(t, []string{"ops@example.com"}, to)
+		sent = string(msg)
+		return nil
+	}
+	err := n.Notify(context.Background(), domain.OutboxMessage{Channel: domain.ChannelTrades, Severity: domain.SeverityInfo, Text: "Order had been opened.\nInstrument - PI_XBTUSD"})
+	assert.NoError(t, err)
+	assert.Equal(t, "From: robot@example.com\r\nTo: ops@example.com\r\nSubject: [robot trades/info] Order had been opened.\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n\r\nOrder had been opened.\r\nInstrument - PI_XBTUSD\r\n", sent)
+}
+
+func TestSendMailTimeout(t *testing.T) {
+	// Сервер принимает соединение, но ничего не отвечает
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = sendMail(ctx, l.Addr().String(), nil, "robot@example.com", []string{"ops@example.com"}, []byte("text"))
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewNotifier(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name      string
+		Config    NotifierConfig
+		ExpectErr string
+	}
+	tests := [...]Test{
+		{"Default", NotifierConfig{Sinks: map[string]SinkConfig{"tg": {Type: "telegram"}}, Routes: map[string][]string{"default": {"tg"}}}, ""},
+		{"Unknown sink", NotifierConfig{Sinks: map[string]SinkConfig{"tg": {Type: "telegram"}}, Routes: map[string][]string{"default": {"tg"}, "errors": {"pager"}}}, "route errors: unknown sink pager"},
+		{"Unknown type", NotifierConfig{Sinks: map[string]SinkConfig{"pager": {Type: "sms"}}}, `sink pager: unknown type "sms"`},
+		{"No url", NotifierConfig{Sinks: map[string]SinkConfig{"hook": {Type: "webhook"}}}, "sink hook: 'url' must be set"},
+		{"Unknown event sink", NotifierConfig{Sinks: map[string]SinkConfig{"tg": {Type: "telegram"}}, Routes: map[string][]string{"default": {"tg"}}, Events: map[string][]string{"report": {"mail"}}}, "event report: unknown sink mail"},
+		{"No default", NotifierConfig{Sinks: map[string]SinkConfig{"tg": {Type: "telegram"}}, Routes: map[string][]string{"errors": {"tg"}}}, "'default' route must be set"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewNotifier(test.Config, nil)
+			if test.ExpectErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.ExpectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// После outboxMaxAttempts неудачных попыток сообщение больше не отправляется
+	outboxMaxAttempts = 20
+	outboxBatch       = 50
+	sendTimeout       = 5 * time.Second
+	// При backlogAlert неотправленных сообщений в лог пишется ошибка и в канал ошибок отправляется предупреждение
+	backlogAlert = 100
+)
+
+const insertOutbox = `INSERT INTO outbox (channel, severity, text, event, dedup_key) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (dedup_key) DO NOTHING`
+
+// withID присваивает событию id, если его еще нет. Событие с уже записанным id в outbox повторно не попадает
+func withID(ev domain.Event) domain.Event {
+	if ev.ID != "" {
+		return ev
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	ev.ID = hex.EncodeToString(b)
+	return ev
+}
+
+// Notify записывает событие в outbox, откуда его отправляет Dispatcher.
+// Если база недоступна, уведомление отправляется сразу
+func (r *Repo) Notify(ev domain.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	ev = withID(ev)
+	msg := eventMessage(ev)
+	data, err := json.Marshal(ev)
+	if err == nil {
+		_, err = r.pool.Exec(ctx, insertOutbox, msg.Channel, msg.Severity, msg.Text, data, ev.ID)
+	}
+	if err == nil {
+		return
+	}
+	r.logger.Errorln("Can't write message to outbox: ", err)
+	err = r.SendNotification(ctx, msg)
+	if err != nil {
+		r.logger.Errorln("Can't send notification: ", err)
+	}
+}
+
+// WriteOrderWithEvent записывает сделку и уведомление о ней в outbox в одной транзакции
+func (r *Repo) WriteOrderWithEvent(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit float32, stoploss float32, ev domain.Event) error {
+	ev = withID(ev)
+	msg := eventMessage(ev)
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx, `INSERT INTO orders (instrument, size, side, price, ts, type, profit, stop_loss) VALUES ($1, $2, $3, $4, now(), $5, $6, $7)`, inst, size, side, price, ordtype, profit, stoploss)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, insertOutbox, msg.Channel, msg.Severity, msg.Text, data, ev.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func eventMessage(ev domain.Event) domain.OutboxMessage {
+	return domain.OutboxMessage{Channel: ev.Channel(), Severity: ev.Severity(), Text: RenderEvent(ev), Event: &ev}
+}
+
+func (r *Repo) FetchOutbox(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, channel, severity, text, event, attempts FROM outbox WHERE sent IS NULL AND attempts < $1 AND next_try <= now() ORDER BY id LIMIT $2`, outboxMaxAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		var data []byte
+		err = rows.Scan(&m.ID, &m.Channel, &m.Severity, &m.Text, &data, &m.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		// У сообщений, записанных до появления событий, поле event пустое, они отправляются текстом
+		if len(data) > 0 {
+			m.Event = new(domain.Event)
+			err = json.Unmarshal(data, m.Event)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repo) MarkOutboxSent(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `UPDATE outbox SET sent = now() WHERE id = $1`, id)
+	return err
+}
+
+func (r *Repo) MarkOutboxFailed(ctx context.Context, id int64, attempts int, next time.Time, reason string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE outbox SET attempts = $2, next_try = $3, last_error = $4 WHERE id = $1`, id, attempts, next, reason)
+	return err
+}
+
+func (r *Repo) GetOutboxStatus(ctx context.Context) (domain.OutboxStatus, error) {
+	var st domain.OutboxStatus
+	var oldest *time.Time
+	row := r.pool.QueryRow(ctx, `SELECT count(*) FILTER (WHERE attempts < $1), count(*) FILTER (WHERE attempts >= $1), min(created) FROM outbox WHERE sent IS NULL`, outboxMaxAttempts)
+	err := row.Scan(&st.Backlog, &st.Failed, &oldest)
+	if err != nil {
+		return st, err
+	}
+	if oldest != nil {
+		st.Oldest = *oldest
+	}
+	return st, nil
+}
+
+// SendNotification отправляет сообщение получателям его канала
+func (r *Repo) SendNotification(ctx context.Context, msg domain.OutboxMessage) error {
+	return r.notifier.Notify(ctx, msg)
+}
+
+type outboxStore interface {
+	FetchOutbox(ctx context.Context, limit int) ([]domain.OutboxMessage, error)
+	MarkOutboxSent(ctx context.Context, id int64) error
+	MarkOutboxFailed(ctx context.Context, id int64, attempts int, next time.Time, reason string) error
+	GetOutboxStatus(ctx context.Context) (domain.OutboxStatus, error)
+	SendNotification(ctx context.Context, msg domain.OutboxMessage) error
+}
+
+// Dispatcher отправляет сообщения из outbox. Неотправленное сообщение повторяется с растущей паузой,
+// получатели, которые уже получили его, повторы отбрасывают по id сообщения
+type Dispatcher struct {
+	store   outboxStore
+	log     logrus.FieldLogger
+	now     func() time.Time
+	alerted bool
+}
+
+func NewDispatcher(store outboxStore, logger logrus.FieldLogger) *Dispatcher {
+	return &Dispatcher{store: store, log: logger, now: time.Now}
+}
+
+func (d *Dispatcher) Run(period time.Duration) {
+	for {
+		d.dispatch(context.Background())
+		time.Sleep(period)
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	msgs, err := d.store.FetchOutbox(ctx, outboxBatch)
+	if err != nil {
+		d.log.Errorln("Can't read outbox: ", err)
+		return
+	}
+	for _, m := range msgs {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err = d.store.SendNotification(sendCtx, m)
+		cancel()
+		if err == nil {
+			err = d.store.MarkOutboxSent(ctx, m.ID)
+		} else {
+			d.log.Warnln("Can't send notification, will retry: ", err)
+			err = d.store.MarkOutboxFailed(ctx, m.ID, m.Attempts+1, d.now().Add(backoff(m.Attempts+1)), err.Error())
+		}
+		if err != nil {
+			d.log.Errorln("Can't update outbox: ", err)
+		}
+	}
+	d.checkBacklog(ctx)
+}
+
+// checkBacklog пишет ошибку в лог и отправляет предупреждение в канал ошибок, когда очередь вырастает
+// до backlogAlert, и сообщает, когда она разбирается. Предупреждение отправляется сразу, мимо очереди
+func (d *Dispatcher) checkBacklog(ctx context.Context) {
+	st, err := d.store.GetOutboxStatus(ctx)
+	if err != nil {
+		d.log.Errorln("Can't read outbox status: ", err)
+		return
+	}
+	switch {
+	case st.Backlog >= backlogAlert && !d.alerted:
+		d.alerted = true
+		reason := fmt.Sprintf("notification backlog is %d messages, the oldest is from %s", st.Backlog, st.Oldest.Format(time.RFC3339))
+		d.log.Errorln("Outbox: ", reason)
+		d.alert(ctx, reason)
+	case st.Backlog < backlogAlert/2 && d.alerted:
+		d.alerted = false
+		reason := fmt.Sprintf("notification backlog is back to %d messages", st.Backlog)
+		d.log.Infoln("Outbox: ", reason)
+		d.alert(ctx, reason)
+	}
+}
+
+func (d *Dispatcher) alert(ctx context.Context, reason string) {
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	err := d.store.SendNotification(sendCtx, eventMessage(domain.Event{Type: domain.EventRobotError, Reason: reason}))
+	if err != nil {
+		d.log.Errorln("Can't send outbox alert: ", err)
+	}
+}
+
+// backoff - пауза перед попыткой номер attempts: 5 секунд, удваивается с каждой попыткой, не больше 10 минут
+func backoff(attempts int) time.Duration {
+	d := 5 * time.Second
+	for i := 1; i < attempts && d < 10*time.Minute; i++ {
+		d *= 2
+	}
+	if d > 10*time.Minute {
+		d = 10 * time.Minute
+	}
+	return d
+}
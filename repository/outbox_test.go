@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type failedMessage struct {
+	attempts int
+	next     time.Time
+}
+
+// fakeOutbox - outbox в памяти, отправка сообщений с id из fail не удается
+type fakeOutbox struct {
+	msgs    []domain.OutboxMessage
+	fail    map[int64]bool
+	sent    []int64
+	failed  map[int64]failedMessage
+	backlog int
+	alerts  []string
+}
+
+func (f *fakeOutbox) FetchOutbox(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	return f.msgs, nil
+}
+
+func (f *fakeOutbox) MarkOutboxSent(ctx context.Context, id int64) error {
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeOutbox) MarkOutboxFailed(ctx context.Context, id int64, attempts int, next time.Time, reason string) error {
+	f.failed[id] = failedMessage{attempts: attempts, next: next}
+	return nil
+}
+
+func (f *fakeOutbox) GetOutboxStatus(ctx context.Context) (domain.OutboxStatus, error) {
+	return domain.OutboxStatus{Backlog: f.backlog}, nil
+}
+
+func (f *fakeOutbox) SendNotification(ctx context.Context, msg domain.OutboxMessage) error {
+	if f.fail[msg.ID] {
+		return errors.New("unavailable")
+	}
+	if msg.ID == 0 {
+		f.alerts = append(f.alerts, msg.Channel+": "+msg.Text)
+	}
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	store := &fakeOutbox{
+		msgs:   []domain.OutboxMessage{{ID: 1, Text: "a"}, {ID: 2, Text: "b", Attempts: 2}, {ID: 3, Text: "c"}},
+		fail:   map[int64]bool{2: true},
+		failed: make(map[int64]failedMessage),
+	}
+	d := NewDispatcher(store, log.New())
+	d.now = func() time.Time { return now }
+
+	d.dispatch(context.Background())
+	assert.Equal(t, []int64{1, 3}, store.sent)
+	assert.Equal(t, map[int64]failedMessage{2: {attempts: 3, next: now.Add(20 * time.Second)}}, store.failed)
+
+	// Предупреждение отправляется один раз при росте очереди и сбрасывается, когда она разбирается
+	store.msgs = nil
+	store.backlog = backlogAlert
+	d.dispatch(context.Background())
+	d.dispatch(context.Background())
+	assert.True(t, d.alerted)
+	assert.Equal(t, []string{"errors: Error: notification backlog is 100 messages, the oldest is from 0001-01-01T00:00:00Z"}, store.alerts)
+	store.backlog = backlogAlert / 2
+	d.dispatch(context.Background())
+	assert.True(t, d.alerted)
+	assert.Len(t, store.alerts, 1)
+	store.backlog = 0
+	d.dispatch(context.Background())
+	assert.False(t, d.alerted)
+	assert.Equal(t, "errors: Error: notification backlog is back to 0 messages", store.alerts[1])
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, backoff(1))
+	assert.Equal(t, 10*time.Second, backoff(2))
+	assert.Equal(t, 80*time.Second, backoff(5))
+	assert.Equal(t, 10*time.Minute, backoff(20))
+}
+
+func TestWithID(t *testing.T) {
+	a, b := withID(domain.Event{Type: domain.EventOrderClosed}), withID(domain.Event{Type: domain.EventOrderClosed})
+	assert.Len(t, a.ID, 32)
+	assert.NotEqual(t, a.ID, b.ID, "identical trades must not be merged")
+	assert.Equal(t, a, withID(a))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/Marseek/tfs-go-hw/course/domain"
@@ -17,7 +18,7 @@ type Repo struct {
 	logger     logrus.FieldLogger
 	httpClient http.Client
 	secrets    map[string]string
-	tgClient   telegrampb.MessageServiceClient
+	notifier   Notifier
 }
 
 func NewRepository(pgxPool *pgxpool.Pool, logger logrus.FieldLogger) Repository {
@@ -32,6 +33,14 @@ func NewRepository(pgxPool *pgxpool.Pool, logger logrus.FieldLogger) Repository
 	if err != nil {
 		logger.Fatalln(err)
 	}
+	notifierCfg, err := LoadNotifierConfig(os.Getenv("NOTIFIERS_CONFIG"))
+	if err != nil {
+		logger.Fatalln(err)
+	}
+	notifier, err := NewNotifier(notifierCfg, tgclient)
+	if err != nil {
+		logger.Fatalln("Bad notifiers config: ", err)
+	}
 	return &Repo{
 		pool:   pgxPool,
 		logger: logger,
@@ -39,15 +48,38 @@ func NewRepository(pgxPool *pgxpool.Pool, logger logrus.FieldLogger) Repository
 			Timeout: time.Second * 5,
 		},
 		secrets:  sec,
-		tgClient: tgclient,
+		notifier: notifier,
 	}
 }
 
 type Repository interface {
 	SendOrder(symbol, side string, size int, addr string) (domain.APIResp, error)
 	SetWSConnection(addr string, tick string) (chan domain.WsResponse, func(), error)
+	SetWSConnectionMulti(addr string, ticks []string) (chan domain.WsResponse, func(), error)
 	GetTotalProfitDb(ctx context.Context) (float32, error)
 	WriteOrderToDb(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit float32, stoploss float32) error
-	WriteToTelegramBot(text string)
-	GetUsersMap(string) map[string]string
+	Notify(ev domain.Event)
+	GetUser(ctx context.Context, login string) (domain.User, error)
+	ListUsers(ctx context.Context) ([]domain.User, error)
+	CreateUser(ctx context.Context, u domain.User) error
+	SetUserDisabled(ctx context.Context, login string, disabled bool) error
+	SetUserRole(ctx context.Context, login, role string) error
+	SetUserPassword(ctx context.Context, login, hash string) error
+	RevokeToken(ctx context.Context, id string, expires time.Time) error
+	GetRevokedTokens(ctx context.Context) (map[string]time.Time, error)
+	GetInstruments(addr string) ([]domain.Instrument, error)
+	GetAvailableMargin(addr string) (float32, error)
+	SendLimitOrder(symbol, side string, size int, price float32, addr string) (domain.APIResp, error)
+	CancelOrder(orderID string, addr string) error
+	GetFills(addr string) ([]domain.Fill, error)
+	GetCandles(symbol, resolution string, from, to time.Time, addr string) ([]domain.Candle, error)
+	SaveOptimization(ctx context.Context, report domain.OptimizeReport) error
+	WriteSignalToDb(ctx context.Context, sig domain.Signal, status, reason string) error
+	WriteOrderWithEvent(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit float32, stoploss float32, ev domain.Event) error
+	FetchOutbox(ctx context.Context, limit int) ([]domain.OutboxMessage, error)
+	MarkOutboxSent(ctx context.Context, id int64) error
+	MarkOutboxFailed(ctx context.Context, id int64, attempts int, next time.Time, reason string) error
+	GetOutboxStatus(ctx context.Context) (domain.OutboxStatus, error)
+	GetTrades(ctx context.Context, from, to time.Time) ([]domain.Trade, error)
+	SendNotification(ctx context.Context, msg domain.OutboxMessage) error
 }
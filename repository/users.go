@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// uniqueViolation - код ошибки Postgres при нарушении уникальности ключа
+const uniqueViolation = "23505"
+
+// NewUsersRepository - репозиторий только для работы с пользователями, без ключей биржи и уведомлений.
+// Используется утилитами, например импортом users.json
+func NewUsersRepository(pgxPool *pgxpool.Pool, logger logrus.FieldLogger) *Repo {
+	return &Repo{pool: pgxPool, logger: logger}
+}
+
+func (r *Repo) GetUser(ctx context.Context, login string) (domain.User, error) {
+	var u domain.User
+	var validAfter *time.Time
+	err := r.pool.QueryRow(ctx, `SELECT login, password_hash, role, disabled, created, tokens_valid_after FROM users WHERE login = $1`, login).
+		Scan(&u.Login, &u.PasswordHash, &u.Role, &u.Disabled, &u.Created, &validAfter)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return u, domain.ErrUserNotFound
+	}
+	if validAfter != nil {
+		u.TokensValidAfter = *validAfter
+	}
+	return u, err
+}
+
+func (r *Repo) ListUsers(ctx context.Context) ([]domain.User, error) {
+	rows, err := r.pool.Query(ctx, `SELECT login, role, disabled, created FROM users ORDER BY login`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []domain.User
+	for rows.Next() {
+		var u domain.User
+		err = rows.Scan(&u.Login, &u.Role, &u.Disabled, &u.Created)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, u)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repo) CreateUser(ctx context.Context, u domain.User) error {
+	_, err := r.pool.Exec(ctx, `INSERT INTO users (login, password_hash, role, disabled) VALUES ($1, $2, $3, $4)`, u.Login, u.PasswordHash, u.Role, u.Disabled)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrUserExists
+	}
+	return err
+}
+
+func (r *Repo) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET disabled = $2, updated = now() WHERE login = $1`, login, disabled)
+	if err == nil && tag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
+
+func (r *Repo) SetUserRole(ctx context.Context, login, role string) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET role = $2, updated = now() WHERE login = $1`, login, role)
+	if err == nil && tag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
+
+// SetUserPassword меняет пароль и отзывает все выданные пользователю токены. Время выдачи в токене хранится
+// с точностью до секунды, поэтому отзываются и токены, выданные в секунду смены пароля
+func (r *Repo) SetUserPassword(ctx context.Context, login, hash string) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET password_hash = $2, updated = now(), tokens_valid_after = date_trunc('second', now()) + interval '1 second' WHERE login = $1`, login, hash)
+	if err == nil && tag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
+
+// RevokeToken сохраняет отзыв токена. Если токен уже отозван, возвращается domain.ErrTokenRevoked, так
+// одновременное использование одного refresh токена из нескольких запросов пройдет только один раз
+func (r *Repo) RevokeToken(ctx context.Context, id string, expires time.Time) error {
+	_, err := r.pool.Exec(ctx, `INSERT INTO revoked_tokens (id, expires) VALUES ($1, $2)`, id, expires)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrTokenRevoked
+	}
+	return err
+}
+
+// GetRevokedTokens возвращает отозванные токены, которые еще не истекли
+func (r *Repo) GetRevokedTokens(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, expires FROM revoked_tokens WHERE expires > now()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var expires time.Time
+		err = rows.Scan(&id, &expires)
+		if err != nil {
+			return nil, err
+		}
+		res[id] = expires
+	}
+	return res, rows.Err()
+}
+
+// ReadUsersFile читает старый файл пользователей users.json в формате {"login": "password"}
+func ReadUsersFile(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var users map[string]string
+	err = json.Unmarshal(data, &users)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse %s: %w", file, err)
+	}
+	return users, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadUsersFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"Users", `{"jlexie": "password", "admin": "secret"}`, map[string]string{"jlexie": "password", "admin": "secret"}, false},
+		{"Bad json", `{"jlexie": `, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "users.json")
+			assert.NoError(t, os.WriteFile(file, []byte(tt.content), 0o600))
+			users, err := ReadUsersFile(file)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, users)
+		})
+	}
+
+	_, err := ReadUsersFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
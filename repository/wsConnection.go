@@ -3,20 +3,21 @@ package repository
 import (
 	"encoding/json"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/Marseek/tfs-go-hw/course/domain"
 	"github.com/gorilla/websocket"
 )
 
-func (r *Repo) EstablishWsConnection(addr string, tick string) (*websocket.Conn, error) {
+func (r *Repo) EstablishWsConnection(addr string, ticks ...string) (*websocket.Conn, error) {
 	c, _, err := websocket.DefaultDialer.Dial(addr, nil)
 	for err != nil { // redialling. Это когда сразу не получается подключиться.
 		time.Sleep(time.Second)
 		c, _, err = websocket.DefaultDialer.Dial(addr, nil)
 	}
 
-	wsRequest, err := json.Marshal(domain.SubscribeWS{Event: "subscribe", Feed: "ticker_lite", Prod: []string{tick}})
+	wsRequest, err := json.Marshal(domain.SubscribeWS{Event: "subscribe", Feed: "ticker", Prod: ticks})
 	if err != nil {
 		r.logger.Fatalln("Error, while unmarshalling WS request. ", err)
 	}
@@ -28,21 +29,53 @@ func (r *Repo) EstablishWsConnection(addr string, tick string) (*websocket.Conn,
 }
 
 func (r *Repo) SetWSConnection(addr string, tick string) (chan domain.WsResponse, func(), error) {
+	return r.SetWSConnectionMulti(addr, []string{tick})
+}
+
+// SetWSConnectionMulti подписывается на тики нескольких инструментов в одном соединении.
+// Функция отмены закрывает соединение и канал, не дожидаясь следующего тика
+func (r *Repo) SetWSConnectionMulti(addr string, ticks []string) (chan domain.WsResponse, func(), error) {
 	ch := make(chan domain.WsResponse)
 
-	c, err := r.EstablishWsConnection(addr, tick)
+	c, err := r.EstablishWsConnection(addr, ticks...)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	cancel := make(chan struct{})
+	done := make(chan struct{})
+	var mu sync.Mutex
+	// conn - текущее соединение, после отмены закрывается сразу, а новое не открывается
+	conn := func(next *websocket.Conn) *websocket.Conn {
+		mu.Lock()
+		defer mu.Unlock()
+		if next != nil {
+			c = next
+			select {
+			case <-done:
+				_ = c.Close()
+			default:
+			}
+		}
+		return c
+	}
 	go func() {
+		defer close(ch)
 		for {
 			var resp domain.WsResponse
-			_, message, err := c.ReadMessage()
+			_, message, err := conn(nil).ReadMessage()
 			if err != nil {
+				select {
+				case <-done:
+					return
+				default:
+				}
 				r.logger.Debugln("WS connection failed. Establishing new connection")
-				c, _ = r.EstablishWsConnection(addr, tick)
+				next, err := r.EstablishWsConnection(addr, ticks...)
+				if err != nil {
+					time.Sleep(time.Second)
+					continue
+				}
+				conn(next)
 				continue
 			}
 			err = json.Unmarshal(message, &resp)
@@ -54,18 +87,25 @@ func (r *Repo) SetWSConnection(addr string, tick string) (chan domain.WsResponse
 			if resp.ProductID == "" { // Игнорируем всякие странные сообщения
 				continue
 			}
-			ch <- resp
 			select {
-			case <-cancel:
-				_ = c.Close()
-				close(ch)
+			case ch <- resp:
+			case <-done:
+				return
+			}
+			select {
+			case <-done:
 				return
 			case <-time.After(time.Millisecond * 100):
 			}
 		}
 	}()
+	var once sync.Once
 	return ch, func() {
-		<-ch // нужно вычитать одно сообщение из канала, чтобы запись в него не блокировалась на 57 строчке
-		cancel <- struct{}{}
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			close(done)
+			_ = c.Close()
+		})
 	}, nil
 }
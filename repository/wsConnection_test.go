@@ -30,7 +30,7 @@ func MockWsHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			continue
 		}
-		if req.Event != "subscribe" || req.Feed != "ticker_lite" {
+		if req.Event != "subscribe" || req.Feed != "ticker" {
 			wsRequest, _ := json.Marshal(domain.WsResponse{ProductID: "error"})
 			_ = c.WriteMessage(websocket.TextMessage, wsRequest)
 			continue
@@ -57,3 +57,20 @@ func TestEstablishWsConnection(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, resp.ProductID, expext)
 }
+
+func TestWsConnectionCancel(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(MockWsHandler))
+	defer s.Close()
+	addr := "ws" + strings.TrimPrefix(s.URL, "http")
+
+	r := Repo{}
+	priceChan, cancel, err := r.SetWSConnection(addr, "Ticker")
+	assert.NoError(t, err)
+	<-priceChan
+
+	// Отмена не ждет следующего тика и закрывает канал
+	cancel()
+	cancel()
+	for range priceChan {
+	}
+}
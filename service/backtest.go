@@ -0,0 +1,122 @@
+package service
+
+import (
+	"math"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// backtest прогоняет стратегию по свечам, начиная со свечи start (предыдущие свечи нужны стратегии как история).
+// Вход - по цене закрытия свечи с сигналом, выход - по stop-loss/take-profit на расстоянии Profit процентов,
+// которые проверяются по high/low следующих свечей. Если в одной свече достигнуты оба уровня, считается stop-loss.
+// Позиция, открытая на последней свече, закрывается по ее цене закрытия
+func backtest(inst domain.Instrument, candles []domain.Candle, start int, params domain.Options, strat strategy) domain.BacktestResult {
+	var profits []float32
+	var pos *position
+	var upper, lower float32
+	for i := start; i < len(candles); i++ {
+		c := candles[i]
+		if pos != nil {
+			exit, ok := backtestExit(pos, c, upper, lower)
+			if !ok {
+				continue
+			}
+			profits = append(profits, pos.pnl(exit))
+			pos = nil
+			continue
+		}
+
+		from := i + 1 - (params.Period + 1)
+		if from < 0 {
+			from = 0
+		}
+		side := strat.signal(candles[from : i+1])
+		if side == "" || (params.Side != "" && side != params.Side) {
+			continue
+		}
+		pos = newPosition(inst, side)
+		pos.add(params.Size, c.Close)
+		upper, lower = pos.limits(params.Profit)
+	}
+	if pos != nil {
+		profits = append(profits, pos.pnl(candles[len(candles)-1].Close))
+	}
+	return backtestStats(profits)
+}
+
+func backtestExit(pos *position, c domain.Candle, upper, lower float32) (float32, bool) {
+	if pos.side == "sell" {
+		switch {
+		case c.High >= upper:
+			return upper, true
+		case c.Low <= lower:
+			return lower, true
+		}
+		return 0, false
+	}
+	switch {
+	case c.Low <= lower:
+		return lower, true
+	case c.High >= upper:
+		return upper, true
+	}
+	return 0, false
+}
+
+func backtestStats(profits []float32) domain.BacktestResult {
+	res := domain.BacktestResult{Trades: len(profits)}
+	if len(profits) == 0 {
+		return res
+	}
+	var equity, peak, gross, loss float32
+	for _, p := range profits {
+		res.Profit += p
+		if p > 0 {
+			res.Wins++
+			gross += p
+		} else {
+			loss -= p
+		}
+		equity += p
+		if equity > peak {
+			peak = equity
+		}
+		if peak-equity > res.MaxDrawdown {
+			res.MaxDrawdown = peak - equity
+		}
+	}
+	res.WinRate = float32(res.Wins) / float32(res.Trades)
+	// Без убыточных сделок profit factor не ограничен, такие наборы ставятся выше остальных
+	switch {
+	case loss > 0:
+		res.ProfitFactor = gross / loss
+	case gross > 0:
+		res.ProfitFactor = math.MaxFloat32
+	}
+
+	// Коэффициент Шарпа по результатам сделок, без годового пересчета
+	mean := float64(res.Profit) / float64(res.Trades)
+	var variance float64
+	for _, p := range profits {
+		variance += (float64(p) - mean) * (float64(p) - mean)
+	}
+	if std := math.Sqrt(variance / float64(res.Trades)); std > 0 {
+		res.Sharpe = float32(mean / std)
+	}
+	return res
+}
+
+// metricValue - значение метрики, по которому наборы параметров сортируются по убыванию
+func metricValue(res domain.BacktestResult, metric string) float32 {
+	switch metric {
+	case "win_rate":
+		return res.WinRate
+	case "profit_factor":
+		return res.ProfitFactor
+	case "sharpe":
+		return res.Sharpe
+	case "drawdown":
+		return -res.MaxDrawdown
+	}
+	return res.Profit
+}
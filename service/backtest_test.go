@@ -0,0 +1,77 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var linearInst = domain.Instrument{Symbol: "PF_XBTUSD", Type: "flexible_futures", TickSize: 0.01, ContractSize: 1}
+
+func TestBacktest(t *testing.T) {
+	params := domain.Options{Size: 1, Profit: 5, Strategy: domain.Strategy{Name: "donchian", Period: 3}}
+	strat := newStrategy(params.Strategy)
+
+	tests := [...]struct {
+		Name    string
+		Candles []domain.Candle
+		Start   int
+		Expect  domain.BacktestResult
+	}{
+		{
+			Name:    "No signals",
+			Candles: series(100, 101, 100, 101, 100),
+		},
+		{
+			// Пробой вверх на 104, take-profit 109.2 достигнут на следующей свече
+			Name:    "Take profit",
+			Candles: append(series(100, 101, 102, 104), domain.Candle{Open: 104, High: 110, Low: 103, Close: 109}),
+			Expect:  domain.BacktestResult{Trades: 1, Wins: 1, Profit: 5.2, WinRate: 1, ProfitFactor: math.MaxFloat32},
+		},
+		{
+			// Пробой вниз на 97 - продажа, в свече с обоими уровнями считается stop-loss 101.85
+			Name:    "Stop loss first",
+			Candles: append(series(100, 101, 102, 97), domain.Candle{Open: 97, High: 102, Low: 92, Close: 95}),
+			Expect:  domain.BacktestResult{Trades: 1, Profit: -4.85, MaxDrawdown: 4.85},
+		},
+		{
+			Name:    "Open position is closed at the end",
+			Candles: series(100, 101, 102, 104, 106),
+			Expect:  domain.BacktestResult{Trades: 1, Wins: 1, Profit: 2, WinRate: 1, ProfitFactor: math.MaxFloat32},
+		},
+		{
+			Name:    "Signals before start are skipped",
+			Candles: series(100, 101, 102, 104, 105),
+			Start:   4,
+			Expect:  domain.BacktestResult{Trades: 1, Wins: 0, Profit: 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := backtest(linearInst, test.Candles, test.Start, params, strat)
+			assert.Equal(t, test.Expect.Trades, got.Trades)
+			assert.Equal(t, test.Expect.Wins, got.Wins)
+			assert.InDelta(t, test.Expect.Profit, got.Profit, 0.01)
+			assert.InDelta(t, test.Expect.MaxDrawdown, got.MaxDrawdown, 0.01)
+			assert.Equal(t, test.Expect.WinRate, got.WinRate)
+			assert.Equal(t, test.Expect.ProfitFactor, got.ProfitFactor)
+		})
+	}
+}
+
+func TestBacktestStats(t *testing.T) {
+	res := backtestStats([]float32{10, -5, 10, -10, 5})
+	assert.Equal(t, 5, res.Trades)
+	assert.Equal(t, 3, res.Wins)
+	assert.Equal(t, float32(10), res.Profit)
+	assert.Equal(t, float32(0.6), res.WinRate)
+	assert.Equal(t, float32(10), res.MaxDrawdown)
+	assert.Equal(t, float32(25)/15, res.ProfitFactor)
+	assert.InDelta(t, 0.24, res.Sharpe, 0.01)
+
+	assert.Equal(t, float32(10), metricValue(res, "profit"))
+	assert.Equal(t, float32(-10), metricValue(res, "drawdown"))
+	assert.Equal(t, float32(10), metricValue(res, ""))
+}
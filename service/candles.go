@@ -0,0 +1,45 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// candles собирает тики в свечи длительностью interval и хранит последние max закрытых свечей
+type candles struct {
+	interval time.Duration
+	max      int
+	current  *domain.Candle
+	closed   []domain.Candle
+}
+
+func newCandles(interval time.Duration, max int) *candles {
+	return &candles{interval: interval, max: max}
+}
+
+// add учитывает цену тика в момент t. Возвращает true, если тик начал новую свечу и закрыл предыдущую
+func (c *candles) add(t time.Time, price float32) bool {
+	start := t.Truncate(c.interval)
+	var closed bool
+	if c.current != nil && start.After(c.current.Time) {
+		c.closed = append(c.closed, *c.current)
+		if len(c.closed) > c.max {
+			c.closed = c.closed[len(c.closed)-c.max:]
+		}
+		c.current = nil
+		closed = true
+	}
+	if c.current == nil {
+		c.current = &domain.Candle{Time: start, Open: price, High: price, Low: price, Close: price}
+		return closed
+	}
+	if price > c.current.High {
+		c.current.High = price
+	}
+	if price < c.current.Low {
+		c.current.Low = price
+	}
+	c.current.Close = price
+	return closed
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronExpr - расписание в формате cron из пяти полей: минута, час, день месяца, месяц, день недели
+type cronExpr struct {
+	minute, hour, dom, month, dow []bool
+	domAny, dowAny                bool
+}
+
+func parseCron(expr string) (cronExpr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cronExpr{}, errors.New("cron expression must have 5 fields: " + expr)
+	}
+	var c cronExpr
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return cronExpr{}, err
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return cronExpr{}, err
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return cronExpr{}, err
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return cronExpr{}, err
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return cronExpr{}, err
+	}
+	// 7 и 0 - воскресенье
+	if c.dow[7] {
+		c.dow[0] = true
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField разбирает поле вида "*", "*/15", "1-5", "0,30", "8-18/2"
+func parseCronField(field string, min, max int) ([]bool, error) {
+	res := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, errors.New("invalid step in cron field: " + field)
+			}
+			part = part[:i]
+		}
+
+		from, to := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			from, err1 = strconv.Atoi(bounds[0])
+			to, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, errors.New("invalid range in cron field: " + field)
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, errors.New("invalid value in cron field: " + field)
+			}
+			from, to = v, v
+			if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, errors.New("cron field is out of range: " + field)
+		}
+		for v := from; v <= to; v += step {
+			res[v] = true
+		}
+	}
+	return res, nil
+}
+
+func (c cronExpr) Match(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+	dom := c.dom[t.Day()]
+	dow := c.dow[int(t.Weekday())]
+	// Как в cron: если заданы и день месяца, и день недели, достаточно совпадения одного из них
+	if !c.domAny && !c.dowAny {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"math"
+	"strings"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+type entry struct {
+	size  int
+	price float32
+}
+
+// position - позиция робота, набранная одним или несколькими ордерами в одну сторону
+type position struct {
+	inst    domain.Instrument
+	side    string
+	entries []entry
+}
+
+func newPosition(inst domain.Instrument, side string) *position {
+	return &position{inst: inst, side: side}
+}
+
+func (p *position) add(size int, price float32) {
+	p.entries = append(p.entries, entry{size: size, price: price})
+}
+
+func (p *position) size() int {
+	var size int
+	for _, e := range p.entries {
+		size += e.size
+	}
+	return size
+}
+
+// avgPrice - средняя цена входа. Для инверсных контрактов размер задан в долларах,
+// поэтому цена усредняется по количеству базовой валюты
+func (p *position) avgPrice() float32 {
+	if len(p.entries) == 1 {
+		return p.entries[0].price
+	}
+	var size, sum float32
+	for _, e := range p.entries {
+		size += float32(e.size)
+		if p.inst.Type == "futures_inverse" {
+			sum += float32(e.size) / e.price
+		} else {
+			sum += float32(e.size) * e.price
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	if p.inst.Type == "futures_inverse" {
+		return size / sum
+	}
+	return sum / size
+}
+
+// notional - сумма позиции в долларах по ценам входа, как она учтена риск-менеджером
+func (p *position) notional() float32 {
+	var sum float32
+	for _, e := range p.entries {
+		sum += notional(p.inst, e.size, e.price)
+	}
+	return sum
+}
+
+func (p *position) pnl(closePrice float32) float32 {
+	var sum float32
+	for _, e := range p.entries {
+		sum += pnl(p.inst, p.side, e.size, e.price, closePrice)
+	}
+	return sum
+}
+
+// limits - границы закрытия позиции, отстоящие от средней цены входа на profit процентов
+func (p *position) limits(profit float32) (upper, lower float32) {
+	avg := p.avgPrice()
+	return roundPrice(p.inst, avg*(1+profit/100)), roundPrice(p.inst, avg*(1-profit/100))
+}
+
+// exitLimits - границы закрытия позиции. Заданные явно цены stop-loss и take-profit заменяют
+// границы, посчитанные от средней цены входа
+func exitLimits(params domain.Options, pos *position) (upper, lower float32) {
+	upper, lower = pos.limits(params.Profit)
+	if pos.side == "sell" {
+		if params.StopPrice > 0 {
+			upper = params.StopPrice
+		}
+		if params.TargetPrice > 0 {
+			lower = params.TargetPrice
+		}
+		return upper, lower
+	}
+	if params.StopPrice > 0 {
+		lower = params.StopPrice
+	}
+	if params.TargetPrice > 0 {
+		upper = params.TargetPrice
+	}
+	return upper, lower
+}
+
+// exit сообщает, достигла ли цена тейк-профита или стоп-лосса позиции
+func (p *position) exit(price, upper, lower float32) (takeProfit, stopLoss bool) {
+	if p.side == "sell" {
+		return price < lower, price > upper
+	}
+	return price > upper, price < lower
+}
+
+// against сообщает, ушла ли цена против позиции до уровня level или дальше
+func (p *position) against(price, level float32) bool {
+	if p.side == "sell" {
+		return price >= level
+	}
+	return price <= level
+}
+
+// safetyPrice - цена страховочного ордера номер n (с 1). Отклонение от цены первого входа
+// для каждого следующего ордера увеличивается в StepScale раз
+func safetyPrice(dca domain.DCA, side string, first float32, n int) float32 {
+	scale := dca.StepScale
+	if scale == 0 {
+		scale = 1
+	}
+	var deviation float32
+	step := dca.Deviation
+	for i := 0; i < n; i++ {
+		deviation += step
+		step *= scale
+	}
+	if side == "sell" {
+		return first * (1 + deviation/100)
+	}
+	return first * (1 - deviation/100)
+}
+
+// safetySize - размер страховочного ордера номер n, base - размер первого входа
+func safetySize(inst domain.Instrument, dca domain.DCA, base, n int) int {
+	mult := dca.SizeMultiplier
+	if mult == 0 {
+		mult = 1
+	}
+	size := roundSize(inst, int(math.Round(float64(base)*math.Pow(float64(mult), float64(n)))))
+	if float32(size) < inst.MinOrderSize || size < 1 {
+		size = int(math.Max(float64(inst.MinOrderSize), 1))
+	}
+	return size
+}
+
+func (r *RobotService) SetExits(exits domain.Exits) {
+	r.mu.Lock()
+	r.params.Exits = exits
+	r.mu.Unlock()
+}
+
+func (r *RobotService) SetDCA(dca domain.DCA) {
+	r.mu.Lock()
+	r.params.DCA = dca
+	r.mu.Unlock()
+}
+
+// safetyOrder отправляет страховочный ордер номер n и добавляет его в позицию.
+// Возвращает false, если ордер не был исполнен
+func (r *RobotService) safetyOrder(params domain.Options, pos *position, n int, lastPrice float32) bool {
+	size := safetySize(pos.inst, params.DCA, params.Size, n)
+	err := r.risk.CheckOpen(params.Ticker, size, notional(pos.inst, size, lastPrice))
+	if err != nil {
+		r.log.Infoln("Safety order blocked by risk manager: ", err)
+		r.repo.Notify(domain.Event{Type: domain.EventRiskLimitHit, Ticker: params.Ticker, Side: params.Side, Size: size, Number: n, Reason: err.Error()})
+		return false
+	}
+	resp, err := r.repo.SendOrder(strings.ToLower(params.Ticker), params.Side, size, sendOrderAddr)
+	if err != nil || resp.Result != "success" || resp.SendStatus.Status != "placed" || len(resp.SendStatus.OrderEvents) == 0 {
+		r.log.Errorln("Can't place safety order: ", err, GetError(resp))
+		r.repo.Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: params.Ticker, Side: params.Side, Size: size, Number: n, Reason: rejectReason(resp, err)})
+		return false
+	}
+
+	price := resp.SendStatus.OrderEvents[0].Price
+	r.risk.OnOpen(params.Ticker, params.Side, size, notional(pos.inst, size, price))
+	pos.add(size, price)
+	upper, lower := exitLimits(params, pos)
+	takeProfit := upper
+	if params.Side == "sell" {
+		takeProfit = lower
+	}
+	r.writeOrder(params.Ticker, size, params.Side, price, "open", 0, params.Profit, domain.Event{
+		Type: domain.EventSafetyOrder, Ticker: params.Ticker, Side: params.Side, Size: size, Price: price, Number: n,
+		PositionSize: pos.size(), OpenPrice: pos.avgPrice(), TakeProfit: takeProfit,
+	})
+	return true
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionAvgPrice(t *testing.T) {
+	linear := domain.Instrument{Symbol: "PF_XBTUSD", Type: "flexible_futures", TickSize: 1, ContractSize: 1}
+
+	pos := newPosition(linear, "buy")
+	pos.add(1, 100)
+	pos.add(3, 80)
+	assert.Equal(t, 4, pos.size())
+	assert.Equal(t, float32(85), pos.avgPrice())
+	assert.Equal(t, float32(1*20+3*40), pos.pnl(120))
+
+	// У инверсного контракта средняя цена - гармоническая, чтобы результат совпадал с суммой по входам
+	inverse := newPosition(defaultInstruments[0], "buy")
+	inverse.add(100, 50000)
+	inverse.add(100, 40000)
+	avg := inverse.avgPrice()
+	assert.InDelta(t, 44444.4, avg, 0.1)
+	assert.InDelta(t, pnl(defaultInstruments[0], "buy", 200, avg, 60000), inverse.pnl(60000), 0.001)
+}
+
+func TestPositionExit(t *testing.T) {
+	tests := [...]struct {
+		Name       string
+		Side       string
+		Price      float32
+		TakeProfit bool
+		StopLoss   bool
+	}{
+		{"Buy take profit", "buy", 111, true, false},
+		{"Buy stop loss", "buy", 89, false, true},
+		{"Buy inside", "buy", 100, false, false},
+		{"Sell take profit", "sell", 89, true, false},
+		{"Sell stop loss", "sell", 111, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			pos := newPosition(domain.Instrument{}, test.Side)
+			pos.add(1, 100)
+			upper, lower := pos.limits(10)
+			takeProfit, stopLoss := pos.exit(test.Price, upper, lower)
+			assert.Equal(t, test.TakeProfit, takeProfit)
+			assert.Equal(t, test.StopLoss, stopLoss)
+		})
+	}
+}
+
+func TestSafetyOrders(t *testing.T) {
+	dca := domain.DCA{SafetyOrders: 3, Deviation: 1, StepScale: 2, SizeMultiplier: 2}
+
+	// Отклонения 1%, 1+2=3%, 1+2+4=7%
+	assert.InDelta(t, 99, safetyPrice(dca, "buy", 100, 1), 0.001)
+	assert.InDelta(t, 97, safetyPrice(dca, "buy", 100, 2), 0.001)
+	assert.InDelta(t, 93, safetyPrice(dca, "buy", 100, 3), 0.001)
+	assert.InDelta(t, 103, safetyPrice(dca, "sell", 100, 2), 0.001)
+	assert.InDelta(t, 102, safetyPrice(domain.DCA{Deviation: 1}, "sell", 100, 2), 0.001)
+
+	inst := domain.Instrument{MinOrderSize: 1}
+	assert.Equal(t, 2, safetySize(inst, dca, 1, 1))
+	assert.Equal(t, 8, safetySize(inst, dca, 1, 3))
+	assert.Equal(t, 5, safetySize(inst, domain.DCA{}, 5, 2))
+	assert.Equal(t, 1, safetySize(inst, domain.DCA{SizeMultiplier: 0.1}, 2, 2))
+}
+
+func TestDCACycle(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	logger := log.New()
+	repo := mock_service.NewMockrepoInterface(c)
+	ch := make(chan domain.WsResponse, 3)
+	inst := defaultInstruments[0]
+	filled := func(price float32) domain.APIResp {
+		return domain.APIResp{Result: "success", SendStatus: domain.SendStatus{Status: "placed", OrderEvents: []domain.OrderEvents{{Price: price}}}}
+	}
+
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").Return(ch, func() {}, nil)
+	repo.EXPECT().Notify(gomock.Any()).AnyTimes()
+	gomock.InOrder(
+		repo.EXPECT().SendOrder("pi_xbtusd", "buy", 1, sendOrderAddr).Return(filled(50000), nil),
+		repo.EXPECT().SendOrder("pi_xbtusd", "buy", 2, sendOrderAddr).Return(filled(49400), nil),
+		repo.EXPECT().SendOrder("pi_xbtusd", "sell", 3, sendOrderAddr).Return(filled(49900), nil),
+	)
+	repo.EXPECT().WriteOrderWithEvent(context.Background(), "PI_XBTUSD", 1, "buy", float32(50000), "open", float32(0), float32(0.5), gomock.Any()).Return(nil)
+	repo.EXPECT().WriteOrderWithEvent(context.Background(), "PI_XBTUSD", 2, "buy", float32(49400), "open", float32(0), float32(0.5), gomock.Any()).Return(nil)
+	profit := pnl(inst, "buy", 1, 50000, 49900) + pnl(inst, "buy", 2, 49400, 49900)
+	closed := make(chan struct{})
+	repo.EXPECT().WriteOrderWithEvent(context.Background(), "PI_XBTUSD", 3, "sell", float32(49900), "close", profit, float32(0), gomock.Any()).
+		DoAndReturn(func(context.Context, string, int, string, float32, string, float32, float32, domain.Event) error {
+			close(closed)
+			return nil
+		})
+	repo.EXPECT().GetTotalProfitDb(context.Background()).Return(profit, nil)
+
+	serv := NewRobotService(repo, logger)
+	serv.SetDCA(domain.DCA{SafetyOrders: 1, Deviation: 1, SizeMultiplier: 2})
+	// Цена падает до страховочного ордера, а затем поднимается выше тейк-профита от средней цены,
+	// который ниже тейк-профита от цены первого входа
+	ch <- domain.WsResponse{Bid: 49400, Ask: 49400}
+	ch <- domain.WsResponse{Bid: 49900, Ask: 49900}
+	serv.SetParams(1, 1, 0.5, "PI_XBTUSD", "buy")
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("DCA cycle is not closed")
+	}
+	assert.Eventually(t, func() bool { return serv.GetParams().Start == 0 }, time.Second, 10*time.Millisecond)
+}
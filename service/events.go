@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// eventsBuffer - сколько событий ждет медленного подписчика. Если он не успевает их забирать, новые события пропускаются
+const eventsBuffer = 100
+
+// Events рассылает события робота подписчикам, например gRPC клиентам WatchEvents
+type Events struct {
+	subs map[chan domain.Event]struct{}
+	mu   sync.Mutex
+}
+
+func NewEvents() *Events {
+	return &Events{subs: make(map[chan domain.Event]struct{})}
+}
+
+func (e *Events) Subscribe() (chan domain.Event, func()) {
+	ch := make(chan domain.Event, eventsBuffer)
+	e.mu.Lock()
+	e.subs[ch] = struct{}{}
+	e.mu.Unlock()
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, ok := e.subs[ch]; ok {
+			delete(e.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (e *Events) Publish(ev domain.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// eventsRepo передает подписчикам Events все события, о которых робот отправляет уведомления.
+// Событие сделки передается после ее записи, если запись не удалась - вместе с уведомлением через Notify
+type eventsRepo struct {
+	repoInterface
+	events *Events
+}
+
+func (r *eventsRepo) Notify(ev domain.Event) {
+	r.events.Publish(ev)
+	r.repoInterface.Notify(ev)
+}
+
+func (r *eventsRepo) WriteOrderWithEvent(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit float32, stoploss float32, ev domain.Event) error {
+	err := r.repoInterface.WriteOrderWithEvent(ctx, inst, size, side, price, ordtype, profit, stoploss, ev)
+	if err == nil {
+		r.events.Publish(ev)
+	}
+	return err
+}
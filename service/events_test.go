@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	events := NewEvents()
+	first, cancelFirst := events.Subscribe()
+	second, cancelSecond := events.Subscribe()
+
+	ev := domain.Event{Type: domain.EventOrderOpened, Ticker: "PI_XBTUSD"}
+	events.Publish(ev)
+	assert.Equal(t, ev, <-first)
+	assert.Equal(t, ev, <-second)
+
+	cancelFirst()
+	cancelFirst()
+	_, ok := <-first
+	assert.False(t, ok)
+
+	// Подписчик, который не забирает события, не блокирует остальных
+	for i := 0; i < eventsBuffer+10; i++ {
+		events.Publish(domain.Event{Type: domain.EventGridStarted, Number: i})
+	}
+	assert.Len(t, second, eventsBuffer)
+	cancelSecond()
+}
+
+func TestEventsRepo(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	events := NewEvents()
+	wrapped := &eventsRepo{repoInterface: repo, events: events}
+	ch, cancel := events.Subscribe()
+	defer cancel()
+
+	rejected := domain.Event{Type: domain.EventOrderRejected, Reason: "error"}
+	repo.EXPECT().Notify(rejected)
+	wrapped.Notify(rejected)
+	assert.Equal(t, rejected, <-ch)
+
+	opened := domain.Event{Type: domain.EventOrderOpened, Ticker: "PI_XBTUSD"}
+	repo.EXPECT().WriteOrderWithEvent(context.Background(), "PI_XBTUSD", 1, "buy", float32(50000), "open", float32(0), float32(1), opened).Return(nil)
+	assert.NoError(t, wrapped.WriteOrderWithEvent(context.Background(), "PI_XBTUSD", 1, "buy", 50000, "open", 0, 1, opened))
+	assert.Equal(t, opened, <-ch)
+
+	// Если сделку не удалось записать, событие передается один раз - уведомлением, которое отправляется вместо записи
+	closed := domain.Event{Type: domain.EventOrderClosed, Ticker: "PI_XBTUSD"}
+	repo.EXPECT().WriteOrderWithEvent(context.Background(), "PI_XBTUSD", 1, "sell", float32(51000), "close", float32(1), float32(0), closed).Return(errors.New("db error"))
+	repo.EXPECT().Notify(closed)
+	writeOrder(wrapped, log.New(), "PI_XBTUSD", 1, "sell", 51000, "close", 1, 0, closed)
+	assert.Equal(t, closed, <-ch)
+	assert.Empty(t, ch)
+}
+
+func TestStateEvents(t *testing.T) {
+	robot := &RobotService{events: NewEvents()}
+	ch, cancel := robot.events.Subscribe()
+	defer cancel()
+
+	// Первая остановка робота, который еще не запускался, не считается сменой состояния
+	robot.setStopped()
+	robot.beginCycle()
+	robot.beginCycle()
+	robot.finishCycle(1.5, true)
+	robot.setStopped()
+
+	assert.Len(t, ch, 2)
+	ev := <-ch
+	assert.Equal(t, domain.EventStateChanged, ev.Type)
+	assert.Equal(t, stateTrading, ev.State)
+	ev = <-ch
+	assert.Equal(t, stateStopped, ev.State)
+	assert.Equal(t, 1, ev.Number)
+	assert.Equal(t, float32(1.5), ev.Profit)
+}
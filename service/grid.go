@@ -0,0 +1,425 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	cancelOrderAddr = "https://demo-futures.kraken.com/derivatives/api/v3/cancelorder"
+	fillsAddr       = "https://demo-futures.kraken.com/derivatives/api/v3/fills"
+)
+
+var errScheduleBlackout = domain.ErrTradingNotAllowed
+
+type gridOrder struct {
+	domain.GridOrder
+	paired bool // ордер завершает пару с исполненным ордером соседнего уровня
+	filled float32
+}
+
+// gridPlan - ордер, который нужно выставить на уровне level
+type gridPlan struct {
+	side   string
+	level  int
+	paired bool
+}
+
+// Grid - стратегия, которая выставляет лестницу лимитных ордеров на покупку ниже цены и на продажу выше,
+// а после исполнения ордера выставляет противоположный ордер на соседнем уровне.
+// mu защищает только состояние сетки: запросы к бирже выполняются без него, чтобы Status и kill switch
+// не ждали ответа биржи
+type Grid struct {
+	repo        repoInterface
+	log         logrus.FieldLogger
+	risk        *RiskManager
+	instruments *Instruments
+	allowed     func() bool // false - расписание сейчас запрещает торговлю
+	params      domain.GridParams
+	inst        domain.Instrument
+	levels      []float32
+	orders      map[string]*gridOrder
+	seenFills   map[string]bool // исполнения из последнего ответа биржи, которые уже учтены
+	position    int
+	trades      int
+	profit      float32
+	active      bool
+	busy        bool // сетка запускается или останавливается
+	stop        chan struct{}
+	mu          sync.Mutex
+}
+
+func NewGrid(repo repoInterface, logger logrus.FieldLogger, risk *RiskManager, instruments *Instruments, allowed func() bool) *Grid {
+	return &Grid{
+		repo:        repo,
+		log:         logger,
+		risk:        risk,
+		instruments: instruments,
+		allowed:     allowed,
+	}
+}
+
+func (g *Grid) Start(params domain.GridParams) error {
+	g.mu.Lock()
+	if g.active || g.busy {
+		g.mu.Unlock()
+		return errors.New("grid is already active or is stopping")
+	}
+	g.busy = true
+	g.mu.Unlock()
+
+	err := g.start(params)
+	g.mu.Lock()
+	g.busy = false
+	g.mu.Unlock()
+	return err
+}
+
+func (g *Grid) start(params domain.GridParams) error {
+	if g.risk.Killed() {
+		return ErrKillSwitch
+	}
+	if !g.allowed() {
+		return errScheduleBlackout
+	}
+	price, err := g.currentPrice(params.Ticker)
+	if err != nil {
+		return err
+	}
+	if price <= params.Lower || price >= params.Upper {
+		return fmt.Errorf("current price %.1f is out of grid range", price)
+	}
+
+	inst, _ := g.instruments.Get(params.Ticker)
+	levels := gridLevels(inst, params.Lower, params.Upper, params.Levels)
+	// Ближайший к цене уровень остается пустым, чтобы ордер не исполнился сразу
+	var plan []gridPlan
+	var buys, sells int
+	step := levels[1] - levels[0]
+	for i, level := range levels {
+		if float32(math.Abs(float64(level-price))) < step/2 {
+			continue
+		}
+		side := "buy"
+		if level > price {
+			side = "sell"
+		}
+		if side == "buy" {
+			buys++
+		} else {
+			sells++
+		}
+		plan = append(plan, gridPlan{side: side, level: i})
+	}
+	// Риск-менеджер проверяет худший случай - исполнение всех ордеров одной стороны
+	worst := buys
+	if sells > worst {
+		worst = sells
+	}
+	worst *= params.Size
+	if err = g.risk.CheckOpen(params.Ticker, worst, notional(inst, worst, price)); err != nil {
+		return err
+	}
+
+	orders := make(map[string]*gridOrder, len(plan))
+	for _, pl := range plan {
+		o, err := g.send(params, levels[pl.level], pl)
+		if err != nil {
+			_ = g.cancelOrders(orders)
+			return err
+		}
+		orders[o.OrderID] = o
+	}
+	// Kill switch, включенный во время запуска, не видит сетку активной
+	if g.risk.Killed() {
+		_ = g.cancelOrders(orders)
+		return ErrKillSwitch
+	}
+
+	g.mu.Lock()
+	g.params = params
+	g.inst = inst
+	g.levels = levels
+	g.orders = orders
+	g.seenFills = make(map[string]bool)
+	g.position, g.trades, g.profit = 0, 0, 0
+	g.active = true
+	g.stop = make(chan struct{})
+	go g.run(g.stop)
+	g.mu.Unlock()
+	g.repo.Notify(domain.Event{Type: domain.EventGridStarted, Ticker: params.Ticker, Lower: params.Lower, Upper: params.Upper, Number: params.Levels, Size: params.Size})
+	return nil
+}
+
+// Stop отменяет все ордера сетки. Если flatten, открытая сеткой позиция, в том числе исполненная часть
+// отмененных ордеров, закрывается рыночным ордером
+func (g *Grid) Stop(flatten bool) error {
+	g.mu.Lock()
+	if !g.active {
+		g.mu.Unlock()
+		return errors.New("grid is not active")
+	}
+	close(g.stop)
+	g.active = false
+	g.busy = true
+	orders := g.orders
+	g.orders = make(map[string]*gridOrder)
+	// Частично исполненные ордера отменяются, а их исполненная часть остается позицией сетки
+	for _, o := range orders {
+		if filled := int(o.filled); filled > 0 {
+			g.addPosition(o.Side, filled, o.Price)
+			o.filled -= float32(filled)
+		}
+	}
+	params, inst, position := g.params, g.inst, g.position
+	g.mu.Unlock()
+
+	err := g.cancelOrders(orders)
+	if flatten && position != 0 {
+		side, size := "sell", position
+		if position < 0 {
+			side, size = "buy", -position
+		}
+		resp, sendErr := g.repo.SendOrder(strings.ToLower(params.Ticker), side, size, sendOrderAddr)
+		if sendErr != nil || resp.Result != "success" || resp.SendStatus.Status != "placed" {
+			g.log.Errorln("Can't close grid position: ", sendErr, GetError(resp))
+			err = errors.New("can't close grid position")
+		} else {
+			var price float32
+			if len(resp.SendStatus.OrderEvents) > 0 {
+				price = resp.SendStatus.OrderEvents[0].Price
+			}
+			g.risk.OnReduce(params.Ticker, size, notional(inst, size, price))
+			position = 0
+		}
+	}
+
+	g.mu.Lock()
+	// Ордера, которые не удалось отменить, и незакрытая позиция остаются в статусе
+	for id, o := range orders {
+		g.orders[id] = o
+	}
+	g.position = position
+	g.busy = false
+	trades, profit := g.trades, g.profit
+	g.mu.Unlock()
+	g.repo.Notify(domain.Event{Type: domain.EventGridStopped, Ticker: params.Ticker, Number: trades, Profit: profit})
+	return err
+}
+
+func (g *Grid) Status() domain.GridStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	status := domain.GridStatus{
+		Active: g.active,
+		Params: g.params,
+		Trades: g.trades,
+		Profit: g.profit,
+	}
+	for _, o := range g.orders {
+		status.Orders = append(status.Orders, o.GridOrder)
+	}
+	return status
+}
+
+func (g *Grid) run(stop chan struct{}) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if g.risk.Killed() {
+			g.log.Warnln("Kill switch is engaged, stopping grid")
+			_ = g.Stop(true)
+			return
+		}
+		fills, err := g.repo.GetFills(fillsAddr)
+		if err != nil {
+			g.log.Errorln("Can't get fills: ", err)
+			continue
+		}
+		g.onFills(fills)
+	}
+}
+
+// gridFill - исполненный ордер сетки, который нужно записать в базу
+type gridFill struct {
+	order  *gridOrder
+	profit float32
+	ev     domain.Event
+}
+
+// onFills учитывает исполнения под mu, а запись в базу и новые ордера выставляет уже без него
+func (g *Grid) onFills(fills []domain.Fill) {
+	g.mu.Lock()
+	if !g.active {
+		g.mu.Unlock()
+		return
+	}
+	// Исполнения, которых уже нет в ответе биржи, больше не придут, поэтому хранятся только последние
+	seen := make(map[string]bool, len(fills))
+	var done []gridFill
+	var next []gridPlan
+	for _, fill := range fills {
+		seen[fill.FillID] = true
+		o, ok := g.orders[fill.OrderID]
+		if !ok || g.seenFills[fill.FillID] {
+			continue
+		}
+		o.filled += fill.Size
+		if o.filled < float32(g.params.Size) {
+			continue
+		}
+		delete(g.orders, fill.OrderID)
+		fill, pl, ok := g.onFilled(o)
+		done = append(done, fill)
+		if ok {
+			next = append(next, pl)
+		}
+	}
+	g.seenFills = seen
+	params, levels, stop := g.params, g.levels, g.stop
+	g.mu.Unlock()
+
+	for _, f := range done {
+		writeOrder(g.repo, g.log, params.Ticker, params.Size, f.order.Side, f.order.Price, "grid", f.profit, 0, f.ev)
+	}
+	for _, pl := range next {
+		o, err := g.send(params, levels[pl.level], pl)
+		if err != nil {
+			g.log.Errorln("Can't place grid order: ", err)
+			g.repo.Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: params.Ticker, Side: pl.side, Size: params.Size, Reason: err.Error()})
+			continue
+		}
+		g.mu.Lock()
+		active := g.active && g.stop == stop
+		if active {
+			g.orders[o.OrderID] = o
+		}
+		g.mu.Unlock()
+		// Сетка остановлена, пока ордер выставлялся
+		if !active {
+			_ = g.cancelOrders(map[string]*gridOrder{o.OrderID: o})
+		}
+	}
+}
+
+// addPosition учитывает size исполненных контрактов ордера сетки в ее позиции. Вызывается под mu
+func (g *Grid) addPosition(side string, size int, price float32) {
+	before := g.position
+	if side == "buy" {
+		g.position += size
+	} else {
+		g.position -= size
+	}
+	// Риск-менеджер учитывает позицию сетки так же, как позицию основного цикла
+	if abs(g.position) > abs(before) {
+		g.risk.OnOpen(g.params.Ticker, side, size, notional(g.inst, size, price))
+	} else {
+		g.risk.OnReduce(g.params.Ticker, size, notional(g.inst, size, price))
+	}
+}
+
+// onFilled учитывает исполненный ордер и решает, какой ордер выставить следом. Исполнение парного ордера
+// закрывает сделку, остальные ее открывают. Вызывается под mu
+func (g *Grid) onFilled(o *gridOrder) (gridFill, gridPlan, bool) {
+	size := g.params.Size
+	fill := gridFill{order: o, ev: domain.Event{Type: domain.EventOrderOpened, Ticker: g.params.Ticker, Side: o.Side, Size: size, Price: o.Price}}
+	g.addPosition(o.Side, size, o.Price)
+	if o.paired {
+		origin := o.Level + 1
+		if o.Side == "sell" {
+			origin = o.Level - 1
+		}
+		fill.profit = pnl(g.inst, reverseSide(o.Side), size, g.levels[origin], o.Price)
+		g.trades++
+		g.profit += fill.profit
+		g.risk.OnResult(fill.profit)
+		fill.ev.Type, fill.ev.OpenPrice, fill.ev.Profit, fill.ev.TotalProfit = domain.EventOrderClosed, g.levels[origin], fill.profit, g.profit
+	}
+
+	next, side := o.Level+1, "sell"
+	if o.Side == "sell" {
+		next, side = o.Level-1, "buy"
+	}
+	if next < 0 || next >= len(g.levels) {
+		return fill, gridPlan{}, false
+	}
+	// Ордер, который увеличит позицию, проходит те же проверки, что и новые сделки
+	increases := (side == "buy" && g.position >= 0) || (side == "sell" && g.position <= 0)
+	if increases {
+		err := g.risk.CheckOpen(g.params.Ticker, size, notional(g.inst, size, g.levels[next]))
+		if err == nil && !g.allowed() {
+			err = errScheduleBlackout
+		}
+		if err != nil {
+			g.log.Infoln("Grid order blocked: ", err)
+			g.repo.Notify(domain.Event{Type: domain.EventRiskLimitHit, Ticker: g.params.Ticker, Side: side, Size: size, Reason: err.Error()})
+			return fill, gridPlan{}, false
+		}
+	}
+	return fill, gridPlan{side: side, level: next, paired: true}, true
+}
+
+// send выставляет лимитный ордер на бирже. Состояние сетки не меняет и вызывается без mu
+func (g *Grid) send(params domain.GridParams, price float32, pl gridPlan) (*gridOrder, error) {
+	if g.risk.Killed() {
+		return nil, ErrKillSwitch
+	}
+	resp, err := g.repo.SendLimitOrder(strings.ToLower(params.Ticker), pl.side, params.Size, price, sendOrderAddr)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Result != "success" || resp.SendStatus.Status != "placed" {
+		return nil, errors.New(strings.TrimSpace(GetError(resp)))
+	}
+	return &gridOrder{
+		GridOrder: domain.GridOrder{OrderID: resp.SendStatus.OrderID, Side: pl.side, Price: price, Level: pl.level},
+		paired:    pl.paired,
+	}, nil
+}
+
+// cancelOrders отменяет ордера и удаляет отмененные из orders. Вызывается без mu
+func (g *Grid) cancelOrders(orders map[string]*gridOrder) error {
+	var err error
+	for id := range orders {
+		cancelErr := g.repo.CancelOrder(id, cancelOrderAddr)
+		if cancelErr != nil {
+			g.log.Errorln("Can't cancel grid order: ", cancelErr)
+			err = cancelErr
+			continue
+		}
+		delete(orders, id)
+	}
+	return err
+}
+
+func (g *Grid) currentPrice(ticker string) (float32, error) {
+	priceChan, cancel, err := g.repo.SetWSConnection(wsAddr, ticker)
+	if err != nil {
+		return 0, err
+	}
+	price := midPrice(<-priceChan)
+	cancel()
+	return price, nil
+}
+
+func gridLevels(inst domain.Instrument, lower, upper float32, n int) []float32 {
+	levels := make([]float32, n)
+	step := (upper - lower) / float32(n-1)
+	for i := range levels {
+		levels[i] = roundPrice(inst, lower+step*float32(i))
+	}
+	return levels
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func allowAll() bool { return true }
+
+func TestGrid(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	logger := log.New()
+	repo := mock_service.NewMockrepoInterface(c)
+	ch := make(chan domain.WsResponse, 1)
+	ch <- domain.WsResponse{Bid: 50000, Ask: 50000}
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").Return(ch, func() {}, nil)
+	repo.EXPECT().SendLimitOrder("pi_xbtusd", gomock.Any(), 1, gomock.Any(), sendOrderAddr).DoAndReturn(
+		func(symbol, side string, size int, price float32, addr string) (domain.APIResp, error) {
+			id := fmt.Sprintf("%s-%.0f", side, price)
+			return domain.APIResp{Result: "success", SendStatus: domain.SendStatus{OrderID: id, Status: "placed"}}, nil
+		}).Times(6)
+	repo.EXPECT().Notify(gomock.Any()).AnyTimes()
+	// Покупка открывает сделку, парная продажа закрывает ее с прибылью
+	profit := pnl(defaultInstruments[0], "buy", 1, 49500, 50000)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), "PI_XBTUSD", 1, "buy", float32(49500), "grid", float32(0), float32(0),
+		domain.Event{Type: domain.EventOrderOpened, Ticker: "PI_XBTUSD", Side: "buy", Size: 1, Price: 49500}).Return(nil)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), "PI_XBTUSD", 1, "sell", float32(50000), "grid", profit, float32(0),
+		domain.Event{Type: domain.EventOrderClosed, Ticker: "PI_XBTUSD", Side: "sell", Size: 1, Price: 50000, OpenPrice: 49500, Profit: profit, TotalProfit: profit}).Return(nil)
+	repo.EXPECT().CancelOrder(gomock.Any(), cancelOrderAddr).Return(nil).Times(4)
+
+	grid := NewGrid(repo, logger, NewRiskManager(), NewInstruments(repo, logger), allowAll)
+	err := grid.Start(domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 1})
+	assert.NoError(t, err)
+
+	status := grid.Status()
+	assert.True(t, status.Active)
+	assert.Len(t, status.Orders, 4)
+
+	// Исполнилась покупка на 49500 - выставляется продажа на 50000
+	grid.onFills([]domain.Fill{{FillID: "f1", OrderID: "buy-49500", Size: 1, Price: 49500}})
+	// Повторно пришедшее исполнение не учитывается
+	grid.onFills([]domain.Fill{{FillID: "f1", OrderID: "buy-49500", Size: 1, Price: 49500}})
+	assert.Len(t, grid.Status().Orders, 4)
+	assert.Equal(t, 0, grid.Status().Trades)
+
+	assert.Equal(t, map[string]int{"PI_XBTUSD": 1}, grid.risk.Status().Positions)
+
+	// Продажа на 50000 завершает пару и выставляет покупку на 49500
+	grid.onFills([]domain.Fill{{FillID: "f2", OrderID: "sell-50000", Size: 1, Price: 50000}})
+	status = grid.Status()
+	assert.Equal(t, 1, status.Trades)
+	assert.Equal(t, profit, status.Profit)
+	assert.Len(t, status.Orders, 4)
+	assert.Empty(t, grid.risk.Status().Positions)
+	// Хранятся только исполнения из последнего ответа биржи
+	assert.Equal(t, map[string]bool{"f2": true}, grid.seenFills)
+
+	assert.Error(t, grid.Start(domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 1}))
+	assert.NoError(t, grid.Stop(false))
+	assert.False(t, grid.Status().Active)
+	assert.Len(t, grid.Status().Orders, 0)
+	assert.Error(t, grid.Stop(false))
+}
+
+func TestGridPriceOutOfRange(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	logger := log.New()
+	repo := mock_service.NewMockrepoInterface(c)
+	ch := make(chan domain.WsResponse, 1)
+	ch <- domain.WsResponse{Bid: 52000, Ask: 52000}
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").Return(ch, func() {}, nil)
+
+	grid := NewGrid(repo, logger, NewRiskManager(), NewInstruments(repo, logger), allowAll)
+	err := grid.Start(domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 1})
+	assert.Error(t, err)
+	assert.False(t, grid.Status().Active)
+}
+
+func TestGridRiskAndSchedule(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	logger := log.New()
+	repo := mock_service.NewMockrepoInterface(c)
+	ch := make(chan domain.WsResponse, 1)
+	ch <- domain.WsResponse{Bid: 50000, Ask: 50000}
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").Return(ch, func() {}, nil)
+
+	// В запрещенный интервал сетка не запускается и не обращается к бирже
+	grid := NewGrid(repo, logger, NewRiskManager(), NewInstruments(repo, logger), func() bool { return false })
+	params := domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 1}
+	assert.Equal(t, errScheduleBlackout, grid.Start(params))
+
+	// Если исполнятся все покупки, позиция будет 2 контракта, а ограничение - 1
+	risk := NewRiskManager()
+	risk.SetLimits(domain.RiskLimits{MaxPosition: 1})
+	grid = NewGrid(repo, logger, risk, NewInstruments(repo, logger), allowAll)
+	assert.Error(t, grid.Start(params))
+	assert.False(t, grid.Status().Active)
+}
+
+func TestGridStatusDuringStart(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	logger := log.New()
+	repo := mock_service.NewMockrepoInterface(c)
+	ch := make(chan domain.WsResponse)
+	started := make(chan struct{})
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").DoAndReturn(
+		func(string, string) (chan domain.WsResponse, func(), error) {
+			close(started)
+			return ch, func() {}, nil
+		})
+
+	grid := NewGrid(repo, logger, NewRiskManager(), NewInstruments(repo, logger), allowAll)
+	done := make(chan error, 1)
+	go func() {
+		done <- grid.Start(domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 1})
+	}()
+	<-started
+
+	// Пока сетка ждет цену от биржи, статус и повторный запуск не блокируются
+	status := make(chan domain.GridStatus, 1)
+	go func() { status <- grid.Status() }()
+	select {
+	case st := <-status:
+		assert.False(t, st.Active)
+	case <-time.After(time.Second):
+		t.Fatal("Status is blocked by Start")
+	}
+	assert.Error(t, grid.Start(domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 1}))
+
+	ch <- domain.WsResponse{Bid: 52000, Ask: 52000}
+	assert.Error(t, <-done)
+}
+
+// partialGrid запускает сетку с ордерами по 3 контракта, которые исполняются частично
+func partialGrid(t *testing.T, repo *mock_service.MockrepoInterface) *Grid {
+	logger := log.New()
+	ch := make(chan domain.WsResponse, 1)
+	ch <- domain.WsResponse{Bid: 50000, Ask: 50000}
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").Return(ch, func() {}, nil)
+	repo.EXPECT().SendLimitOrder("pi_xbtusd", gomock.Any(), 3, gomock.Any(), sendOrderAddr).DoAndReturn(
+		func(symbol, side string, size int, price float32, addr string) (domain.APIResp, error) {
+			id := fmt.Sprintf("%s-%.0f", side, price)
+			return domain.APIResp{Result: "success", SendStatus: domain.SendStatus{OrderID: id, Status: "placed"}}, nil
+		}).Times(4)
+	repo.EXPECT().Notify(gomock.Any()).AnyTimes()
+	repo.EXPECT().CancelOrder(gomock.Any(), cancelOrderAddr).Return(nil).Times(4)
+
+	grid := NewGrid(repo, logger, NewRiskManager(), NewInstruments(repo, logger), allowAll)
+	assert.NoError(t, grid.Start(domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 3}))
+	return grid
+}
+
+func TestGridStopPartialFill(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	grid := partialGrid(t, repo)
+
+	// Покупка на 49500 исполнилась на 2 контракта из 3
+	grid.onFills([]domain.Fill{{FillID: "f1", OrderID: "buy-49500", Size: 2, Price: 49500}})
+	assert.Empty(t, grid.risk.Status().Positions)
+
+	// При остановке исполненная часть закрывается вместе с позицией сетки
+	repo.EXPECT().SendOrder("pi_xbtusd", "sell", 2, sendOrderAddr).Return(filledOrder(49400), nil)
+	assert.NoError(t, grid.Stop(true))
+	assert.Empty(t, grid.risk.Status().Positions)
+	assert.Equal(t, float32(0), grid.risk.Status().Exposure)
+}
+
+func TestGridStopKeepsPartialFill(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	grid := partialGrid(t, repo)
+	grid.onFills([]domain.Fill{{FillID: "f1", OrderID: "sell-50500", Size: 1, Price: 50500}})
+
+	// Без закрытия исполненная часть остается в лимитах риск-менеджера
+	assert.NoError(t, grid.Stop(false))
+	assert.Equal(t, map[string]int{"PI_XBTUSD": -1}, grid.risk.Status().Positions)
+}
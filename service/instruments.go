@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const instrumentsAddr = "https://demo-futures.kraken.com/derivatives/api/v3/instruments"
+
+// Спецификации бессрочных контрактов, с которыми робот работает до первого ответа от биржи
+var defaultInstruments = []domain.Instrument{
+	{Symbol: "PI_XBTUSD", Type: "futures_inverse", Tradeable: true, TickSize: 0.5, ContractSize: 1, MinOrderSize: 1, MaxLeverage: 50},
+	{Symbol: "PI_ETHUSD", Type: "futures_inverse", Tradeable: true, TickSize: 0.05, ContractSize: 1, MinOrderSize: 1, MaxLeverage: 50},
+	{Symbol: "PI_LTCUSD", Type: "futures_inverse", Tradeable: true, TickSize: 0.01, ContractSize: 1, MinOrderSize: 1, MaxLeverage: 50},
+	{Symbol: "PI_XRPUSD", Type: "futures_inverse", Tradeable: true, TickSize: 0.0001, ContractSize: 1, MinOrderSize: 1, MaxLeverage: 50},
+	{Symbol: "PI_BCHUSD", Type: "futures_inverse", Tradeable: true, TickSize: 0.1, ContractSize: 1, MinOrderSize: 1, MaxLeverage: 50},
+}
+
+type Instruments struct {
+	repo     repoInterface
+	log      logrus.FieldLogger
+	byTicker map[string]domain.Instrument
+	mu       sync.RWMutex
+}
+
+func NewInstruments(repo repoInterface, logger logrus.FieldLogger) *Instruments {
+	byTicker := make(map[string]domain.Instrument, len(defaultInstruments))
+	for _, inst := range defaultInstruments {
+		byTicker[inst.Symbol] = inst
+	}
+	return &Instruments{
+		repo:     repo,
+		log:      logger,
+		byTicker: byTicker,
+	}
+}
+
+func (i *Instruments) Get(ticker string) (domain.Instrument, bool) {
+	i.mu.RLock()
+	inst, ok := i.byTicker[ticker]
+	i.mu.RUnlock()
+	return inst, ok
+}
+
+// Refresh заменяет инструменты списком от биржи. Пустой список считается ошибкой: иначе до следующего
+// обновления робот не сможет торговать ни одним инструментом
+func (i *Instruments) Refresh() error {
+	list, err := i.repo.GetInstruments(instrumentsAddr)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return errors.New("exchange returned no instruments")
+	}
+	byTicker := make(map[string]domain.Instrument, len(list))
+	for _, inst := range list {
+		byTicker[inst.Symbol] = inst
+	}
+	i.mu.Lock()
+	i.byTicker = byTicker
+	i.mu.Unlock()
+	return nil
+}
+
+// Watch загружает инструменты при старте и затем обновляет их с периодом period
+func (i *Instruments) Watch(period time.Duration) {
+	for {
+		if err := i.Refresh(); err != nil {
+			i.log.Errorln("Can't load instruments: ", err)
+		}
+		time.Sleep(period)
+	}
+}
+
+// roundSize округляет размер сделки вниз до шага минимального размера ордера
+func roundSize(inst domain.Instrument, size int) int {
+	if inst.MinOrderSize <= 1 {
+		return size
+	}
+	step := int(inst.MinOrderSize)
+	return size / step * step
+}
+
+// roundPrice округляет цену до шага цены инструмента
+func roundPrice(inst domain.Instrument, price float32) float32 {
+	if inst.TickSize <= 0 {
+		return price
+	}
+	return float32(math.Round(float64(price/inst.TickSize))) * inst.TickSize
+}
+
+// pnl считает результат сделки в долларах. side - направление открытия позиции
+func pnl(inst domain.Instrument, side string, size int, open, closePrice float32) float32 {
+	diff := closePrice - open
+	if side == "sell" {
+		diff *= -1
+	}
+	// У инверсных контрактов размер задан в долларах, а результат считается в базовой валюте
+	if inst.Type == "futures_inverse" && open != 0 {
+		return float32(size) * contractSize(inst) * diff / open
+	}
+	return float32(size) * contractSize(inst) * diff
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentsRefresh(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_service.NewMockrepoInterface(c)
+	newContract := domain.Instrument{Symbol: "PF_SOLUSD", Type: "flexible_futures", Tradeable: true, TickSize: 0.01, ContractSize: 1, MinOrderSize: 1, MaxLeverage: 20}
+	repo.EXPECT().GetInstruments(instrumentsAddr).Return([]domain.Instrument{newContract}, nil)
+	repo.EXPECT().GetInstruments(instrumentsAddr).Return(nil, errors.New("error"))
+	repo.EXPECT().GetInstruments(instrumentsAddr).Return([]domain.Instrument{}, nil)
+
+	instruments := NewInstruments(repo, log.New())
+	_, ok := instruments.Get("PI_XBTUSD")
+	assert.True(t, ok)
+
+	assert.NoError(t, instruments.Refresh())
+	got, ok := instruments.Get("PF_SOLUSD")
+	assert.True(t, ok)
+	assert.Equal(t, newContract, got)
+	_, ok = instruments.Get("PI_XBTUSD")
+	assert.False(t, ok)
+
+	// При ошибке запроса остаются загруженные ранее инструменты
+	assert.Error(t, instruments.Refresh())
+	_, ok = instruments.Get("PF_SOLUSD")
+	assert.True(t, ok)
+
+	// Пустой ответ биржи тоже не заменяет инструменты
+	assert.Error(t, instruments.Refresh())
+	_, ok = instruments.Get("PF_SOLUSD")
+	assert.True(t, ok)
+}
+
+func TestRoundPrice(t *testing.T) {
+	inst := domain.Instrument{TickSize: 0.5}
+	assert.Equal(t, float32(50005), roundPrice(inst, 50005.2))
+	assert.Equal(t, float32(50005.5), roundPrice(inst, 50005.3))
+	assert.Equal(t, float32(1.2345), roundPrice(domain.Instrument{}, 1.2345))
+}
+
+func TestPnl(t *testing.T) {
+	inverse := domain.Instrument{Type: "futures_inverse", ContractSize: 1}
+	linear := domain.Instrument{Type: "flexible_futures", ContractSize: 1}
+	assert.Equal(t, float32(20), pnl(inverse, "buy", 1000, 50000, 51000))
+	assert.Equal(t, float32(-20), pnl(inverse, "sell", 1000, 50000, 51000))
+	assert.Equal(t, float32(2000), pnl(linear, "buy", 2, 50000, 51000))
+	assert.Equal(t, float32(2000), pnl(linear, "sell", 2, 51000, 50000))
+}
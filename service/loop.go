@@ -0,0 +1,113 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+const (
+	stateStopped  = "stopped"
+	stateTrading  = "trading"
+	stateCooldown = "cooldown"
+)
+
+func (r *RobotService) SetLoop(loop domain.Loop) {
+	r.mu.Lock()
+	r.params.Loop = loop
+	r.mu.Unlock()
+}
+
+func (r *RobotService) GetStatus() domain.RobotStatus {
+	params := r.GetParams()
+	r.mu.Lock()
+	status := domain.RobotStatus{
+		State:      r.state,
+		Params:     params,
+		Cycle:      r.cycle,
+		CyclesLeft: -1,
+		PnL:        r.cyclePnL,
+	}
+	r.mu.Unlock()
+
+	if status.State == "" {
+		status.State = stateStopped
+	}
+	if !params.Loop.Enabled {
+		return status
+	}
+	if params.Cycles > 0 {
+		status.CyclesLeft = params.Cycles - status.Cycle
+	}
+	if params.TargetPnL > 0 {
+		status.ToTarget = params.TargetPnL - status.PnL
+	}
+	if params.LossLimit > 0 {
+		status.LossBudget = params.LossLimit + status.PnL
+	}
+	return status
+}
+
+func (r *RobotService) setStopped() {
+	r.mu.Lock()
+	changed := r.setState(stateStopped)
+	r.mu.Unlock()
+	r.publishState(changed)
+}
+
+// setState меняет состояние робота и сообщает, изменилось ли оно. Вызывается под mu
+func (r *RobotService) setState(state string) bool {
+	changed := r.state != state && !(r.state == "" && state == stateStopped)
+	r.state = state
+	return changed
+}
+
+// publishState передает подписчикам событий новое состояние робота
+func (r *RobotService) publishState(changed bool) {
+	if !changed || r.events == nil {
+		return
+	}
+	st := r.GetStatus()
+	r.events.Publish(domain.Event{Type: domain.EventStateChanged, State: st.State, Ticker: st.Params.Ticker, Number: st.Cycle, Profit: st.PnL})
+}
+
+// beginCycle начинает новую серию сделок, если робот до этого был остановлен
+func (r *RobotService) beginCycle() {
+	r.mu.Lock()
+	if r.state == stateStopped || r.state == "" {
+		r.cycle = 0
+		r.cyclePnL = 0
+	}
+	changed := r.setState(stateTrading)
+	r.mu.Unlock()
+	r.publishState(changed)
+}
+
+// finishCycle учитывает результат закрытой сделки в долларах и решает, продолжать ли работу.
+// stopped - сделка закрыта по сигналу к остановке
+func (r *RobotService) finishCycle(profit float32, stopped bool) {
+	r.mu.Lock()
+	r.cycle++
+	r.cyclePnL += profit
+	loop := r.params.Loop
+	done := stopped || !loop.Enabled ||
+		(loop.Cycles > 0 && r.cycle >= loop.Cycles) ||
+		(loop.TargetPnL > 0 && r.cyclePnL >= loop.TargetPnL) ||
+		(loop.LossLimit > 0 && -r.cyclePnL >= loop.LossLimit)
+	if done {
+		r.params.Start = 0
+		changed := r.setState(stateStopped)
+		r.mu.Unlock()
+		r.publishState(changed)
+		return
+	}
+	changed := r.setState(stateCooldown)
+	r.mu.Unlock()
+	r.publishState(changed)
+
+	// Пауза перед следующей сделкой, которую можно прервать сигналом к остановке
+	deadline := time.Now().Add(time.Duration(loop.LoopCooldown) * time.Second)
+	for time.Now().Before(deadline) && r.GetParams().Start == 1 {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinishCycle(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name        string
+		Loop        domain.Loop
+		Profits     []float32
+		Stopped     bool
+		ExpectStart int
+		ExpectState string
+	}
+	tests := [...]Test{
+		{Name: "Single trade", Profits: []float32{5}, ExpectStart: 0, ExpectState: stateStopped},
+		{Name: "Loop continues", Loop: domain.Loop{Enabled: true, Cycles: 3}, Profits: []float32{5, 5}, ExpectStart: 1, ExpectState: stateCooldown},
+		{Name: "Cycles are over", Loop: domain.Loop{Enabled: true, Cycles: 2}, Profits: []float32{5, 5}, ExpectStart: 0, ExpectState: stateStopped},
+		{Name: "Target reached", Loop: domain.Loop{Enabled: true, TargetPnL: 8}, Profits: []float32{5, 5}, ExpectStart: 0, ExpectState: stateStopped},
+		{Name: "Loss limit reached", Loop: domain.Loop{Enabled: true, LossLimit: 8}, Profits: []float32{-5, 1, -5}, ExpectStart: 0, ExpectState: stateStopped},
+		{
+			// 1000 контрактов PI_XBTUSD по $1, цена выросла на 1% - $10 прибыли, цель в $8 достигнута
+			Name:        "Target in dollars on inverse contract",
+			Loop:        domain.Loop{Enabled: true, TargetPnL: 8},
+			Profits:     []float32{pnl(domain.Instrument{Type: "futures_inverse", ContractSize: 1}, "buy", 1000, 50000, 50500)},
+			ExpectStart: 0, ExpectState: stateStopped,
+		},
+		{
+			Name:        "Loss limit in dollars on inverse contract",
+			Loop:        domain.Loop{Enabled: true, LossLimit: 8},
+			Profits:     []float32{pnl(domain.Instrument{Type: "futures_inverse", ContractSize: 1}, "sell", 1000, 50000, 50500)},
+			ExpectStart: 0, ExpectState: stateStopped,
+		},
+		{Name: "Stopped by signal", Loop: domain.Loop{Enabled: true}, Profits: []float32{5}, Stopped: true, ExpectStart: 0, ExpectState: stateStopped},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			robot := &RobotService{log: log.New(), params: domain.Options{Start: 1, Loop: test.Loop}}
+			robot.beginCycle()
+			for _, profit := range test.Profits {
+				robot.finishCycle(profit, test.Stopped)
+			}
+			assert.Equal(t, test.ExpectStart, robot.GetParams().Start)
+			assert.Equal(t, test.ExpectState, robot.GetStatus().State)
+			assert.Equal(t, len(test.Profits), robot.GetStatus().Cycle)
+		})
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	robot := &RobotService{log: log.New(), params: domain.Options{Start: 1, Loop: domain.Loop{Enabled: true, Cycles: 5, TargetPnL: 100, LossLimit: 50}}}
+	robot.beginCycle()
+	robot.finishCycle(-10, false)
+	robot.finishCycle(30, false)
+
+	status := robot.GetStatus()
+	assert.Equal(t, stateCooldown, status.State)
+	assert.Equal(t, 2, status.Cycle)
+	assert.Equal(t, 3, status.CyclesLeft)
+	assert.Equal(t, float32(20), status.PnL)
+	assert.Equal(t, float32(80), status.ToTarget)
+	assert.Equal(t, float32(70), status.LossBudget)
+
+	// Новый запуск после остановки начинает отсчет заново
+	robot.setStopped()
+	robot.beginCycle()
+	assert.Equal(t, 0, robot.GetStatus().Cycle)
+	assert.Equal(t, float32(0), robot.GetStatus().PnL)
+}
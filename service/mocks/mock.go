@@ -8,6 +8,7 @@ import (
 	context "context"
 	domain "github.com/Marseek/tfs-go-hw/course/domain"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +36,124 @@ func (m *MockrepoInterface) EXPECT() *MockrepoInterfaceMockRecorder {
 	return m.recorder
 }
 
+// CancelOrder mocks base method.
+func (m *MockrepoInterface) CancelOrder(orderID, addr string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelOrder", orderID, addr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelOrder indicates an expected call of CancelOrder.
+func (mr *MockrepoInterfaceMockRecorder) CancelOrder(orderID, addr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockrepoInterface)(nil).CancelOrder), orderID, addr)
+}
+
+// CreateUser mocks base method.
+func (m *MockrepoInterface) CreateUser(ctx context.Context, u domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, u)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockrepoInterfaceMockRecorder) CreateUser(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockrepoInterface)(nil).CreateUser), ctx, u)
+}
+
+// GetAvailableMargin mocks base method.
+func (m *MockrepoInterface) GetAvailableMargin(addr string) (float32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAvailableMargin", addr)
+	ret0, _ := ret[0].(float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAvailableMargin indicates an expected call of GetAvailableMargin.
+func (mr *MockrepoInterfaceMockRecorder) GetAvailableMargin(addr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAvailableMargin", reflect.TypeOf((*MockrepoInterface)(nil).GetAvailableMargin), addr)
+}
+
+// GetCandles mocks base method.
+func (m *MockrepoInterface) GetCandles(symbol, resolution string, from, to time.Time, addr string) ([]domain.Candle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCandles", symbol, resolution, from, to, addr)
+	ret0, _ := ret[0].([]domain.Candle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCandles indicates an expected call of GetCandles.
+func (mr *MockrepoInterfaceMockRecorder) GetCandles(symbol, resolution, from, to, addr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCandles", reflect.TypeOf((*MockrepoInterface)(nil).GetCandles), symbol, resolution, from, to, addr)
+}
+
+// GetFills mocks base method.
+func (m *MockrepoInterface) GetFills(addr string) ([]domain.Fill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFills", addr)
+	ret0, _ := ret[0].([]domain.Fill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFills indicates an expected call of GetFills.
+func (mr *MockrepoInterfaceMockRecorder) GetFills(addr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFills", reflect.TypeOf((*MockrepoInterface)(nil).GetFills), addr)
+}
+
+// GetInstruments mocks base method.
+func (m *MockrepoInterface) GetInstruments(addr string) ([]domain.Instrument, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstruments", addr)
+	ret0, _ := ret[0].([]domain.Instrument)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstruments indicates an expected call of GetInstruments.
+func (mr *MockrepoInterfaceMockRecorder) GetInstruments(addr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstruments", reflect.TypeOf((*MockrepoInterface)(nil).GetInstruments), addr)
+}
+
+// GetOutboxStatus mocks base method.
+func (m *MockrepoInterface) GetOutboxStatus(ctx context.Context) (domain.OutboxStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutboxStatus", ctx)
+	ret0, _ := ret[0].(domain.OutboxStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOutboxStatus indicates an expected call of GetOutboxStatus.
+func (mr *MockrepoInterfaceMockRecorder) GetOutboxStatus(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxStatus", reflect.TypeOf((*MockrepoInterface)(nil).GetOutboxStatus), ctx)
+}
+
+// GetRevokedTokens mocks base method.
+func (m *MockrepoInterface) GetRevokedTokens(ctx context.Context) (map[string]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevokedTokens", ctx)
+	ret0, _ := ret[0].(map[string]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevokedTokens indicates an expected call of GetRevokedTokens.
+func (mr *MockrepoInterfaceMockRecorder) GetRevokedTokens(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevokedTokens", reflect.TypeOf((*MockrepoInterface)(nil).GetRevokedTokens), ctx)
+}
+
 // GetTotalProfitDb mocks base method.
 func (m *MockrepoInterface) GetTotalProfitDb(ctx context.Context) (float32, error) {
 	m.ctrl.T.Helper()
@@ -50,18 +169,104 @@ func (mr *MockrepoInterfaceMockRecorder) GetTotalProfitDb(ctx interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalProfitDb", reflect.TypeOf((*MockrepoInterface)(nil).GetTotalProfitDb), ctx)
 }
 
-// GetUsersMap mocks base method.
-func (m *MockrepoInterface) GetUsersMap(arg0 string) map[string]string {
+// GetTrades mocks base method.
+func (m *MockrepoInterface) GetTrades(ctx context.Context, from, to time.Time) ([]domain.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrades", ctx, from, to)
+	ret0, _ := ret[0].([]domain.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrades indicates an expected call of GetTrades.
+func (mr *MockrepoInterfaceMockRecorder) GetTrades(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrades", reflect.TypeOf((*MockrepoInterface)(nil).GetTrades), ctx, from, to)
+}
+
+// GetUser mocks base method.
+func (m *MockrepoInterface) GetUser(ctx context.Context, login string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, login)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockrepoInterfaceMockRecorder) GetUser(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockrepoInterface)(nil).GetUser), ctx, login)
+}
+
+// ListUsers mocks base method.
+func (m *MockrepoInterface) ListUsers(ctx context.Context) ([]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockrepoInterfaceMockRecorder) ListUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockrepoInterface)(nil).ListUsers), ctx)
+}
+
+// Notify mocks base method.
+func (m *MockrepoInterface) Notify(ev domain.Event) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Notify", ev)
+}
+
+// Notify indicates an expected call of Notify.
+func (mr *MockrepoInterfaceMockRecorder) Notify(ev interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockrepoInterface)(nil).Notify), ev)
+}
+
+// RevokeToken mocks base method.
+func (m *MockrepoInterface) RevokeToken(ctx context.Context, id string, expires time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, id, expires)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockrepoInterfaceMockRecorder) RevokeToken(ctx, id, expires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockrepoInterface)(nil).RevokeToken), ctx, id, expires)
+}
+
+// SaveOptimization mocks base method.
+func (m *MockrepoInterface) SaveOptimization(ctx context.Context, report domain.OptimizeReport) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsersMap", arg0)
-	ret0, _ := ret[0].(map[string]string)
+	ret := m.ctrl.Call(m, "SaveOptimization", ctx, report)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// GetUsersMap indicates an expected call of GetUsersMap.
-func (mr *MockrepoInterfaceMockRecorder) GetUsersMap(arg0 interface{}) *gomock.Call {
+// SaveOptimization indicates an expected call of SaveOptimization.
+func (mr *MockrepoInterfaceMockRecorder) SaveOptimization(ctx, report interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersMap", reflect.TypeOf((*MockrepoInterface)(nil).GetUsersMap), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOptimization", reflect.TypeOf((*MockrepoInterface)(nil).SaveOptimization), ctx, report)
+}
+
+// SendLimitOrder mocks base method.
+func (m *MockrepoInterface) SendLimitOrder(symbol, side string, size int, price float32, addr string) (domain.APIResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendLimitOrder", symbol, side, size, price, addr)
+	ret0, _ := ret[0].(domain.APIResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendLimitOrder indicates an expected call of SendLimitOrder.
+func (mr *MockrepoInterfaceMockRecorder) SendLimitOrder(symbol, side, size, price, addr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendLimitOrder", reflect.TypeOf((*MockrepoInterface)(nil).SendLimitOrder), symbol, side, size, price, addr)
 }
 
 // SendOrder mocks base method.
@@ -79,6 +284,48 @@ func (mr *MockrepoInterfaceMockRecorder) SendOrder(symbol, side, size, addr inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendOrder", reflect.TypeOf((*MockrepoInterface)(nil).SendOrder), symbol, side, size, addr)
 }
 
+// SetUserDisabled mocks base method.
+func (m *MockrepoInterface) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, login, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockrepoInterfaceMockRecorder) SetUserDisabled(ctx, login, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockrepoInterface)(nil).SetUserDisabled), ctx, login, disabled)
+}
+
+// SetUserPassword mocks base method.
+func (m *MockrepoInterface) SetUserPassword(ctx context.Context, login, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPassword", ctx, login, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPassword indicates an expected call of SetUserPassword.
+func (mr *MockrepoInterfaceMockRecorder) SetUserPassword(ctx, login, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPassword", reflect.TypeOf((*MockrepoInterface)(nil).SetUserPassword), ctx, login, hash)
+}
+
+// SetUserRole mocks base method.
+func (m *MockrepoInterface) SetUserRole(ctx context.Context, login, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", ctx, login, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockrepoInterfaceMockRecorder) SetUserRole(ctx, login, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockrepoInterface)(nil).SetUserRole), ctx, login, role)
+}
+
 // SetWSConnection mocks base method.
 func (m *MockrepoInterface) SetWSConnection(addr, tick string) (chan domain.WsResponse, func(), error) {
 	m.ctrl.T.Helper()
@@ -95,6 +342,22 @@ func (mr *MockrepoInterfaceMockRecorder) SetWSConnection(addr, tick interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWSConnection", reflect.TypeOf((*MockrepoInterface)(nil).SetWSConnection), addr, tick)
 }
 
+// SetWSConnectionMulti mocks base method.
+func (m *MockrepoInterface) SetWSConnectionMulti(addr string, ticks []string) (chan domain.WsResponse, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWSConnectionMulti", addr, ticks)
+	ret0, _ := ret[0].(chan domain.WsResponse)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SetWSConnectionMulti indicates an expected call of SetWSConnectionMulti.
+func (mr *MockrepoInterfaceMockRecorder) SetWSConnectionMulti(addr, ticks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWSConnectionMulti", reflect.TypeOf((*MockrepoInterface)(nil).SetWSConnectionMulti), addr, ticks)
+}
+
 // WriteOrderToDb mocks base method.
 func (m *MockrepoInterface) WriteOrderToDb(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit, stoploss float32) error {
 	m.ctrl.T.Helper()
@@ -109,16 +372,32 @@ func (mr *MockrepoInterfaceMockRecorder) WriteOrderToDb(ctx, inst, size, side, p
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteOrderToDb", reflect.TypeOf((*MockrepoInterface)(nil).WriteOrderToDb), ctx, inst, size, side, price, ordtype, profit, stoploss)
 }
 
-// WriteToTelegramBot mocks base method.
-func (m *MockrepoInterface) WriteToTelegramBot(text string) {
+// WriteOrderWithEvent mocks base method.
+func (m *MockrepoInterface) WriteOrderWithEvent(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit, stoploss float32, ev domain.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteOrderWithEvent", ctx, inst, size, side, price, ordtype, profit, stoploss, ev)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteOrderWithEvent indicates an expected call of WriteOrderWithEvent.
+func (mr *MockrepoInterfaceMockRecorder) WriteOrderWithEvent(ctx, inst, size, side, price, ordtype, profit, stoploss, ev interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteOrderWithEvent", reflect.TypeOf((*MockrepoInterface)(nil).WriteOrderWithEvent), ctx, inst, size, side, price, ordtype, profit, stoploss, ev)
+}
+
+// WriteSignalToDb mocks base method.
+func (m *MockrepoInterface) WriteSignalToDb(ctx context.Context, sig domain.Signal, status, reason string) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "WriteToTelegramBot", text)
+	ret := m.ctrl.Call(m, "WriteSignalToDb", ctx, sig, status, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// WriteToTelegramBot indicates an expected call of WriteToTelegramBot.
-func (mr *MockrepoInterfaceMockRecorder) WriteToTelegramBot(text interface{}) *gomock.Call {
+// WriteSignalToDb indicates an expected call of WriteSignalToDb.
+func (mr *MockrepoInterfaceMockRecorder) WriteSignalToDb(ctx, sig, status, reason interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteToTelegramBot", reflect.TypeOf((*MockrepoInterface)(nil).WriteToTelegramBot), text)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSignalToDb", reflect.TypeOf((*MockrepoInterface)(nil).WriteSignalToDb), ctx, sig, status, reason)
 }
 
 // MockRobotInterface is a mock of RobotInterface interface.
@@ -144,6 +423,135 @@ func (m *MockRobotInterface) EXPECT() *MockRobotInterfaceMockRecorder {
 	return m.recorder
 }
 
+// Authenticate mocks base method.
+func (m *MockRobotInterface) Authenticate(login, passwd string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", login, passwd)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockRobotInterfaceMockRecorder) Authenticate(login, passwd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockRobotInterface)(nil).Authenticate), login, passwd)
+}
+
+// CheckStart mocks base method.
+func (m *MockRobotInterface) CheckStart(trading string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckStart", trading)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckStart indicates an expected call of CheckStart.
+func (mr *MockRobotInterfaceMockRecorder) CheckStart(trading interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckStart", reflect.TypeOf((*MockRobotInterface)(nil).CheckStart), trading)
+}
+
+// CreateUser mocks base method.
+func (m *MockRobotInterface) CreateUser(login, passwd, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", login, passwd, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockRobotInterfaceMockRecorder) CreateUser(login, passwd, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRobotInterface)(nil).CreateUser), login, passwd, role)
+}
+
+// DeleteSchedule mocks base method.
+func (m *MockRobotInterface) DeleteSchedule(id string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedule", id)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// DeleteSchedule indicates an expected call of DeleteSchedule.
+func (mr *MockRobotInterfaceMockRecorder) DeleteSchedule(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedule", reflect.TypeOf((*MockRobotInterface)(nil).DeleteSchedule), id)
+}
+
+// DeleteScript mocks base method.
+func (m *MockRobotInterface) DeleteScript(name string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScript", name)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// DeleteScript indicates an expected call of DeleteScript.
+func (mr *MockRobotInterfaceMockRecorder) DeleteScript(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScript", reflect.TypeOf((*MockRobotInterface)(nil).DeleteScript), name)
+}
+
+// GetGridStatus mocks base method.
+func (m *MockRobotInterface) GetGridStatus() domain.GridStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGridStatus")
+	ret0, _ := ret[0].(domain.GridStatus)
+	return ret0
+}
+
+// GetGridStatus indicates an expected call of GetGridStatus.
+func (mr *MockRobotInterfaceMockRecorder) GetGridStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGridStatus", reflect.TypeOf((*MockRobotInterface)(nil).GetGridStatus))
+}
+
+// GetInstrument mocks base method.
+func (m *MockRobotInterface) GetInstrument(ticker string) (domain.Instrument, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstrument", ticker)
+	ret0, _ := ret[0].(domain.Instrument)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetInstrument indicates an expected call of GetInstrument.
+func (mr *MockRobotInterfaceMockRecorder) GetInstrument(ticker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstrument", reflect.TypeOf((*MockRobotInterface)(nil).GetInstrument), ticker)
+}
+
+// GetOutboxStatus mocks base method.
+func (m *MockRobotInterface) GetOutboxStatus() (domain.OutboxStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutboxStatus")
+	ret0, _ := ret[0].(domain.OutboxStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOutboxStatus indicates an expected call of GetOutboxStatus.
+func (mr *MockRobotInterfaceMockRecorder) GetOutboxStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxStatus", reflect.TypeOf((*MockRobotInterface)(nil).GetOutboxStatus))
+}
+
+// GetPairsStatus mocks base method.
+func (m *MockRobotInterface) GetPairsStatus() domain.PairsStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPairsStatus")
+	ret0, _ := ret[0].(domain.PairsStatus)
+	return ret0
+}
+
+// GetPairsStatus indicates an expected call of GetPairsStatus.
+func (mr *MockRobotInterfaceMockRecorder) GetPairsStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPairsStatus", reflect.TypeOf((*MockRobotInterface)(nil).GetPairsStatus))
+}
+
 // GetParams mocks base method.
 func (m *MockRobotInterface) GetParams() domain.Options {
 	m.ctrl.T.Helper()
@@ -158,18 +566,304 @@ func (mr *MockRobotInterfaceMockRecorder) GetParams() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetParams", reflect.TypeOf((*MockRobotInterface)(nil).GetParams))
 }
 
-// GetUsersMap mocks base method.
-func (m *MockRobotInterface) GetUsersMap(arg0 string) map[string]string {
+// GetReport mocks base method.
+func (m *MockRobotInterface) GetReport(period string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReport", period)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReport indicates an expected call of GetReport.
+func (mr *MockRobotInterfaceMockRecorder) GetReport(period interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReport", reflect.TypeOf((*MockRobotInterface)(nil).GetReport), period)
+}
+
+// GetRiskStatus mocks base method.
+func (m *MockRobotInterface) GetRiskStatus() domain.RiskStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskStatus")
+	ret0, _ := ret[0].(domain.RiskStatus)
+	return ret0
+}
+
+// GetRiskStatus indicates an expected call of GetRiskStatus.
+func (mr *MockRobotInterfaceMockRecorder) GetRiskStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskStatus", reflect.TypeOf((*MockRobotInterface)(nil).GetRiskStatus))
+}
+
+// GetSchedules mocks base method.
+func (m *MockRobotInterface) GetSchedules() []domain.Schedule {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules")
+	ret0, _ := ret[0].([]domain.Schedule)
+	return ret0
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockRobotInterfaceMockRecorder) GetSchedules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockRobotInterface)(nil).GetSchedules))
+}
+
+// GetScripts mocks base method.
+func (m *MockRobotInterface) GetScripts() []domain.Script {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScripts")
+	ret0, _ := ret[0].([]domain.Script)
+	return ret0
+}
+
+// GetScripts indicates an expected call of GetScripts.
+func (mr *MockRobotInterfaceMockRecorder) GetScripts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScripts", reflect.TypeOf((*MockRobotInterface)(nil).GetScripts))
+}
+
+// GetStatus mocks base method.
+func (m *MockRobotInterface) GetStatus() domain.RobotStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus")
+	ret0, _ := ret[0].(domain.RobotStatus)
+	return ret0
+}
+
+// GetStatus indicates an expected call of GetStatus.
+func (mr *MockRobotInterfaceMockRecorder) GetStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockRobotInterface)(nil).GetStatus))
+}
+
+// GetTotalProfit mocks base method.
+func (m *MockRobotInterface) GetTotalProfit() (float32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalProfit")
+	ret0, _ := ret[0].(float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotalProfit indicates an expected call of GetTotalProfit.
+func (mr *MockRobotInterfaceMockRecorder) GetTotalProfit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalProfit", reflect.TypeOf((*MockRobotInterface)(nil).GetTotalProfit))
+}
+
+// GetUser mocks base method.
+func (m *MockRobotInterface) GetUser(login string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", login)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockRobotInterfaceMockRecorder) GetUser(login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRobotInterface)(nil).GetUser), login)
+}
+
+// HandleSignal mocks base method.
+func (m *MockRobotInterface) HandleSignal(sig domain.Signal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleSignal", sig)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleSignal indicates an expected call of HandleSignal.
+func (mr *MockRobotInterfaceMockRecorder) HandleSignal(sig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleSignal", reflect.TypeOf((*MockRobotInterface)(nil).HandleSignal), sig)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockRobotInterface) IsTokenRevoked(id string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", id)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockRobotInterfaceMockRecorder) IsTokenRevoked(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockRobotInterface)(nil).IsTokenRevoked), id)
+}
+
+// KillSwitch mocks base method.
+func (m *MockRobotInterface) KillSwitch() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "KillSwitch")
+}
+
+// KillSwitch indicates an expected call of KillSwitch.
+func (mr *MockRobotInterfaceMockRecorder) KillSwitch() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KillSwitch", reflect.TypeOf((*MockRobotInterface)(nil).KillSwitch))
+}
+
+// ListTrades mocks base method.
+func (m *MockRobotInterface) ListTrades(from, to time.Time) ([]domain.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrades", from, to)
+	ret0, _ := ret[0].([]domain.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrades indicates an expected call of ListTrades.
+func (mr *MockRobotInterfaceMockRecorder) ListTrades(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrades", reflect.TypeOf((*MockRobotInterface)(nil).ListTrades), from, to)
+}
+
+// ListUsers mocks base method.
+func (m *MockRobotInterface) ListUsers() ([]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers")
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockRobotInterfaceMockRecorder) ListUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockRobotInterface)(nil).ListUsers))
+}
+
+// LoadRevokedTokens mocks base method.
+func (m *MockRobotInterface) LoadRevokedTokens() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadRevokedTokens")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadRevokedTokens indicates an expected call of LoadRevokedTokens.
+func (mr *MockRobotInterfaceMockRecorder) LoadRevokedTokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadRevokedTokens", reflect.TypeOf((*MockRobotInterface)(nil).LoadRevokedTokens))
+}
+
+// Optimize mocks base method.
+func (m *MockRobotInterface) Optimize(req domain.OptimizeRequest) (domain.OptimizeReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Optimize", req)
+	ret0, _ := ret[0].(domain.OptimizeReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Optimize indicates an expected call of Optimize.
+func (mr *MockRobotInterfaceMockRecorder) Optimize(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Optimize", reflect.TypeOf((*MockRobotInterface)(nil).Optimize), req)
+}
+
+// ResetKillSwitch mocks base method.
+func (m *MockRobotInterface) ResetKillSwitch() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetKillSwitch")
+}
+
+// ResetKillSwitch indicates an expected call of ResetKillSwitch.
+func (mr *MockRobotInterfaceMockRecorder) ResetKillSwitch() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetKillSwitch", reflect.TypeOf((*MockRobotInterface)(nil).ResetKillSwitch))
+}
+
+// ResetPassword mocks base method.
+func (m *MockRobotInterface) ResetPassword(login, passwd string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", login, passwd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockRobotInterfaceMockRecorder) ResetPassword(login, passwd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockRobotInterface)(nil).ResetPassword), login, passwd)
+}
+
+// RevokeToken mocks base method.
+func (m *MockRobotInterface) RevokeToken(id string, expires time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsersMap", arg0)
-	ret0, _ := ret[0].(map[string]string)
+	ret := m.ctrl.Call(m, "RevokeToken", id, expires)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// GetUsersMap indicates an expected call of GetUsersMap.
-func (mr *MockRobotInterfaceMockRecorder) GetUsersMap(arg0 interface{}) *gomock.Call {
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockRobotInterfaceMockRecorder) RevokeToken(id, expires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockRobotInterface)(nil).RevokeToken), id, expires)
+}
+
+// RunReports mocks base method.
+func (m *MockRobotInterface) RunReports() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunReports")
+}
+
+// RunReports indicates an expected call of RunReports.
+func (mr *MockRobotInterfaceMockRecorder) RunReports() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersMap", reflect.TypeOf((*MockRobotInterface)(nil).GetUsersMap), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunReports", reflect.TypeOf((*MockRobotInterface)(nil).RunReports))
+}
+
+// RunSchedules mocks base method.
+func (m *MockRobotInterface) RunSchedules() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunSchedules")
+}
+
+// RunSchedules indicates an expected call of RunSchedules.
+func (mr *MockRobotInterfaceMockRecorder) RunSchedules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunSchedules", reflect.TypeOf((*MockRobotInterface)(nil).RunSchedules))
+}
+
+// SetDCA mocks base method.
+func (m *MockRobotInterface) SetDCA(dca domain.DCA) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDCA", dca)
+}
+
+// SetDCA indicates an expected call of SetDCA.
+func (mr *MockRobotInterfaceMockRecorder) SetDCA(dca interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDCA", reflect.TypeOf((*MockRobotInterface)(nil).SetDCA), dca)
+}
+
+// SetExits mocks base method.
+func (m *MockRobotInterface) SetExits(exits domain.Exits) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetExits", exits)
+}
+
+// SetExits indicates an expected call of SetExits.
+func (mr *MockRobotInterfaceMockRecorder) SetExits(exits interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExits", reflect.TypeOf((*MockRobotInterface)(nil).SetExits), exits)
+}
+
+// SetLoop mocks base method.
+func (m *MockRobotInterface) SetLoop(loop domain.Loop) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLoop", loop)
+}
+
+// SetLoop indicates an expected call of SetLoop.
+func (mr *MockRobotInterfaceMockRecorder) SetLoop(loop interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLoop", reflect.TypeOf((*MockRobotInterface)(nil).SetLoop), loop)
 }
 
 // SetParams mocks base method.
@@ -196,6 +890,70 @@ func (mr *MockRobotInterfaceMockRecorder) SetParamsWithoutStart(size, profit, ti
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetParamsWithoutStart", reflect.TypeOf((*MockRobotInterface)(nil).SetParamsWithoutStart), size, profit, ticker, side)
 }
 
+// SetReportsDir mocks base method.
+func (m *MockRobotInterface) SetReportsDir(dir string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetReportsDir", dir)
+}
+
+// SetReportsDir indicates an expected call of SetReportsDir.
+func (mr *MockRobotInterfaceMockRecorder) SetReportsDir(dir interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReportsDir", reflect.TypeOf((*MockRobotInterface)(nil).SetReportsDir), dir)
+}
+
+// SetRiskLimits mocks base method.
+func (m *MockRobotInterface) SetRiskLimits(limits domain.RiskLimits) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRiskLimits", limits)
+}
+
+// SetRiskLimits indicates an expected call of SetRiskLimits.
+func (mr *MockRobotInterfaceMockRecorder) SetRiskLimits(limits interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRiskLimits", reflect.TypeOf((*MockRobotInterface)(nil).SetRiskLimits), limits)
+}
+
+// SetSchedule mocks base method.
+func (m *MockRobotInterface) SetSchedule(sch domain.Schedule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedule", sch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockRobotInterfaceMockRecorder) SetSchedule(sch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockRobotInterface)(nil).SetSchedule), sch)
+}
+
+// SetScript mocks base method.
+func (m *MockRobotInterface) SetScript(sc domain.Script) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetScript", sc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetScript indicates an expected call of SetScript.
+func (mr *MockRobotInterfaceMockRecorder) SetScript(sc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetScript", reflect.TypeOf((*MockRobotInterface)(nil).SetScript), sc)
+}
+
+// SetSizing mocks base method.
+func (m *MockRobotInterface) SetSizing(sizing domain.Sizing) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSizing", sizing)
+}
+
+// SetSizing indicates an expected call of SetSizing.
+func (mr *MockRobotInterfaceMockRecorder) SetSizing(sizing interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSizing", reflect.TypeOf((*MockRobotInterface)(nil).SetSizing), sizing)
+}
+
 // SetStart mocks base method.
 func (m *MockRobotInterface) SetStart(start int) {
 	m.ctrl.T.Helper()
@@ -207,3 +965,156 @@ func (mr *MockRobotInterfaceMockRecorder) SetStart(start interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStart", reflect.TypeOf((*MockRobotInterface)(nil).SetStart), start)
 }
+
+// SetStrategy mocks base method.
+func (m *MockRobotInterface) SetStrategy(params domain.Strategy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetStrategy", params)
+}
+
+// SetStrategy indicates an expected call of SetStrategy.
+func (mr *MockRobotInterfaceMockRecorder) SetStrategy(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStrategy", reflect.TypeOf((*MockRobotInterface)(nil).SetStrategy), params)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockRobotInterface) SetUserDisabled(login string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", login, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockRobotInterfaceMockRecorder) SetUserDisabled(login, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockRobotInterface)(nil).SetUserDisabled), login, disabled)
+}
+
+// SetUserRole mocks base method.
+func (m *MockRobotInterface) SetUserRole(login, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", login, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockRobotInterfaceMockRecorder) SetUserRole(login, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockRobotInterface)(nil).SetUserRole), login, role)
+}
+
+// StartGrid mocks base method.
+func (m *MockRobotInterface) StartGrid(params domain.GridParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartGrid", params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartGrid indicates an expected call of StartGrid.
+func (mr *MockRobotInterfaceMockRecorder) StartGrid(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartGrid", reflect.TypeOf((*MockRobotInterface)(nil).StartGrid), params)
+}
+
+// StartPairs mocks base method.
+func (m *MockRobotInterface) StartPairs(params domain.PairsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartPairs", params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartPairs indicates an expected call of StartPairs.
+func (mr *MockRobotInterfaceMockRecorder) StartPairs(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartPairs", reflect.TypeOf((*MockRobotInterface)(nil).StartPairs), params)
+}
+
+// StopGrid mocks base method.
+func (m *MockRobotInterface) StopGrid() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopGrid")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopGrid indicates an expected call of StopGrid.
+func (mr *MockRobotInterfaceMockRecorder) StopGrid() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopGrid", reflect.TypeOf((*MockRobotInterface)(nil).StopGrid))
+}
+
+// StopPairs mocks base method.
+func (m *MockRobotInterface) StopPairs() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopPairs")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopPairs indicates an expected call of StopPairs.
+func (mr *MockRobotInterfaceMockRecorder) StopPairs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopPairs", reflect.TypeOf((*MockRobotInterface)(nil).StopPairs))
+}
+
+// TradingAllowed mocks base method.
+func (m *MockRobotInterface) TradingAllowed() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradingAllowed")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// TradingAllowed indicates an expected call of TradingAllowed.
+func (mr *MockRobotInterfaceMockRecorder) TradingAllowed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradingAllowed", reflect.TypeOf((*MockRobotInterface)(nil).TradingAllowed))
+}
+
+// WatchEvents mocks base method.
+func (m *MockRobotInterface) WatchEvents() (chan domain.Event, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchEvents")
+	ret0, _ := ret[0].(chan domain.Event)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// WatchEvents indicates an expected call of WatchEvents.
+func (mr *MockRobotInterfaceMockRecorder) WatchEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchEvents", reflect.TypeOf((*MockRobotInterface)(nil).WatchEvents))
+}
+
+// WatchInstruments mocks base method.
+func (m *MockRobotInterface) WatchInstruments(period time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WatchInstruments", period)
+}
+
+// WatchInstruments indicates an expected call of WatchInstruments.
+func (mr *MockRobotInterfaceMockRecorder) WatchInstruments(period interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchInstruments", reflect.TypeOf((*MockRobotInterface)(nil).WatchInstruments), period)
+}
+
+// WatchTicks mocks base method.
+func (m *MockRobotInterface) WatchTicks(ticker string) (chan domain.WsResponse, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchTicks", ticker)
+	ret0, _ := ret[0].(chan domain.WsResponse)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// WatchTicks indicates an expected call of WatchTicks.
+func (mr *MockRobotInterfaceMockRecorder) WatchTicks(ticker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchTicks", reflect.TypeOf((*MockRobotInterface)(nil).WatchTicks), ticker)
+}
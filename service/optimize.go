@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	chartsAddr = "https://demo-futures.kraken.com/api/charts/v1/trade"
+	// maxCombinations ограничивает число наборов параметров в одном запросе, в том числе случайных
+	maxCombinations = 10000
+	// defaultReportsDir - каталог для отчетов, если OPTIMIZE_REPORTS_DIR не задан
+	defaultReportsDir = "reports"
+)
+
+// optimizeParams - параметры стратегии, которые можно перебирать
+var optimizeParams = map[string]bool{"period": true, "std_dev": true, "profit": true}
+
+// Optimizer подбирает параметры стратегии, прогоняя бэктест по историческим свечам
+type Optimizer struct {
+	repo        repoInterface
+	log         logrus.FieldLogger
+	instruments *Instruments
+	strategy    func(domain.Strategy) strategy
+	reportsDir  string // каталог, в который сохраняются отчеты с заданным File
+}
+
+func NewOptimizer(repo repoInterface, logger logrus.FieldLogger, instruments *Instruments, strategy func(domain.Strategy) strategy) *Optimizer {
+	return &Optimizer{
+		repo:        repo,
+		log:         logger,
+		instruments: instruments,
+		strategy:    strategy,
+		reportsDir:  defaultReportsDir,
+	}
+}
+
+func (o *Optimizer) Run(req domain.OptimizeRequest) (domain.OptimizeReport, error) {
+	inst, ok := o.instruments.Get(req.Ticker)
+	if !ok {
+		return domain.OptimizeReport{}, errors.New("unknown instrument " + req.Ticker)
+	}
+	sets, err := paramSets(req)
+	if err != nil {
+		return domain.OptimizeReport{}, err
+	}
+	candles, err := o.repo.GetCandles(req.Ticker, req.Resolution, req.From, req.To, chartsAddr)
+	if err != nil {
+		return domain.OptimizeReport{}, err
+	}
+	if len(candles) == 0 {
+		return domain.OptimizeReport{}, errors.New("no candles for the period")
+	}
+
+	report := domain.OptimizeReport{
+		Ticker:       req.Ticker,
+		Strategy:     req.Options.Name,
+		Metric:       req.Metric,
+		Candles:      len(candles),
+		Combinations: len(sets),
+	}
+	if req.Folds > 0 {
+		report.Folds, err = o.walkForward(inst, candles, sets, req)
+		if err != nil {
+			return domain.OptimizeReport{}, err
+		}
+	}
+	report.Results = top(o.evaluate(inst, candles, 0, sets, req), req.Top)
+
+	if req.File != "" {
+		err = o.saveReport(req.File, report)
+		if err != nil {
+			return report, err
+		}
+	}
+	if req.SaveToDB {
+		err = o.repo.SaveOptimization(context.Background(), report)
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// saveReport записывает отчет в каталог отчетов. От имени файла берется только последний элемент пути,
+// чтобы клиент не мог записать файл в другое место
+func (o *Optimizer) saveReport(file string, report domain.OptimizeReport) error {
+	name := filepath.Base(file)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return fmt.Errorf("bad report file name %q", file)
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(o.reportsDir, 0o755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(o.reportsDir, name), b, 0o644)
+}
+
+// evaluate прогоняет бэктест для всех наборов параметров параллельно и сортирует результаты по метрике
+func (o *Optimizer) evaluate(inst domain.Instrument, candles []domain.Candle, start int, sets []map[string]float32, req domain.OptimizeRequest) []domain.OptimizeResult {
+	// Параллельных прогонов не больше, чем ядер, сколько бы ни попросил клиент
+	workers := req.Workers
+	if workers <= 0 || workers > runtime.NumCPU() {
+		workers = runtime.NumCPU()
+	}
+	results := make([]domain.OptimizeResult, len(sets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				params := applyParams(req.Options, sets[i])
+				results[i] = domain.OptimizeResult{
+					Params: sets[i],
+					Result: backtest(inst, candles, start, params, o.strategy(params.Strategy)),
+				}
+			}
+		}()
+	}
+	for i := range sets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return metricValue(results[i].Result, req.Metric) > metricValue(results[j].Result, req.Metric)
+	})
+	return results
+}
+
+// walkForward делит свечи на Folds последовательных окон. В каждом окне параметры подбираются на первой части
+// и проверяются на оставшейся, которую подбор не видел
+func (o *Optimizer) walkForward(inst domain.Instrument, candles []domain.Candle, sets []map[string]float32, req domain.OptimizeRequest) ([]domain.WalkForwardFold, error) {
+	size := len(candles) / req.Folds
+	train := int(float32(size) * req.TrainRatio)
+	if train < 1 || train >= size {
+		return nil, fmt.Errorf("not enough candles for %d folds", req.Folds)
+	}
+	folds := make([]domain.WalkForwardFold, 0, req.Folds)
+	for k := 0; k < req.Folds; k++ {
+		window := candles[k*size : (k+1)*size]
+		best := o.evaluate(inst, window[:train], 0, sets, req)[0]
+		// Обучающая часть остается в истории, чтобы стратегия могла дать сигнал с первой проверочной свечи
+		params := applyParams(req.Options, best.Params)
+		test := backtest(inst, window, train, params, o.strategy(params.Strategy))
+		folds = append(folds, domain.WalkForwardFold{
+			Fold:      k + 1,
+			TrainFrom: window[0].Time,
+			TestFrom:  window[train].Time,
+			TestTo:    window[len(window)-1].Time,
+			Best:      best,
+			Test:      test,
+		})
+	}
+	return folds, nil
+}
+
+// paramSets перечисляет наборы параметров: все сочетания для grid или Samples случайных сочетаний для random
+func paramSets(req domain.OptimizeRequest) ([]map[string]float32, error) {
+	names := make([]string, 0, len(req.Ranges))
+	for name := range req.Ranges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([][]float32, len(names))
+	total := 1
+	for i, name := range names {
+		if !optimizeParams[name] {
+			return nil, fmt.Errorf("parameter '%s' can't be optimized", name)
+		}
+		r := req.Ranges[name]
+		if r.Step < 0 || r.To < r.From || (r.Step > 0 && (r.To-r.From)/r.Step > maxCombinations) {
+			return nil, fmt.Errorf("invalid range for '%s'", name)
+		}
+		values[i] = append(values[i], r.From)
+		if r.Step > 0 {
+			for v := r.From + r.Step; v <= r.To+r.Step/1000; v += r.Step {
+				values[i] = append(values[i], v)
+			}
+		}
+		total *= len(values[i])
+		if total > maxCombinations && req.Search != "random" {
+			return nil, fmt.Errorf("too many combinations, the limit is %d", maxCombinations)
+		}
+	}
+
+	set := func(idx []int) map[string]float32 {
+		res := make(map[string]float32, len(names))
+		for i, name := range names {
+			res[name] = values[i][idx[i]]
+		}
+		return res
+	}
+	idx := make([]int, len(names))
+	if req.Search == "random" {
+		if req.Samples < 1 || req.Samples > maxCombinations {
+			return nil, fmt.Errorf("'samples' must be in range [1, %d] for random search", maxCombinations)
+		}
+		seed := req.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rnd := rand.New(rand.NewSource(seed))
+		sets := make([]map[string]float32, req.Samples)
+		for s := range sets {
+			for i := range idx {
+				idx[i] = rnd.Intn(len(values[i]))
+			}
+			sets[s] = set(idx)
+		}
+		return sets, nil
+	}
+
+	sets := make([]map[string]float32, 0, total)
+	for {
+		sets = append(sets, set(idx))
+		// Следующее сочетание, как в счетчике с разрядами разной длины
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			idx[i]++
+			if idx[i] < len(values[i]) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			return sets, nil
+		}
+	}
+}
+
+func applyParams(opt domain.Options, set map[string]float32) domain.Options {
+	for name, v := range set {
+		switch name {
+		case "period":
+			opt.Period = int(v)
+		case "std_dev":
+			opt.StdDev = v
+		case "profit":
+			opt.Profit = v
+		}
+	}
+	if opt.Size == 0 {
+		opt.Size = 1
+	}
+	return opt
+}
+
+func top(results []domain.OptimizeResult, n int) []domain.OptimizeResult {
+	if n > 0 && n < len(results) {
+		return results[:n]
+	}
+	return results
+}
+
+func (r *RobotService) Optimize(req domain.OptimizeRequest) (domain.OptimizeReport, error) {
+	return r.optimizer.Run(req)
+}
+
+// SetReportsDir задает каталог для отчетов оптимизации. Пустая строка оставляет каталог по умолчанию
+func (r *RobotService) SetReportsDir(dir string) {
+	if dir != "" {
+		r.optimizer.reportsDir = dir
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamSets(t *testing.T) {
+	req := domain.OptimizeRequest{Ranges: map[string]domain.ParamRange{
+		"period": {From: 10, To: 30, Step: 10},
+		"profit": {From: 0.5, To: 1, Step: 0.5},
+	}}
+	sets, err := paramSets(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]float32{
+		{"period": 10, "profit": 0.5}, {"period": 10, "profit": 1},
+		{"period": 20, "profit": 0.5}, {"period": 20, "profit": 1},
+		{"period": 30, "profit": 0.5}, {"period": 30, "profit": 1},
+	}, sets)
+
+	req.Search, req.Samples, req.Seed = "random", 4, 42
+	random, err := paramSets(req)
+	assert.NoError(t, err)
+	assert.Len(t, random, 4)
+	again, _ := paramSets(req)
+	assert.Equal(t, random, again)
+	for _, set := range random {
+		assert.Contains(t, sets, set)
+	}
+
+	sets, err = paramSets(domain.OptimizeRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]float32{{}}, sets)
+
+	_, err = paramSets(domain.OptimizeRequest{Ranges: map[string]domain.ParamRange{"size": {From: 1, To: 2, Step: 1}}})
+	assert.Error(t, err)
+	_, err = paramSets(domain.OptimizeRequest{Ranges: map[string]domain.ParamRange{"period": {From: 1, To: 100000, Step: 1}}})
+	assert.Error(t, err)
+	_, err = paramSets(domain.OptimizeRequest{Search: "random", Ranges: map[string]domain.ParamRange{"period": {From: 1, To: 2, Step: 1}}})
+	assert.Error(t, err)
+	_, err = paramSets(domain.OptimizeRequest{Search: "random", Samples: 2000000000, Ranges: map[string]domain.ParamRange{"period": {From: 1, To: 2, Step: 1}}})
+	assert.Error(t, err)
+}
+
+// trend - свечи, которые растут по 1% и каждые 4 свечи откатываются
+func trend(n int) []domain.Candle {
+	closes := make([]float32, n)
+	price := float32(100)
+	for i := range closes {
+		if i%4 == 3 {
+			price *= 0.98
+		} else {
+			price *= 1.01
+		}
+		closes[i] = price
+	}
+	return series(closes...)
+}
+
+func TestOptimizer(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	logger := log.New()
+	repo := mock_service.NewMockrepoInterface(c)
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	candles := trend(120)
+	repo.EXPECT().GetCandles("PI_XBTUSD", "1h", from, from.Add(120*time.Hour), chartsAddr).Return(candles, nil).Times(2)
+	repo.EXPECT().SaveOptimization(gomock.Any(), gomock.Any()).Return(nil)
+
+	robot := &RobotService{scripts: NewScripts(logger)}
+	optimizer := NewOptimizer(repo, logger, NewInstruments(repo, logger), robot.strategy)
+	optimizer.reportsDir = t.TempDir()
+	req := domain.OptimizeRequest{
+		Ticker:     "PI_XBTUSD",
+		Resolution: "1h",
+		From:       from,
+		To:         from.Add(120 * time.Hour),
+		Options:    domain.Options{Size: 100, Strategy: domain.Strategy{Name: "donchian"}},
+		Ranges: map[string]domain.ParamRange{
+			"period": {From: 2, To: 6, Step: 2},
+			"profit": {From: 1, To: 3, Step: 1},
+		},
+		Metric:  "profit",
+		Workers: 3,
+		Top:     3,
+		// Каталоги из имени файла отбрасываются, отчет пишется в каталог отчетов
+		File:     "../../etc/report.json",
+		SaveToDB: true,
+	}
+	report, err := optimizer.Run(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, report.Combinations)
+	assert.Equal(t, 120, report.Candles)
+	assert.Len(t, report.Results, 3)
+	for i := 1; i < len(report.Results); i++ {
+		assert.GreaterOrEqual(t, report.Results[i-1].Result.Profit, report.Results[i].Result.Profit)
+	}
+
+	// Результат лучшего набора совпадает с отдельным прогоном
+	best := applyParams(req.Options, report.Results[0].Params)
+	assert.Equal(t, backtest(defaultInstruments[0], candles, 0, best, newStrategy(best.Strategy)), report.Results[0].Result)
+
+	b, err := ioutil.ReadFile(filepath.Join(optimizer.reportsDir, "report.json"))
+	assert.NoError(t, err)
+	var saved domain.OptimizeReport
+	assert.NoError(t, json.Unmarshal(b, &saved))
+	assert.Equal(t, report.Results[0].Params, saved.Results[0].Params)
+
+	// Walk-forward: 3 окна по 40 свечей, 30 для подбора и 10 для проверки
+	req.Folds, req.TrainRatio, req.File, req.SaveToDB = 3, 0.75, "", false
+	report, err = optimizer.Run(req)
+	assert.NoError(t, err)
+	assert.Len(t, report.Folds, 3)
+	assert.Equal(t, candles[30].Time, report.Folds[0].TestFrom)
+	assert.Equal(t, candles[79].Time, report.Folds[1].TestTo)
+}
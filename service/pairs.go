@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// Pairs - стратегия торговли спредом. Отношение цен двух инструментов собирается в свечи,
+// по последним Period свечам считается z-score. При сильном отклонении продается подорожавшая
+// нога и покупается подешевевшая, при возврате к среднему обе позиции закрываются вместе
+type Pairs struct {
+	repo        repoInterface
+	log         logrus.FieldLogger
+	risk        *RiskManager
+	instruments *Instruments
+	params      domain.PairsParams
+	prices      map[string]float32
+	ratios      *candles
+	zscore      float32
+	legs        []domain.PairLeg
+	trades      int
+	profit      float32 // сумма прибыли закрытых ног в долларах
+	pairProfit  float32 // прибыль уже закрытых ног текущей пары
+	active      bool
+	closing     bool         // торговля остановлена, но не все ноги закрыты
+	retry       closeBackoff // пауза между повторами закрытия ног
+	stop        chan struct{}
+	mu          sync.Mutex
+}
+
+func NewPairs(repo repoInterface, logger logrus.FieldLogger, risk *RiskManager, instruments *Instruments) *Pairs {
+	return &Pairs{
+		repo:        repo,
+		log:         logger,
+		risk:        risk,
+		instruments: instruments,
+	}
+}
+
+func (p *Pairs) Start(params domain.PairsParams) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active {
+		return errors.New("pairs trading is already active")
+	}
+	if p.risk.Killed() {
+		return ErrKillSwitch
+	}
+	priceChan, cancel, err := p.repo.SetWSConnectionMulti(wsAddr, []string{params.TickerA, params.TickerB})
+	if err != nil {
+		return err
+	}
+
+	p.params = params
+	p.prices = make(map[string]float32, 2)
+	p.ratios = newCandles(time.Duration(params.Interval)*time.Second, params.Period)
+	p.zscore = 0
+	p.legs = nil
+	p.trades, p.profit, p.pairProfit = 0, 0, 0
+	p.closing = false
+	p.retry.reset()
+	p.active = true
+	p.stop = make(chan struct{})
+	go p.run(priceChan, cancel, p.stop)
+	p.repo.Notify(domain.Event{Type: domain.EventPairsStarted, Legs: []domain.PairLeg{
+		{Ticker: params.TickerA, Size: params.SizeA},
+		{Ticker: params.TickerB, Size: params.SizeB},
+	}, EntryZ: params.Entry, ExitZ: params.Exit})
+	return nil
+}
+
+// Stop прекращает торговлю и закрывает обе ноги, если позиция открыта. Если закрыть ноги не удалось,
+// торговля остается активной без новых входов, а закрытие повторяется на тиках с растущей паузой
+func (p *Pairs) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.active {
+		return errors.New("pairs trading is not active")
+	}
+	if len(p.legs) > 0 {
+		if err := p.closeLegs(); err != nil {
+			p.notifyUnclosed(err)
+			p.closing = true
+			p.retry.failed(time.Now())
+			return errors.New("can't close pair legs, close will be retried: " + err.Error())
+		}
+	}
+	p.finishStop()
+	return nil
+}
+
+// finishStop завершает торговлю, когда все ноги закрыты
+func (p *Pairs) finishStop() {
+	close(p.stop)
+	p.active = false
+	p.closing = false
+	p.repo.Notify(domain.Event{Type: domain.EventPairsStopped, Legs: p.tickers(), Number: p.trades, Profit: p.profit})
+}
+
+func (p *Pairs) Status() domain.PairsStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return domain.PairsStatus{
+		Active: p.active,
+		Params: p.params,
+		ZScore: p.zscore,
+		Legs:   append([]domain.PairLeg(nil), p.legs...),
+		Trades: p.trades,
+		Profit: p.profit,
+	}
+}
+
+func (p *Pairs) run(priceChan chan domain.WsResponse, cancel func(), stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			cancel()
+			return
+		case wsReturn, ok := <-priceChan:
+			if !ok {
+				return
+			}
+			p.onTick(time.Now(), wsReturn)
+		}
+	}
+}
+
+func (p *Pairs) onTick(now time.Time, wsReturn domain.WsResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.active {
+		return
+	}
+	p.prices[strings.ToUpper(wsReturn.ProductID)] = midPrice(wsReturn)
+	// Торговля остановлена или осталась только одна нога - закрываем ноги, не дожидаясь сигнала
+	if p.closing || len(p.legs) == 1 {
+		p.tryClose(now)
+		if p.closing && len(p.legs) == 0 {
+			p.finishStop()
+		}
+		return
+	}
+	a, b := p.prices[p.params.TickerA], p.prices[p.params.TickerB]
+	if a == 0 || b == 0 || !p.ratios.add(now, a/b) {
+		return
+	}
+	if len(p.ratios.closed) < p.params.Period {
+		return
+	}
+	p.zscore = zScore(p.ratios.closed)
+
+	if len(p.legs) > 0 {
+		z := float32(math.Abs(float64(p.zscore)))
+		if z < p.params.Exit || (p.params.StopZ > 0 && z > p.params.StopZ) {
+			p.tryClose(now)
+		}
+		return
+	}
+	switch {
+	case p.zscore > p.params.Entry:
+		p.open(now, "sell", "buy")
+	case p.zscore < -p.params.Entry:
+		p.open(now, "buy", "sell")
+	}
+}
+
+// tryClose закрывает ноги, если прошла пауза после прошлой неудачи. Об ошибке сообщается
+// только при первой неудаче подряд, чтобы повторы не засыпали уведомлениями
+func (p *Pairs) tryClose(now time.Time) {
+	if !p.retry.ready(now) {
+		return
+	}
+	err := p.closeLegs()
+	if err == nil {
+		p.retry.reset()
+		return
+	}
+	if p.retry.failed(now) {
+		p.notifyUnclosed(err)
+	}
+}
+
+// notifyUnclosed сообщает о каждой ноге, которую не удалось закрыть
+func (p *Pairs) notifyUnclosed(err error) {
+	for _, leg := range p.legs {
+		p.repo.Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: leg.Ticker, Side: reverseSide(leg.Side), Size: leg.Size, Reason: "can't close pair leg: " + err.Error()})
+	}
+}
+
+// open открывает ноги по очереди. Если вторая нога не открылась, первая закрывается,
+// чтобы не оставлять направленную позицию
+func (p *Pairs) open(now time.Time, sideA, sideB string) {
+	legs := []domain.PairLeg{
+		{Ticker: p.params.TickerA, Side: sideA, Size: p.params.SizeA},
+		{Ticker: p.params.TickerB, Side: sideB, Size: p.params.SizeB},
+	}
+	for _, leg := range legs {
+		inst, _ := p.instruments.Get(leg.Ticker)
+		err := p.risk.CheckOpen(leg.Ticker, leg.Size, notional(inst, leg.Size, p.prices[leg.Ticker]))
+		if err != nil {
+			p.log.Infoln("Pair blocked by risk manager: ", err)
+			p.repo.Notify(domain.Event{Type: domain.EventRiskLimitHit, Ticker: leg.Ticker, Side: leg.Side, Size: leg.Size, Reason: err.Error()})
+			return
+		}
+	}
+
+	for _, leg := range legs {
+		price, err := p.send(leg.Ticker, leg.Side, leg.Size)
+		if err != nil {
+			p.log.Errorln("Can't open pair leg: ", err)
+			p.repo.Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: leg.Ticker, Side: leg.Side, Size: leg.Size, Reason: "can't open pair leg: " + err.Error()})
+			if len(p.legs) > 0 {
+				p.tryClose(now)
+			}
+			return
+		}
+		leg.Price = price
+		inst, _ := p.instruments.Get(leg.Ticker)
+		p.risk.OnOpen(leg.Ticker, leg.Side, leg.Size, notional(inst, leg.Size, price))
+		p.legs = append(p.legs, leg)
+		writeOrder(p.repo, p.log, leg.Ticker, leg.Size, leg.Side, price, "open", 0, 0,
+			domain.Event{Type: domain.EventOrderOpened, Ticker: leg.Ticker, Side: leg.Side, Size: leg.Size, Price: price})
+	}
+	p.repo.Notify(domain.Event{Type: domain.EventPairOpened, Legs: append([]domain.PairLeg(nil), p.legs...), ZScore: p.zscore})
+}
+
+// closeLegs закрывает все открытые ноги. Ноги, которые не удалось закрыть, остаются в p.legs.
+// О каждой закрытой ноге отправляется order_closed с ее прибылью: pnl возвращает доллары
+// для любого инструмента, поэтому прибыль пары - сумма прибыли ног
+func (p *Pairs) closeLegs() error {
+	var err error
+	var left []domain.PairLeg
+	for _, leg := range p.legs {
+		closePrice, sendErr := p.send(leg.Ticker, reverseSide(leg.Side), leg.Size)
+		if sendErr != nil {
+			p.log.Errorln("Can't close pair leg: ", sendErr)
+			left = append(left, leg)
+			err = sendErr
+			continue
+		}
+		inst, _ := p.instruments.Get(leg.Ticker)
+		legProfit := pnl(inst, leg.Side, leg.Size, leg.Price, closePrice)
+		p.risk.OnClose(leg.Ticker, leg.Side, leg.Size, notional(inst, leg.Size, leg.Price), legProfit)
+		p.profit += legProfit
+		p.pairProfit += legProfit
+		writeOrder(p.repo, p.log, leg.Ticker, leg.Size, reverseSide(leg.Side), closePrice, "close", legProfit, 0,
+			domain.Event{Type: domain.EventOrderClosed, Ticker: leg.Ticker, Side: reverseSide(leg.Side), Size: leg.Size,
+				Price: closePrice, OpenPrice: leg.Price, Profit: legProfit})
+	}
+	p.legs = left
+	if len(left) == 0 {
+		p.trades++
+		p.repo.Notify(domain.Event{Type: domain.EventPairClosed, Legs: p.tickers(), ZScore: p.zscore, Profit: p.pairProfit})
+		p.pairProfit = 0
+	}
+	return err
+}
+
+// tickers - инструменты пары для уведомлений
+func (p *Pairs) tickers() []domain.PairLeg {
+	return []domain.PairLeg{{Ticker: p.params.TickerA}, {Ticker: p.params.TickerB}}
+}
+
+// send отправляет рыночный ордер и возвращает цену исполнения
+func (p *Pairs) send(ticker, side string, size int) (float32, error) {
+	resp, err := p.repo.SendOrder(strings.ToLower(ticker), side, size, sendOrderAddr)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Result != "success" || resp.SendStatus.Status != "placed" || len(resp.SendStatus.OrderEvents) == 0 {
+		return 0, errors.New(strings.TrimSpace(GetError(resp)))
+	}
+	return resp.SendStatus.OrderEvents[0].Price, nil
+}
+
+// zScore - отклонение цены закрытия последней свечи от среднего в стандартных отклонениях
+func zScore(candles []domain.Candle) float32 {
+	var sum float64
+	for _, c := range candles {
+		sum += float64(c.Close)
+	}
+	mean := sum / float64(len(candles))
+	var variance float64
+	for _, c := range candles {
+		variance += (float64(c.Close) - mean) * (float64(c.Close) - mean)
+	}
+	std := math.Sqrt(variance / float64(len(candles)))
+	if std == 0 {
+		return 0
+	}
+	return float32((float64(candles[len(candles)-1].Close) - mean) / std)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func filledOrder(price float32) domain.APIResp {
+	return domain.APIResp{Result: "success", SendStatus: domain.SendStatus{Status: "placed", OrderEvents: []domain.OrderEvents{{Price: price}}}}
+}
+
+// feedRatios отправляет по паре тиков в секунду: цена B всегда 100, цена A - 100 * ratio
+func feedRatios(p *Pairs, start time.Time, ratios ...float32) {
+	for i, ratio := range ratios {
+		now := start.Add(time.Duration(i) * time.Second)
+		p.onTick(now, domain.WsResponse{ProductID: "PI_ETHUSD", Bid: 100 * ratio, Ask: 100 * ratio})
+		p.onTick(now, domain.WsResponse{ProductID: "PI_XRPUSD", Bid: 100, Ask: 100})
+	}
+}
+
+func startPairs(t *testing.T, repo *mock_service.MockrepoInterface) *Pairs {
+	logger := log.New()
+	repo.EXPECT().SetWSConnectionMulti(wsAddr, []string{"PI_ETHUSD", "PI_XRPUSD"}).Return(make(chan domain.WsResponse), func() {}, nil)
+	repo.EXPECT().Notify(gomock.Any()).AnyTimes()
+
+	pairs := NewPairs(repo, logger, NewRiskManager(), NewInstruments(repo, logger))
+	err := pairs.Start(domain.PairsParams{TickerA: "PI_ETHUSD", TickerB: "PI_XRPUSD", SizeA: 1, SizeB: 2, Interval: 1, Period: 3, Entry: 1.3, Exit: 0.7})
+	assert.NoError(t, err)
+	return pairs
+}
+
+func TestPairs(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	pairs := startPairs(t, repo)
+	start := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	// Первое окно 1.0, 1.01, 0.99 дает z-score около -1.22 - меньше порога входа
+	feedRatios(pairs, start, 1, 1.01, 0.99, 1.2)
+	assert.Len(t, pairs.Status().Legs, 0)
+	assert.InDelta(t, -1.22, pairs.Status().ZScore, 0.01)
+
+	// Отношение 1.2 дает z-score около 1.41: A продается, B покупается
+	gomock.InOrder(
+		repo.EXPECT().SendOrder("pi_ethusd", "sell", 1, sendOrderAddr).Return(filledOrder(120), nil),
+		repo.EXPECT().SendOrder("pi_xrpusd", "buy", 2, sendOrderAddr).Return(filledOrder(100), nil),
+	)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "open", float32(0), float32(0), gomock.Any()).Return(nil).Times(2)
+	feedRatios(pairs, start.Add(4*time.Second), 1)
+	status := pairs.Status()
+	assert.Equal(t, []domain.PairLeg{{Ticker: "PI_ETHUSD", Side: "sell", Size: 1, Price: 120}, {Ticker: "PI_XRPUSD", Side: "buy", Size: 2, Price: 100}}, status.Legs)
+
+	// Отношение вернулось к 1.0, z-score около -0.65: обе ноги закрываются
+	repo.EXPECT().SendOrder("pi_ethusd", "buy", 1, sendOrderAddr).Return(filledOrder(100), nil)
+	repo.EXPECT().SendOrder("pi_xrpusd", "sell", 2, sendOrderAddr).Return(filledOrder(100), nil)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "close", gomock.Any(), float32(0), gomock.Any()).Return(nil).Times(2)
+	feedRatios(pairs, start.Add(5*time.Second), 1)
+	status = pairs.Status()
+	assert.Len(t, status.Legs, 0)
+	assert.Equal(t, 1, status.Trades)
+	assert.Equal(t, pnl(defaultInstruments[1], "sell", 1, 120, 100), status.Profit)
+
+	assert.NoError(t, pairs.Stop())
+	assert.Error(t, pairs.Stop())
+}
+
+func TestPairsLegFailure(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	pairs := startPairs(t, repo)
+	start := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	// Вторая нога не открылась - первая сразу закрывается
+	gomock.InOrder(
+		repo.EXPECT().SendOrder("pi_ethusd", "sell", 1, sendOrderAddr).Return(filledOrder(120), nil),
+		repo.EXPECT().SendOrder("pi_xrpusd", "buy", 2, sendOrderAddr).Return(domain.APIResp{}, errors.New("timeout")),
+		repo.EXPECT().SendOrder("pi_ethusd", "buy", 1, sendOrderAddr).Return(domain.APIResp{Result: "error"}, nil),
+		// Закрыть первую ногу не удалось, повтор через секунду, а не на следующем тике
+		repo.EXPECT().SendOrder("pi_ethusd", "buy", 1, sendOrderAddr).Return(filledOrder(119), nil),
+	)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), "PI_ETHUSD", 1, "sell", float32(120), "open", float32(0), float32(0), gomock.Any()).Return(nil)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), "PI_ETHUSD", 1, "buy", float32(119), "close", gomock.Any(), float32(0), gomock.Any()).Return(nil)
+
+	feedRatios(pairs, start, 1, 1.01, 0.99, 1.2, 1.2, 1.2)
+	assert.Len(t, pairs.Status().Legs, 0)
+	assert.Equal(t, 0, pairs.risk.Status().Positions["PI_ETHUSD"])
+	assert.NoError(t, pairs.Stop())
+}
+
+func TestPairsStopRetry(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	pairs := startPairs(t, repo)
+	start := time.Now()
+
+	repo.EXPECT().SendOrder("pi_ethusd", "sell", 1, sendOrderAddr).Return(filledOrder(120), nil)
+	repo.EXPECT().SendOrder("pi_xrpusd", "buy", 2, sendOrderAddr).Return(filledOrder(100), nil)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "open", float32(0), float32(0), gomock.Any()).Return(nil).Times(2)
+	feedRatios(pairs, start.Add(-5*time.Second), 1, 1.01, 0.99, 1.2, 1)
+	assert.Len(t, pairs.Status().Legs, 2)
+
+	// Нога B не закрылась: она остается в статусе, торговля не останавливается
+	repo.EXPECT().SendOrder("pi_ethusd", "buy", 1, sendOrderAddr).Return(filledOrder(110), nil)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), "PI_ETHUSD", 1, "buy", float32(110), "close", gomock.Any(), float32(0), gomock.Any()).Return(nil)
+	repo.EXPECT().SendOrder("pi_xrpusd", "sell", 2, sendOrderAddr).Return(domain.APIResp{}, errors.New("timeout"))
+	assert.Error(t, pairs.Stop())
+	status := pairs.Status()
+	assert.True(t, status.Active)
+	assert.Equal(t, []domain.PairLeg{{Ticker: "PI_XRPUSD", Side: "buy", Size: 2, Price: 100}}, status.Legs)
+	assert.Equal(t, map[string]int{"PI_XRPUSD": 2}, pairs.risk.Status().Positions)
+
+	// До конца паузы ордер не отправляется повторно, после нее нога закрывается и торговля останавливается
+	pairs.onTick(time.Now(), domain.WsResponse{ProductID: "PI_XRPUSD", Bid: 100, Ask: 100})
+	repo.EXPECT().SendOrder("pi_xrpusd", "sell", 2, sendOrderAddr).Return(filledOrder(101), nil)
+	repo.EXPECT().WriteOrderWithEvent(gomock.Any(), "PI_XRPUSD", 2, "sell", float32(101), "close", gomock.Any(), float32(0), gomock.Any()).Return(nil)
+	pairs.onTick(time.Now().Add(2*time.Second), domain.WsResponse{ProductID: "PI_XRPUSD", Bid: 101, Ask: 101})
+	status = pairs.Status()
+	assert.False(t, status.Active)
+	assert.Empty(t, status.Legs)
+	assert.Equal(t, 1, status.Trades)
+	assert.InDelta(t, pnl(defaultInstruments[1], "sell", 1, 120, 110)+pnl(defaultInstruments[3], "buy", 2, 100, 101), status.Profit, 0.001)
+	assert.Error(t, pairs.Stop())
+}
+
+func TestStartExclusive(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	repo.EXPECT().Notify(gomock.Any()).AnyTimes()
+	robot := signalRobot(repo, domain.Options{Start: 1, Ticker: "PI_XBTUSD"})
+	params := domain.PairsParams{TickerA: "PI_ETHUSD", TickerB: "PI_XRPUSD", SizeA: 1, SizeB: 2, Interval: 1, Period: 3, Entry: 1.3, Exit: 0.7}
+	grid := domain.GridParams{Ticker: "PI_XBTUSD", Lower: 49000, Upper: 51000, Levels: 5, Size: 1}
+
+	assert.Equal(t, domain.ErrRobotActive, robot.StartPairs(params))
+	assert.Equal(t, domain.ErrRobotActive, robot.StartGrid(grid))
+
+	// Пока работают пары, не запускаются ни робот, ни сетка
+	robot.SetStart(0)
+	repo.EXPECT().SetWSConnectionMulti(wsAddr, []string{"PI_ETHUSD", "PI_XRPUSD"}).Return(make(chan domain.WsResponse), func() {}, nil)
+	assert.NoError(t, robot.StartPairs(params))
+	assert.Equal(t, domain.ErrPairsActive, robot.CheckStart(domain.TradingRobot))
+	assert.Equal(t, domain.ErrPairsActive, robot.StartGrid(grid))
+	assert.NoError(t, robot.StopPairs())
+
+	assert.NoError(t, robot.SetSchedule(domain.Schedule{ID: "night", Blackouts: []domain.Blackout{{From: "00:00", To: "24:00"}}}))
+	assert.Equal(t, domain.ErrTradingNotAllowed, robot.StartPairs(params))
+	assert.Equal(t, domain.ErrTradingNotAllowed, robot.CheckStart(domain.TradingRobot))
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+var errUnknownPeriod = errors.New("unknown period, use 'day' or 'week'")
+
+// periodStart - начало дня или недели (с понедельника), в которые попадает t. Время считается в UTC
+func periodStart(period string, t time.Time) (time.Time, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case "day":
+		return day, nil
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	}
+	return time.Time{}, errUnknownPeriod
+}
+
+// GetReport возвращает отчет за текущий день или неделю
+func (r *RobotService) GetReport(period string) (string, error) {
+	now := time.Now().UTC()
+	from, err := periodStart(period, now)
+	if err != nil {
+		return "", err
+	}
+	rep, err := r.buildReport(period, from, now)
+	if err != nil {
+		return "", err
+	}
+	return formatReport(rep), nil
+}
+
+func (r *RobotService) buildReport(period string, from, to time.Time) (domain.Report, error) {
+	trades, err := r.repo.GetTrades(context.Background(), from, to)
+	if err != nil {
+		return domain.Report{}, err
+	}
+	rep := makeReport(trades)
+	rep.Period, rep.From, rep.To = period, from, to
+	risk := r.risk.Status()
+	rep.Positions, rep.Exposure = risk.Positions, risk.Exposure
+	return rep, nil
+}
+
+// makeReport считает итоги по сделкам
+func makeReport(trades []domain.Trade) domain.Report {
+	var rep domain.Report
+	byTicker := make(map[string]*domain.InstrumentReport)
+	for _, t := range trades {
+		rep.Trades++
+		rep.PnL += t.Profit
+		if t.Profit > 0 {
+			rep.Wins++
+		}
+		if t.Profit > rep.LargestWin {
+			rep.LargestWin = t.Profit
+		}
+		if t.Profit < rep.LargestLoss {
+			rep.LargestLoss = t.Profit
+		}
+		ir, ok := byTicker[t.Ticker]
+		if !ok {
+			ir = &domain.InstrumentReport{Ticker: t.Ticker}
+			byTicker[t.Ticker] = ir
+		}
+		ir.Trades++
+		ir.PnL += t.Profit
+	}
+	if rep.Trades > 0 {
+		rep.WinRate = float32(rep.Wins) / float32(rep.Trades) * 100
+	}
+	for _, ir := range byTicker {
+		rep.Instruments = append(rep.Instruments, *ir)
+	}
+	sort.Slice(rep.Instruments, func(i, j int) bool { return rep.Instruments[i].Ticker < rep.Instruments[j].Ticker })
+	return rep
+}
+
+func formatReport(rep domain.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Report for the %s %s - %s UTC\n", rep.Period, rep.From.Format("2006-01-02 15:04"), rep.To.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "Trades - %d, wins - %d, win rate is %.1f%%\n", rep.Trades, rep.Wins, rep.WinRate)
+	fmt.Fprintf(&b, "Net profit is %.2f, largest win/loss is %.2f/%.2f\n", rep.PnL, rep.LargestWin, rep.LargestLoss)
+	for _, ir := range rep.Instruments {
+		fmt.Fprintf(&b, "  %s: trades - %d, profit is %.2f\n", ir.Ticker, ir.Trades, ir.PnL)
+	}
+	tickers := make([]string, 0, len(rep.Positions))
+	for ticker, size := range rep.Positions {
+		if size != 0 {
+			tickers = append(tickers, ticker)
+		}
+	}
+	if len(tickers) == 0 {
+		b.WriteString("No open positions\n")
+		return b.String()
+	}
+	sort.Strings(tickers)
+	fmt.Fprintf(&b, "Exposure is %.2f\n", rep.Exposure)
+	for _, ticker := range tickers {
+		fmt.Fprintf(&b, "  %s: position - %d\n", ticker, rep.Positions[ticker])
+	}
+	return b.String()
+}
+
+// RunReports отправляет отчет за прошедший день каждую полночь по UTC, по понедельникам - и отчет за неделю
+func (r *RobotService) RunReports() {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		time.Sleep(next.Sub(now))
+		r.sendReports(next)
+	}
+}
+
+func (r *RobotService) sendReports(now time.Time) {
+	periods := []string{"day"}
+	if now.Weekday() == time.Monday {
+		periods = append(periods, "week")
+	}
+	for _, period := range periods {
+		// Отчет за период, который закончился в now
+		from, _ := periodStart(period, now.Add(-time.Nanosecond))
+		rep, err := r.buildReport(period, from, now)
+		if err != nil {
+			r.log.Errorln("Can't build report: ", err)
+			continue
+		}
+		r.repo.Notify(domain.Event{Type: domain.EventReport, Text: formatReport(rep)})
+	}
+}
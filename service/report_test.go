@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// Среда
+	now := time.Date(2021, 12, 1, 15, 30, 0, 0, time.UTC)
+	day, err := periodStart("day", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC), day)
+	week, err := periodStart("week", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 11, 29, 0, 0, 0, 0, time.UTC), week)
+	// Воскресенье относится к неделе, которая началась в понедельник
+	week, _ = periodStart("week", time.Date(2021, 12, 5, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2021, 11, 29, 0, 0, 0, 0, time.UTC), week)
+	_, err = periodStart("month", now)
+	assert.Error(t, err)
+}
+
+func TestMakeReport(t *testing.T) {
+	rep := makeReport([]domain.Trade{
+		{Ticker: "PI_XBTUSD", Profit: 10},
+		{Ticker: "PI_ETHUSD", Profit: -4},
+		{Ticker: "PI_XBTUSD", Profit: -1},
+		{Ticker: "PI_XBTUSD", Profit: 5},
+	})
+	assert.Equal(t, 4, rep.Trades)
+	assert.Equal(t, 2, rep.Wins)
+	assert.Equal(t, float32(50), rep.WinRate)
+	assert.Equal(t, float32(10), rep.PnL)
+	assert.Equal(t, float32(10), rep.LargestWin)
+	assert.Equal(t, float32(-4), rep.LargestLoss)
+	assert.Equal(t, []domain.InstrumentReport{{Ticker: "PI_ETHUSD", Trades: 1, PnL: -4}, {Ticker: "PI_XBTUSD", Trades: 3, PnL: 14}}, rep.Instruments)
+
+	assert.Equal(t, domain.Report{}, makeReport(nil))
+}
+
+func TestGetReport(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	robot := NewRobotService(repo, log.New())
+
+	// Сделки запрашиваются с начала текущего дня по UTC, сетка и основной цикл считаются вместе
+	repo.EXPECT().GetTrades(context.Background(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, from, to time.Time) ([]domain.Trade, error) {
+			assert.Equal(t, time.UTC, from.Location())
+			assert.Equal(t, time.UTC, to.Location())
+			day, _ := periodStart("day", to)
+			assert.Equal(t, day, from)
+			return []domain.Trade{
+				{Ticker: "PI_XBTUSD", Side: "sell", Profit: 0.1},
+				{Ticker: "PI_ETHUSD", Side: "sell", Profit: -0.05},
+				{Ticker: "PI_XBTUSD", Side: "buy", Profit: 0.2},
+			}, nil
+		})
+
+	text, err := robot.GetReport("day")
+	assert.NoError(t, err)
+	lines := strings.Split(text, "\n")
+	assert.Equal(t, []string{
+		"Trades - 3, wins - 2, win rate is 66.7%",
+		"Net profit is 0.25, largest win/loss is 0.20/-0.05",
+		"  PI_ETHUSD: trades - 1, profit is -0.05",
+		"  PI_XBTUSD: trades - 2, profit is 0.30",
+		"No open positions",
+	}, lines[1:6])
+
+	_, err = robot.GetReport("month")
+	assert.Error(t, err)
+}
+
+func TestSendReports(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	robot := NewRobotService(repo, log.New()).(*RobotService)
+	robot.risk.OnOpen("PI_XBTUSD", "buy", 2, 2)
+
+	// Понедельник: отчет за воскресенье и за прошедшую неделю
+	now := time.Date(2021, 12, 6, 0, 0, 0, 0, time.UTC)
+	trades := []domain.Trade{{Ticker: "PI_XBTUSD", Profit: 3}}
+	repo.EXPECT().GetTrades(context.Background(), time.Date(2021, 12, 5, 0, 0, 0, 0, time.UTC), now).Return(trades, nil)
+	repo.EXPECT().GetTrades(context.Background(), time.Date(2021, 11, 29, 0, 0, 0, 0, time.UTC), now).Return(trades, nil)
+	var texts []string
+	repo.EXPECT().Notify(gomock.Any()).Do(func(ev domain.Event) {
+		assert.Equal(t, domain.EventReport, ev.Type)
+		texts = append(texts, ev.Text)
+	}).Times(2)
+
+	robot.sendReports(now)
+	assert.Equal(t, "Report for the day 2021-12-05 00:00 - 2021-12-06 00:00 UTC\n"+
+		"Trades - 1, wins - 1, win rate is 100.0%\n"+
+		"Net profit is 3.00, largest win/loss is 3.00/0.00\n"+
+		"  PI_XBTUSD: trades - 1, profit is 3.00\n"+
+		"Exposure is 2.00\n"+
+		"  PI_XBTUSD: position - 2\n", texts[0])
+	assert.True(t, strings.HasPrefix(texts[1], "Report for the week 2021-11-29 00:00"))
+}
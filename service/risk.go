@@ -0,0 +1,299 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+var ErrKillSwitch = domain.ErrKillSwitch
+
+const (
+	minCloseRetry = time.Second
+	maxCloseRetry = time.Minute
+)
+
+type closedTrade struct {
+	ts     time.Time
+	profit float32
+}
+
+// RiskManager проверяет каждую новую сделку перед отправкой ордера на биржу.
+// Ордера на закрытие позиций не ограничиваются
+type RiskManager struct {
+	limits            domain.RiskLimits
+	killed            bool
+	positions         map[string]int
+	notional          map[string]float32
+	opened            []time.Time
+	closed            []closedTrade
+	consecutiveLosses int
+	cooldownUntil     time.Time
+	now               func() time.Time
+	mu                sync.Mutex
+}
+
+func NewRiskManager() *RiskManager {
+	return &RiskManager{
+		positions: make(map[string]int),
+		notional:  make(map[string]float32),
+		now:       time.Now,
+	}
+}
+
+func (m *RiskManager) SetLimits(limits domain.RiskLimits) {
+	m.mu.Lock()
+	m.limits = limits
+	m.mu.Unlock()
+}
+
+func (m *RiskManager) Kill() {
+	m.mu.Lock()
+	m.killed = true
+	m.mu.Unlock()
+}
+
+func (m *RiskManager) Reset() {
+	m.mu.Lock()
+	m.killed = false
+	m.mu.Unlock()
+}
+
+func (m *RiskManager) Killed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.killed
+}
+
+// CheckOpen возвращает ошибку, если открытие позиции size на сумму notional нарушает ограничения.
+// Убытки за день и неделю считаются в долларах, как и ограничения
+func (m *RiskManager) CheckOpen(ticker string, size int, notional float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if m.killed {
+		return ErrKillSwitch
+	}
+	if now.Before(m.cooldownUntil) {
+		return fmt.Errorf("cooldown after %d losses in a row until %s", m.limits.MaxConsecutiveLosses, m.cooldownUntil.Format(time.RFC3339))
+	}
+	if m.limits.MaxPosition > 0 && abs(m.positions[ticker])+size > m.limits.MaxPosition {
+		return fmt.Errorf("position limit for %s is %d contracts", ticker, m.limits.MaxPosition)
+	}
+	if m.limits.MaxExposure > 0 && m.exposure()+notional > m.limits.MaxExposure {
+		return fmt.Errorf("total exposure limit is %.1f", m.limits.MaxExposure)
+	}
+	if m.limits.MaxDailyLoss > 0 && -m.profitSince(startOfDay(now)) >= m.limits.MaxDailyLoss {
+		return fmt.Errorf("daily loss limit %.1f is reached", m.limits.MaxDailyLoss)
+	}
+	if m.limits.MaxWeeklyLoss > 0 && -m.profitSince(startOfWeek(now)) >= m.limits.MaxWeeklyLoss {
+		return fmt.Errorf("weekly loss limit %.1f is reached", m.limits.MaxWeeklyLoss)
+	}
+	if m.limits.MaxTradesPerHour > 0 && m.tradesSince(now.Add(-time.Hour)) >= m.limits.MaxTradesPerHour {
+		return fmt.Errorf("limit of %d trades per hour is reached", m.limits.MaxTradesPerHour)
+	}
+	return nil
+}
+
+func (m *RiskManager) OnOpen(ticker, side string, size int, notional float32) {
+	m.mu.Lock()
+	if side == "sell" {
+		size *= -1
+	}
+	m.positions[ticker] += size
+	m.notional[ticker] += notional
+	m.opened = append(m.opened, m.now())
+	m.prune(m.now())
+	m.mu.Unlock()
+}
+
+// OnClose учитывает закрытие позиции side размером size на сумму notional и ее результат в долларах.
+// Вызывается только после того, как ордер на закрытие исполнен: до этого позиция остается в лимитах.
+// По одному инструменту могут торговать робот, сетка и пары, поэтому вычитается только закрытая позиция
+func (m *RiskManager) OnClose(ticker, side string, size int, notional, profit float32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if side == "sell" {
+		size *= -1
+	}
+	pos := m.positions[ticker] - size
+	if pos == 0 {
+		delete(m.positions, ticker)
+		delete(m.notional, ticker)
+	} else {
+		m.positions[ticker] = pos
+		m.notional[ticker] -= notional
+		if m.notional[ticker] < 0 {
+			m.notional[ticker] = 0
+		}
+	}
+	m.onResult(profit)
+}
+
+// OnReduce учитывает частичное закрытие позиции: size контрактов на сумму notional
+func (m *RiskManager) OnReduce(ticker string, size int, notional float32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pos := m.positions[ticker]
+	switch {
+	case abs(pos) <= size:
+		delete(m.positions, ticker)
+		delete(m.notional, ticker)
+		return
+	case pos > 0:
+		m.positions[ticker] = pos - size
+	default:
+		m.positions[ticker] = pos + size
+	}
+	m.notional[ticker] -= notional
+	if m.notional[ticker] < 0 {
+		m.notional[ticker] = 0
+	}
+}
+
+// OnResult учитывает результат сделки в долларах, позиция которой учтена через OnReduce
+func (m *RiskManager) OnResult(profit float32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onResult(profit)
+}
+
+// onResult вызывается под mu
+func (m *RiskManager) onResult(profit float32) {
+	now := m.now()
+	m.closed = append(m.closed, closedTrade{ts: now, profit: profit})
+	m.prune(now)
+
+	if profit >= 0 {
+		m.consecutiveLosses = 0
+		return
+	}
+	m.consecutiveLosses++
+	if m.limits.MaxConsecutiveLosses > 0 && m.consecutiveLosses >= m.limits.MaxConsecutiveLosses {
+		m.cooldownUntil = now.Add(time.Duration(m.limits.Cooldown) * time.Second)
+		m.consecutiveLosses = 0
+	}
+}
+
+func (m *RiskManager) Status() domain.RiskStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	positions := make(map[string]int, len(m.positions))
+	for ticker, size := range m.positions {
+		positions[ticker] = size
+	}
+	return domain.RiskStatus{
+		Limits:            m.limits,
+		Killed:            m.killed,
+		Positions:         positions,
+		Exposure:          m.exposure(),
+		DailyPnL:          m.profitSince(startOfDay(now)),
+		WeeklyPnL:         m.profitSince(startOfWeek(now)),
+		TradesLastHour:    m.tradesSince(now.Add(-time.Hour)),
+		ConsecutiveLosses: m.consecutiveLosses,
+		CooldownUntil:     m.cooldownUntil,
+	}
+}
+
+// prune удаляет записи, которые уже не влияют на ограничения
+func (m *RiskManager) prune(now time.Time) {
+	hourAgo := now.Add(-time.Hour)
+	for len(m.opened) > 0 && !m.opened[0].After(hourAgo) {
+		m.opened = m.opened[1:]
+	}
+	week := startOfWeek(now)
+	for len(m.closed) > 0 && m.closed[0].ts.Before(week) {
+		m.closed = m.closed[1:]
+	}
+}
+
+func (m *RiskManager) exposure() float32 {
+	var sum float32
+	for _, n := range m.notional {
+		sum += n
+	}
+	return sum
+}
+
+func (m *RiskManager) profitSince(from time.Time) float32 {
+	var sum float32
+	for _, trade := range m.closed {
+		if !trade.ts.Before(from) {
+			sum += trade.profit
+		}
+	}
+	return sum
+}
+
+func (m *RiskManager) tradesSince(from time.Time) int {
+	var count int
+	for _, ts := range m.opened {
+		if ts.After(from) {
+			count++
+		}
+	}
+	return count
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// startOfWeek - начало недели с понедельника
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	weekday := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -weekday)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// notional - размер позиции в долларах
+func notional(inst domain.Instrument, size int, price float32) float32 {
+	if inst.Type == "futures_inverse" {
+		return float32(size) * contractSize(inst)
+	}
+	return float32(size) * contractSize(inst) * price
+}
+
+// closeBackoff - растущая пауза между повторами закрытия позиции, которое не удалось
+type closeBackoff struct {
+	next  time.Time
+	delay time.Duration
+}
+
+// ready - true, если пауза после последней неудачи прошла
+func (b *closeBackoff) ready(now time.Time) bool {
+	return !now.Before(b.next)
+}
+
+// failed увеличивает паузу после неудачной попытки. Возвращает true для первой неудачи подряд
+func (b *closeBackoff) failed(now time.Time) bool {
+	first := b.delay == 0
+	b.delay *= 2
+	if first {
+		b.delay = minCloseRetry
+	}
+	if b.delay > maxCloseRetry {
+		b.delay = maxCloseRetry
+	}
+	b.next = now.Add(b.delay)
+	return first
+}
+
+func (b *closeBackoff) reset() {
+	*b = closeBackoff{}
+}
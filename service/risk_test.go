@@ -0,0 +1,166 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskManagerCheckOpen(t *testing.T) {
+	now := time.Date(2021, 12, 1, 12, 0, 0, 0, time.UTC)
+	// Test Table
+	type Test struct {
+		Name      string
+		Limits    domain.RiskLimits
+		Prepare   func(m *RiskManager)
+		Size      int
+		Notional  float32
+		ExpectErr bool
+	}
+	tests := [...]Test{
+		{Name: "No limits", Size: 100, Notional: 100},
+		{Name: "Kill switch", Prepare: func(m *RiskManager) { m.Kill() }, Size: 1, Notional: 1, ExpectErr: true},
+		{Name: "Max position", Limits: domain.RiskLimits{MaxPosition: 10}, Prepare: func(m *RiskManager) { m.OnOpen("PI_XBTUSD", "sell", 8, 8) }, Size: 3, Notional: 3, ExpectErr: true},
+		{Name: "Max position other instrument", Limits: domain.RiskLimits{MaxPosition: 10}, Prepare: func(m *RiskManager) { m.OnOpen("PI_ETHUSD", "buy", 8, 8) }, Size: 3, Notional: 3},
+		{Name: "Max exposure", Limits: domain.RiskLimits{MaxExposure: 1000}, Prepare: func(m *RiskManager) { m.OnOpen("PI_ETHUSD", "buy", 800, 800) }, Size: 300, Notional: 300, ExpectErr: true},
+		{Name: "Daily loss", Limits: domain.RiskLimits{MaxDailyLoss: 50}, Prepare: func(m *RiskManager) { m.OnResult(-60) }, Size: 1, Notional: 1, ExpectErr: true},
+		{
+			Name:   "Daily loss in dollars on inverse contract",
+			Limits: domain.RiskLimits{MaxDailyLoss: 500},
+			Prepare: func(m *RiskManager) {
+				// 10000 контрактов по $1, цена упала на 6% - убыток $600
+				inverse := domain.Instrument{Type: "futures_inverse", ContractSize: 1}
+				m.OnResult(pnl(inverse, "buy", 10000, 50000, 47000))
+			},
+			Size: 1, Notional: 1, ExpectErr: true,
+		},
+		{
+			Name:   "Yesterday loss counts only for week",
+			Limits: domain.RiskLimits{MaxDailyLoss: 50},
+			Prepare: func(m *RiskManager) {
+				m.now = func() time.Time { return now.Add(-24 * time.Hour) }
+				m.OnResult(-60)
+				m.now = func() time.Time { return now }
+			},
+			Size: 1, Notional: 1,
+		},
+		{
+			Name:   "Weekly loss",
+			Limits: domain.RiskLimits{MaxWeeklyLoss: 100},
+			Prepare: func(m *RiskManager) {
+				m.now = func() time.Time { return now.Add(-24 * time.Hour) }
+				m.OnResult(-60)
+				m.now = func() time.Time { return now }
+				m.OnResult(-50)
+			},
+			Size: 1, Notional: 1, ExpectErr: true,
+		},
+		{
+			Name:   "Trades per hour",
+			Limits: domain.RiskLimits{MaxTradesPerHour: 2},
+			Prepare: func(m *RiskManager) {
+				m.OnOpen("PI_XBTUSD", "buy", 1, 1)
+				m.OnResult(1)
+				m.OnOpen("PI_XBTUSD", "buy", 1, 1)
+				m.OnResult(1)
+			},
+			Size: 1, Notional: 1, ExpectErr: true,
+		},
+		{
+			Name:   "Cooldown after losses",
+			Limits: domain.RiskLimits{MaxConsecutiveLosses: 2, Cooldown: 600},
+			Prepare: func(m *RiskManager) {
+				m.OnResult(-1)
+				m.OnResult(-1)
+			},
+			Size: 1, Notional: 1, ExpectErr: true,
+		},
+		{
+			Name:   "Cooldown is over",
+			Limits: domain.RiskLimits{MaxConsecutiveLosses: 2, Cooldown: 600},
+			Prepare: func(m *RiskManager) {
+				m.OnResult(-1)
+				m.OnResult(-1)
+				m.now = func() time.Time { return now.Add(11 * time.Minute) }
+			},
+			Size: 1, Notional: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			m := NewRiskManager()
+			m.now = func() time.Time { return now }
+			m.SetLimits(test.Limits)
+			if test.Prepare != nil {
+				test.Prepare(m)
+			}
+			err := m.CheckOpen("PI_XBTUSD", test.Size, test.Notional)
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRiskManagerStatus(t *testing.T) {
+	m := NewRiskManager()
+	m.OnOpen("PI_XBTUSD", "sell", 5, 5)
+	m.OnOpen("PI_ETHUSD", "buy", 3, 3)
+	m.OnClose("PI_ETHUSD", "buy", 3, 3, -2)
+	m.Kill()
+
+	status := m.Status()
+	assert.True(t, status.Killed)
+	assert.Equal(t, map[string]int{"PI_XBTUSD": -5}, status.Positions)
+	assert.Equal(t, float32(5), status.Exposure)
+	assert.Equal(t, float32(-2), status.DailyPnL)
+	assert.Equal(t, 2, status.TradesLastHour)
+	assert.Equal(t, 1, status.ConsecutiveLosses)
+
+	m.Reset()
+	assert.False(t, m.Killed())
+}
+
+func TestCloseBackoff(t *testing.T) {
+	now := time.Date(2021, 12, 1, 12, 0, 0, 0, time.UTC)
+	var b closeBackoff
+	assert.True(t, b.ready(now))
+	assert.True(t, b.failed(now))
+	assert.False(t, b.ready(now.Add(500*time.Millisecond)))
+	assert.True(t, b.ready(now.Add(time.Second)))
+	assert.False(t, b.failed(now))
+	assert.False(t, b.ready(now.Add(time.Second)))
+	for i := 0; i < 10; i++ {
+		b.failed(now)
+	}
+	assert.Equal(t, maxCloseRetry, b.delay)
+	b.reset()
+	assert.True(t, b.ready(now))
+}
+
+func TestRiskManagerSharedTicker(t *testing.T) {
+	m := NewRiskManager()
+	// Сетка держит 2 контракта, пары открывают и закрывают продажу по тому же инструменту
+	m.OnOpen("PI_XBTUSD", "buy", 2, 200)
+	m.OnOpen("PI_XBTUSD", "sell", 1, 100)
+	m.OnClose("PI_XBTUSD", "sell", 1, 100, 5)
+	status := m.Status()
+	assert.Equal(t, map[string]int{"PI_XBTUSD": 2}, status.Positions)
+	assert.Equal(t, float32(200), status.Exposure)
+
+	// Робот закрывает свою покупку, позиция сетки остается в лимитах
+	m.OnOpen("PI_XBTUSD", "buy", 1, 100)
+	m.OnClose("PI_XBTUSD", "buy", 1, 100, -1)
+	status = m.Status()
+	assert.Equal(t, map[string]int{"PI_XBTUSD": 2}, status.Positions)
+	assert.Equal(t, float32(200), status.Exposure)
+
+	m.OnReduce("PI_XBTUSD", 2, 200)
+	assert.Empty(t, m.Status().Positions)
+	assert.Equal(t, float32(0), m.Status().Exposure)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -13,32 +14,257 @@ import (
 
 //go:generate mockgen -source=robot.go -destination=mocks/mock.go
 
+const (
+	wsAddr        = "wss://demo-futures.kraken.com/ws/v1"
+	sendOrderAddr = "http://demo-futures.kraken.com/derivatives/api/v3/sendorder"
+)
+
 type repoInterface interface {
 	SendOrder(symbol, side string, size int, addr string) (domain.APIResp, error)
 	SetWSConnection(addr string, tick string) (chan domain.WsResponse, func(), error)
+	SetWSConnectionMulti(addr string, ticks []string) (chan domain.WsResponse, func(), error)
 	GetTotalProfitDb(ctx context.Context) (float32, error)
 	WriteOrderToDb(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit float32, stoploss float32) error
-	WriteToTelegramBot(text string)
-	GetUsersMap(string) map[string]string
+	Notify(ev domain.Event)
+	GetUser(ctx context.Context, login string) (domain.User, error)
+	ListUsers(ctx context.Context) ([]domain.User, error)
+	CreateUser(ctx context.Context, u domain.User) error
+	SetUserDisabled(ctx context.Context, login string, disabled bool) error
+	SetUserRole(ctx context.Context, login, role string) error
+	SetUserPassword(ctx context.Context, login, hash string) error
+	RevokeToken(ctx context.Context, id string, expires time.Time) error
+	GetRevokedTokens(ctx context.Context) (map[string]time.Time, error)
+	GetInstruments(addr string) ([]domain.Instrument, error)
+	GetAvailableMargin(addr string) (float32, error)
+	SendLimitOrder(symbol, side string, size int, price float32, addr string) (domain.APIResp, error)
+	CancelOrder(orderID string, addr string) error
+	GetFills(addr string) ([]domain.Fill, error)
+	GetCandles(symbol, resolution string, from, to time.Time, addr string) ([]domain.Candle, error)
+	SaveOptimization(ctx context.Context, report domain.OptimizeReport) error
+	WriteSignalToDb(ctx context.Context, sig domain.Signal, status, reason string) error
+	WriteOrderWithEvent(ctx context.Context, inst string, size int, side string, price float32, ordtype string, profit float32, stoploss float32, ev domain.Event) error
+	GetOutboxStatus(ctx context.Context) (domain.OutboxStatus, error)
+	GetTrades(ctx context.Context, from, to time.Time) ([]domain.Trade, error)
 }
 
 type RobotInterface interface {
 	SetParams(start, size int, profit float32, ticker, side string)
 	SetStart(start int)
 	SetParamsWithoutStart(size int, profit float32, ticker, side string)
+	SetSizing(sizing domain.Sizing)
+	SetLoop(loop domain.Loop)
+	SetDCA(dca domain.DCA)
+	SetStrategy(params domain.Strategy)
+	SetExits(exits domain.Exits)
 	GetParams() domain.Options
-	GetUsersMap(string) map[string]string
+	GetStatus() domain.RobotStatus
+	Authenticate(login, passwd string) (domain.User, error)
+	ListUsers() ([]domain.User, error)
+	CreateUser(login, passwd, role string) error
+	SetUserDisabled(login string, disabled bool) error
+	SetUserRole(login, role string) error
+	ResetPassword(login, passwd string) error
+	GetUser(login string) (domain.User, error)
+	RevokeToken(id string, expires time.Time) error
+	IsTokenRevoked(id string) bool
+	LoadRevokedTokens() error
+	GetInstrument(ticker string) (domain.Instrument, bool)
+	WatchInstruments(period time.Duration)
+	KillSwitch()
+	ResetKillSwitch()
+	SetRiskLimits(limits domain.RiskLimits)
+	GetRiskStatus() domain.RiskStatus
+	GetTotalProfit() (float32, error)
+	GetOutboxStatus() (domain.OutboxStatus, error)
+	GetReport(period string) (string, error)
+	RunReports()
+	ListTrades(from, to time.Time) ([]domain.Trade, error)
+	WatchEvents() (chan domain.Event, func())
+	WatchTicks(ticker string) (chan domain.WsResponse, func(), error)
+	SetSchedule(sch domain.Schedule) error
+	DeleteSchedule(id string) bool
+	GetSchedules() []domain.Schedule
+	RunSchedules()
+	TradingAllowed() bool
+	CheckStart(trading string) error
+	StartGrid(params domain.GridParams) error
+	StopGrid() error
+	GetGridStatus() domain.GridStatus
+	StartPairs(params domain.PairsParams) error
+	StopPairs() error
+	GetPairsStatus() domain.PairsStatus
+	SetScript(sc domain.Script) error
+	DeleteScript(name string) bool
+	GetScripts() []domain.Script
+	Optimize(req domain.OptimizeRequest) (domain.OptimizeReport, error)
+	SetReportsDir(dir string)
+	HandleSignal(sig domain.Signal) error
 }
 
 type RobotService struct {
-	repo   repoInterface
-	log    logrus.FieldLogger
-	params domain.Options
-	mu     sync.Mutex
+	repo        repoInterface
+	log         logrus.FieldLogger
+	params      domain.Options
+	instruments *Instruments
+	risk        *RiskManager
+	schedules   *Scheduler
+	grid        *Grid
+	pairs       *Pairs
+	scripts     *Scripts
+	optimizer   *Optimizer
+	signals     *Signals
+	events      *Events
+	ticks       *Ticks
+	revoked     *Revocations
+	state       string
+	cycle       int
+	cyclePnL    float32
+	mu          sync.Mutex
+	startMu     sync.Mutex // запуск сетки и пар: проверка и запуск не пересекаются
+}
+
+func (r *RobotService) GetInstrument(ticker string) (domain.Instrument, bool) {
+	return r.instruments.Get(ticker)
+}
+
+func (r *RobotService) WatchInstruments(period time.Duration) {
+	r.instruments.Watch(period)
+}
+
+// KillSwitch останавливает робота, закрывая открытую позицию, и блокирует новые сделки до сброса
+func (r *RobotService) KillSwitch() {
+	r.risk.Kill()
+	// Позицию основного цикла закрывает GetStart: при ошибке он повторяет закрытие, пока позиция не закрыта
+	r.SetStart(0)
+	if r.grid.Status().Active {
+		if err := r.grid.Stop(true); err != nil {
+			r.log.Errorln("Kill switch can't stop grid: ", err)
+			r.repo.Notify(domain.Event{Type: domain.EventRobotError, Reason: "kill switch can't stop grid: " + err.Error()})
+		}
+	}
+	if r.pairs.Status().Active {
+		if err := r.pairs.Stop(); err != nil {
+			r.log.Errorln("Kill switch can't stop pairs trading: ", err)
+			r.repo.Notify(domain.Event{Type: domain.EventRobotError, Reason: "kill switch can't stop pairs trading: " + err.Error()})
+		}
+	}
+	r.log.Warnln("Kill switch is engaged")
+}
+
+func (r *RobotService) ResetKillSwitch() {
+	r.risk.Reset()
+	r.log.Infoln("Kill switch is reset")
+}
+
+func (r *RobotService) SetRiskLimits(limits domain.RiskLimits) {
+	r.risk.SetLimits(limits)
+}
+
+func (r *RobotService) GetRiskStatus() domain.RiskStatus {
+	return r.risk.Status()
+}
+
+func (r *RobotService) GetOutboxStatus() (domain.OutboxStatus, error) {
+	return r.repo.GetOutboxStatus(context.Background())
+}
+
+// ListTrades - закрытые сделки с from до to
+func (r *RobotService) ListTrades(from, to time.Time) ([]domain.Trade, error) {
+	return r.repo.GetTrades(context.Background(), from, to)
+}
+
+// WatchEvents подписывает на события робота. Вызов возвращенной функции отменяет подписку
+func (r *RobotService) WatchEvents() (chan domain.Event, func()) {
+	return r.events.Subscribe()
+}
+
+// WatchTicks подписывает на тики инструмента. Подписчики одного инструмента получают тики из одного WS соединения
+func (r *RobotService) WatchTicks(ticker string) (chan domain.WsResponse, func(), error) {
+	if _, ok := r.instruments.Get(ticker); !ok {
+		return nil, nil, errors.New("unknown instrument " + ticker)
+	}
+	return r.ticks.Subscribe(ticker)
+}
+
+// GetTotalProfit - прибыль по всем закрытым сделкам из базы
+func (r *RobotService) GetTotalProfit() (float32, error) {
+	return r.repo.GetTotalProfitDb(context.Background())
+}
+
+func (r *RobotService) SetSchedule(sch domain.Schedule) error {
+	return r.schedules.Set(sch)
+}
+
+func (r *RobotService) DeleteSchedule(id string) bool {
+	return r.schedules.Delete(id)
+}
+
+func (r *RobotService) GetSchedules() []domain.Schedule {
+	return r.schedules.List()
+}
+
+func (r *RobotService) RunSchedules() {
+	r.schedules.Run()
+}
+
+// TradingAllowed - false, если сейчас действует запрещенный интервал одного из расписаний
+func (r *RobotService) TradingAllowed() bool {
+	return !r.schedules.InBlackout(time.Now())
+}
+
+// CheckStart проверяет, можно ли сейчас запустить торговлю trading: робот, сетка и пары одновременно не работают,
+// а в запрещенный расписанием интервал и при включенном kill switch ничего не запускается
+func (r *RobotService) CheckStart(trading string) error {
+	switch {
+	case r.risk.Killed():
+		return ErrKillSwitch
+	case trading != domain.TradingRobot && r.GetParams().Start == 1:
+		return domain.ErrRobotActive
+	case trading != domain.TradingGrid && r.grid.Status().Active:
+		return domain.ErrGridActive
+	case trading != domain.TradingPairs && r.pairs.Status().Active:
+		return domain.ErrPairsActive
+	case !r.TradingAllowed():
+		return domain.ErrTradingNotAllowed
+	}
+	return nil
+}
+
+// StartGrid запускает сеточную стратегию. Одновременно с обычной торговлей и парами сетка не работает
+func (r *RobotService) StartGrid(params domain.GridParams) error {
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+	if err := r.CheckStart(domain.TradingGrid); err != nil {
+		return err
+	}
+	return r.grid.Start(params)
+}
+
+func (r *RobotService) StopGrid() error {
+	return r.grid.Stop(false)
+}
+
+func (r *RobotService) GetGridStatus() domain.GridStatus {
+	return r.grid.Status()
+}
+
+// StartPairs запускает торговлю спредом. Одновременно с обычной торговлей и сеткой пары не работают
+func (r *RobotService) StartPairs(params domain.PairsParams) error {
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+	if err := r.CheckStart(domain.TradingPairs); err != nil {
+		return err
+	}
+	return r.pairs.Start(params)
+}
+
+// StopPairs останавливает торговлю спредом и закрывает обе ноги
+func (r *RobotService) StopPairs() error {
+	return r.pairs.Stop()
 }
 
-func (r *RobotService) GetUsersMap(file string) map[string]string {
-	return r.repo.GetUsersMap(file)
+func (r *RobotService) GetPairsStatus() domain.PairsStatus {
+	return r.pairs.Status()
 }
 
 func (r *RobotService) SetStart(start int) {
@@ -66,6 +292,12 @@ func (r *RobotService) SetParamsWithoutStart(size int, profit float32, ticker, s
 	r.mu.Unlock()
 }
 
+func (r *RobotService) SetSizing(sizing domain.Sizing) {
+	r.mu.Lock()
+	r.params.Sizing = sizing
+	r.mu.Unlock()
+}
+
 func (r *RobotService) GetParams() domain.Options {
 	var Opt domain.Options
 	r.mu.Lock()
@@ -74,6 +306,11 @@ func (r *RobotService) GetParams() domain.Options {
 	Opt.Ticker = r.params.Ticker
 	Opt.Size = r.params.Size
 	Opt.Side = r.params.Side
+	Opt.Sizing = r.params.Sizing
+	Opt.Loop = r.params.Loop
+	Opt.DCA = r.params.DCA
+	Opt.Strategy = r.params.Strategy
+	Opt.Exits = r.params.Exits
 	r.mu.Unlock()
 	return Opt
 }
@@ -88,24 +325,58 @@ func GetError(resp domain.APIResp) string {
 	return s
 }
 
+// rejectReason - причина, по которой ордер не был размещен, для уведомления
+func rejectReason(resp domain.APIResp, err error) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case resp.Error != "":
+		return resp.Error
+	case resp.Result != "success":
+		return resp.Result
+	}
+	return resp.SendStatus.Status
+}
+
 func (r *RobotService) GetStart() {
 	for {
 		time.Sleep(100 * time.Millisecond)
 		start := r.GetParams().Start
 		if start != 1 {
+			r.restoreAfterSignal()
+			r.setStopped()
+			continue
+		}
+		// Каждая сделка серии проверяет расписание: следующий цикл не начинается в запрещенный интервал
+		if !r.TradingAllowed() {
+			r.log.Infoln("Trading is not allowed now by schedule, stopping")
+			r.SetStart(0)
 			continue
 		}
+		r.beginCycle()
 
 		params := r.GetParams()
 		r.log.Infoln("Start trading with params: ", params)
+		inst, _ := r.instruments.Get(params.Ticker)
 
-		priceChan, cancel, err := r.repo.SetWSConnection("wss://demo-futures.kraken.com/ws/v1", params.Ticker)
+		priceChan, cancel, err := r.repo.SetWSConnection(wsAddr, params.Ticker)
 		if err != nil {
 			r.log.Errorln("Bad request to WebSocket: ", err)
 			r.SetStart(0)
 			continue
 		}
 
+		var series *candles
+		if strat := r.strategy(params.Strategy); strat != nil {
+			series = newCandles(time.Duration(params.Interval)*time.Second, params.Period+1)
+			side, ok := r.waitSignal(params, strat, series, priceChan)
+			if !ok {
+				cancel()
+				continue
+			}
+			params.Side = side
+		}
+
 		// Небольшой анализ рынка, если направление сделки не задано вручную
 		if params.Side == "" {
 			maxAndMinPrice := map[string]float32{"max": 0, "min": 100000000000000, "mid": 0}
@@ -129,7 +400,34 @@ func (r *RobotService) GetStart() {
 			}
 		}
 
-		resp, err := r.repo.SendOrder(strings.ToLower(params.Ticker), params.Side, params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder")
+		params.Size, err = r.positionSize(params, inst, priceChan)
+		if err != nil {
+			r.log.Errorln("Can't calculate position size: ", err)
+			r.repo.Notify(domain.Event{Type: domain.EventRobotError, Ticker: params.Ticker, Reason: "can't calculate position size: " + err.Error()})
+			r.SetStart(0)
+			cancel()
+			continue
+		}
+
+		// Для линейных контрактов размер позиции в долларах зависит от цены
+		var lastPrice float32
+		if inst.Type != "futures_inverse" {
+			lastPrice = midPrice(<-priceChan)
+		}
+		err = r.risk.CheckOpen(params.Ticker, params.Size, notional(inst, params.Size, lastPrice))
+		// Ожидание сигнала стратегии может затянуться до запрещенного интервала
+		if err == nil && !r.TradingAllowed() {
+			err = errors.New("trading is not allowed now by schedule")
+		}
+		if err != nil {
+			r.log.Infoln("Order blocked by risk manager: ", err)
+			r.repo.Notify(domain.Event{Type: domain.EventRiskLimitHit, Ticker: params.Ticker, Side: params.Side, Size: params.Size, Reason: err.Error()})
+			r.SetStart(0)
+			cancel()
+			continue
+		}
+
+		resp, err := r.repo.SendOrder(strings.ToLower(params.Ticker), params.Side, params.Size, sendOrderAddr)
 		if err != nil {
 			r.log.Errorln("Bad request to Api, while sending order: ", err)
 			r.SetStart(0)
@@ -139,7 +437,7 @@ func (r *RobotService) GetStart() {
 		// Api запрос на открытие сделки вернул ошибку
 		if resp.Result != "success" || resp.SendStatus.Status != "placed" {
 			r.log.Infoln(GetError(resp))
-			r.repo.WriteToTelegramBot(GetError(resp))
+			r.repo.Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: params.Ticker, Side: params.Side, Size: params.Size, Reason: rejectReason(resp, nil)})
 			r.SetStart(0)
 			cancel()
 			continue
@@ -147,60 +445,116 @@ func (r *RobotService) GetStart() {
 
 		// сообщение о покупке, запись в базу
 		price := resp.SendStatus.OrderEvents[0].Price
-		upperLimit := price * (1 + params.Profit/100)
-		lowerLimit := price * (1 - params.Profit/100)
-		message := fmt.Sprintf("Order had been opened.\nInstrument - %s, side - %s, size - %d, price - %.1f\nStoploss/takeprofit is %.1f/%.1f\n", params.Ticker, params.Side, params.Size, price, upperLimit, lowerLimit)
-		r.repo.WriteToTelegramBot(message)
-		err = r.repo.WriteOrderToDb(context.Background(), params.Ticker, params.Size, params.Side, price, "open", 0, params.Profit)
-		if err != nil {
-			r.log.Errorln("Can't write do Database: ", err)
+		r.risk.OnOpen(params.Ticker, params.Side, params.Size, notional(inst, params.Size, price))
+		pos := newPosition(inst, params.Side)
+		pos.add(params.Size, price)
+		upperLimit, lowerLimit := exitLimits(params, pos)
+		// Для покупки стоп-лосс ниже цены входа, тейк-профит выше, для продажи наоборот
+		stopLoss, takeProfit := lowerLimit, upperLimit
+		if params.Side == "sell" {
+			stopLoss, takeProfit = upperLimit, lowerLimit
 		}
+		r.writeOrder(params.Ticker, params.Size, params.Side, price, "open", 0, params.Profit, domain.Event{
+			Type: domain.EventOrderOpened, Ticker: params.Ticker, Side: params.Side, Size: params.Size, Price: price,
+			StopLoss: stopLoss, TakeProfit: takeProfit,
+		})
 
-		// Слушаем канал и принимаем решение о закрытии
+		// Слушаем канал и принимаем решение об усреднении или закрытии
+		safety := 0
+		var retry closeBackoff
 		for wsReturn := range priceChan {
 			r.log.Debugf("%+v\n", wsReturn)
 			closePrice := wsReturn.Ask
 			if params.Side == "buy" {
 				closePrice = wsReturn.Bid
 			}
-			if closePrice > upperLimit || closePrice < lowerLimit || r.GetParams().Start != 1 {
-				params.Side = reverseSide(params.Side)
-				resp, err = r.repo.SendOrder(strings.ToLower(params.Ticker), params.Side, params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder")
+			stopped := r.GetParams().Start != 1
+			// Скрипт может закрыть позицию по своему сигналу на закрытии каждой свечи
+			var scriptClose bool
+			if params.Name == "script" && series.add(time.Now(), midPrice(wsReturn)) {
+				decision, err := r.scripts.Run(params.Script, series.closed, wsReturn, pos)
+				if err != nil {
+					r.log.Errorln("Script error: ", err)
+				}
+				scriptClose = decision == "close"
+			}
+			if !stopped && safety < params.SafetyOrders && pos.against(closePrice, safetyPrice(params.DCA, params.Side, price, safety+1)) {
+				safety++
+				if !r.safetyOrder(params, pos, safety, closePrice) {
+					// Дальше не усредняемся, позиция закрывается по тейк-профиту или стоп-лоссу
+					safety = params.SafetyOrders
+				}
+				upperLimit, lowerLimit = exitLimits(params, pos)
+				continue
+			}
+			takeProfit, stopLoss := pos.exit(closePrice, upperLimit, lowerLimit)
+			// Пока остаются страховочные ордера, убыточное движение цены усредняется, а не закрывается
+			if (takeProfit || (stopLoss && safety >= params.SafetyOrders) || stopped || scriptClose) && retry.ready(time.Now()) {
+				size := pos.size()
+				closeSide := reverseSide(params.Side)
+				resp, err = r.repo.SendOrder(strings.ToLower(params.Ticker), closeSide, size, sendOrderAddr)
 				if resp.Result != "success" || resp.SendStatus.Status != "placed" || err != nil {
-					r.log.Errorln(GetError(resp))
-					r.repo.WriteToTelegramBot(GetError(resp))
+					// Позиция остается открытой и учитывается риск-менеджером, закрытие повторяется с паузой,
+					// а новые сделки не открываются
+					r.log.Errorln("Can't close position, retrying: ", GetError(resp), err)
+					if retry.failed(time.Now()) {
+						r.repo.Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: params.Ticker, Side: closeSide, Size: size, Reason: rejectReason(resp, err)})
+					}
 					r.SetStart(0)
-					cancel() // closing WS connection
-					break
+					continue
 				}
+				params.Side = closeSide
 				cancel()
-				r.SetStart(0)
 				r.log.Infoln("The order had been closed")
 				// Запись в базу и сообщение в телеграмм
-				profit := closePrice - price
-				if params.Side == "buy" {
-					profit *= -1
-				}
-				err = r.repo.WriteOrderToDb(context.Background(), params.Ticker, params.Size, params.Side, closePrice, "close", profit*float32(params.Size), 0)
-				if err != nil {
-					r.log.Errorln("Can't write to DB: ", err)
-				}
+				profit := pos.pnl(closePrice)
+				r.risk.OnClose(params.Ticker, pos.side, size, pos.notional(), profit)
+				// Сделка еще не записана, поэтому ее прибыль добавляется к сумме из базы
 				total, _ := r.repo.GetTotalProfitDb(context.Background())
-				message = fmt.Sprintf("Order had been closed.\nInstrument - %s, side - %s, size - %d, open price - %.1f, close price - %.1f, profit is %.1f\nTotal profit is %.1f", params.Ticker, params.Side, params.Size, price, closePrice, profit*float32(params.Size), total)
-				r.repo.WriteToTelegramBot(message)
+				r.writeOrder(params.Ticker, size, params.Side, closePrice, "close", profit, 0, domain.Event{
+					Type: domain.EventOrderClosed, Ticker: params.Ticker, Side: params.Side, Size: size, Price: closePrice,
+					OpenPrice: pos.avgPrice(), Profit: profit, TotalProfit: total + profit,
+				})
+				r.finishCycle(profit, stopped)
 				break
 			}
 		}
 	}
 }
 
+func (r *RobotService) writeOrder(ticker string, size int, side string, price float32, ordtype string, profit, stoploss float32, ev domain.Event) {
+	writeOrder(r.repo, r.log, ticker, size, side, price, ordtype, profit, stoploss, ev)
+}
+
+// writeOrder записывает сделку вместе с уведомлением о ней. Если запись не удалась, уведомление все равно отправляется
+func writeOrder(repo repoInterface, log logrus.FieldLogger, ticker string, size int, side string, price float32, ordtype string, profit, stoploss float32, ev domain.Event) {
+	err := repo.WriteOrderWithEvent(context.Background(), ticker, size, side, price, ordtype, profit, stoploss, ev)
+	if err != nil {
+		log.Errorln("Can't write to DB: ", err)
+		repo.Notify(ev)
+	}
+}
+
 func NewRobotService(repo repoInterface, logger logrus.FieldLogger) RobotInterface {
+	events := NewEvents()
+	repo = &eventsRepo{repoInterface: repo, events: events}
 	robot := RobotService{
-		repo:   repo,
-		log:    logger,
-		params: domain.Options{},
-		mu:     sync.Mutex{},
+		repo:        repo,
+		log:         logger,
+		params:      domain.Options{},
+		instruments: NewInstruments(repo, logger),
+		risk:        NewRiskManager(),
+		events:      events,
+		ticks:       NewTicks(repo),
+		mu:          sync.Mutex{},
 	}
+	robot.schedules = NewScheduler(&robot, logger)
+	robot.grid = NewGrid(repo, logger, robot.risk, robot.instruments, robot.TradingAllowed)
+	robot.pairs = NewPairs(repo, logger, robot.risk, robot.instruments)
+	robot.scripts = NewScripts(logger)
+	robot.optimizer = NewOptimizer(repo, logger, robot.instruments, robot.strategy)
+	robot.signals = NewSignals()
+	robot.revoked = NewRevocations()
 	go robot.GetStart()
 
 	return &robot
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -35,14 +34,39 @@ func TestLogin3(t *testing.T) {
 				r.EXPECT().SetWSConnection("wss://demo-futures.kraken.com/ws/v1", params.Ticker).Return(ch, func() {}, nil)
 				r.EXPECT().SendOrder(strings.ToLower(params.Ticker), params.Side, params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder").Return(resp, nil)
 				price := resp.SendStatus.OrderEvents[0].Price
-				message := fmt.Sprintf("Order had been opened.\nInstrument - %s, side - %s, size - %d, price - %.1f\nStoploss/takeprofit is %.1f/%.1f\n", params.Ticker, params.Side, params.Size, price, price*(1+params.Profit/100), price*(1-params.Profit/100))
-				r.EXPECT().WriteToTelegramBot(message).Return()
-				r.EXPECT().WriteOrderToDb(context.Background(), params.Ticker, params.Size, params.Side, price, "open", float32(0), params.Profit).Return(nil)
+				// Для покупки стоп-лосс ниже цены входа
+				opened := domain.Event{Type: domain.EventOrderOpened, Ticker: params.Ticker, Side: params.Side, Size: params.Size, Price: price,
+					StopLoss: price * (1 - params.Profit/100), TakeProfit: price * (1 + params.Profit/100)}
+				r.EXPECT().WriteOrderWithEvent(context.Background(), params.Ticker, params.Size, params.Side, price, "open", float32(0), params.Profit, opened).Return(nil)
 				r.EXPECT().SendOrder(strings.ToLower(params.Ticker), reverseSide(params.Side), params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder").Return(resp, nil)
-				r.EXPECT().WriteOrderToDb(context.Background(), params.Ticker, params.Size, reverseSide(params.Side), price*1.1, "close", price*0.1, float32(0)).Return(nil)
+				// PI_XBTUSD - инверсный контракт, результат считается относительно цены открытия
+				profit := (price*1.1 - price) / price * float32(params.Size)
 				r.EXPECT().GetTotalProfitDb(context.Background()).Return(float32(50.0), nil)
-				message = fmt.Sprintf("Order had been closed.\nInstrument - %s, side - %s, size - %d, open price - %.1f, close price - %.1f, profit is %.1f\nTotal profit is %.1f", params.Ticker, reverseSide(params.Side), params.Size, price, price*1.1, price*0.1*float32(params.Size), 50.0)
-				r.EXPECT().WriteToTelegramBot(message).Return()
+				closed := domain.Event{Type: domain.EventOrderClosed, Ticker: params.Ticker, Side: reverseSide(params.Side), Size: params.Size, Price: price * 1.1,
+					OpenPrice: price, Profit: profit, TotalProfit: 50.0 + profit}
+				r.EXPECT().WriteOrderWithEvent(context.Background(), params.Ticker, params.Size, reverseSide(params.Side), price*1.1, "close", profit, float32(0), closed).Return(nil)
+			},
+		},
+		{
+			Name:    "DB error",
+			Params:  domain.Options{Start: 1, Side: "buy", Size: 1, Profit: 0.01, Ticker: "PI_XBTUSD"},
+			APIResp: domain.APIResp{Result: "success", SendStatus: domain.SendStatus{Status: "placed", OrderEvents: []domain.OrderEvents{domain.OrderEvents{Price: 50000}}}},
+			mockBehavior: func(r *mock_service.MockrepoInterface, ch chan domain.WsResponse, params domain.Options, resp domain.APIResp) {
+				r.EXPECT().SetWSConnection("wss://demo-futures.kraken.com/ws/v1", params.Ticker).Return(ch, func() {}, nil)
+				r.EXPECT().SendOrder(strings.ToLower(params.Ticker), params.Side, params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder").Return(resp, nil)
+				price := resp.SendStatus.OrderEvents[0].Price
+				// Для покупки стоп-лосс ниже цены входа
+				opened := domain.Event{Type: domain.EventOrderOpened, Ticker: params.Ticker, Side: params.Side, Size: params.Size, Price: price,
+					StopLoss: price * (1 - params.Profit/100), TakeProfit: price * (1 + params.Profit/100)}
+				r.EXPECT().WriteOrderWithEvent(context.Background(), params.Ticker, params.Size, params.Side, price, "open", float32(0), params.Profit, opened).Return(nil)
+				r.EXPECT().SendOrder(strings.ToLower(params.Ticker), reverseSide(params.Side), params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder").Return(resp, nil)
+				profit := (price*1.1 - price) / price * float32(params.Size)
+				r.EXPECT().GetTotalProfitDb(context.Background()).Return(float32(50.0), nil)
+				closed := domain.Event{Type: domain.EventOrderClosed, Ticker: params.Ticker, Side: reverseSide(params.Side), Size: params.Size, Price: price * 1.1,
+					OpenPrice: price, Profit: profit, TotalProfit: 50.0 + profit}
+				// Если запись в базу не удалась, уведомление отправляется напрямую
+				r.EXPECT().WriteOrderWithEvent(context.Background(), params.Ticker, params.Size, reverseSide(params.Side), price*1.1, "close", profit, float32(0), closed).Return(errors.New("db error"))
+				r.EXPECT().Notify(closed).Return()
 			},
 		},
 		{
@@ -62,12 +86,13 @@ func TestLogin3(t *testing.T) {
 				r.EXPECT().SetWSConnection("wss://demo-futures.kraken.com/ws/v1", params.Ticker).Return(ch, func() {}, nil)
 				r.EXPECT().SendOrder(strings.ToLower(params.Ticker), params.Side, params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder").Return(resp, nil)
 				price := resp.SendStatus.OrderEvents[0].Price
-				message := fmt.Sprintf("Order had been opened.\nInstrument - %s, side - %s, size - %d, price - %.1f\nStoploss/takeprofit is %.1f/%.1f\n", params.Ticker, params.Side, params.Size, price, price*(1+params.Profit/100), price*(1-params.Profit/100))
-				r.EXPECT().WriteToTelegramBot(message).Return()
-				r.EXPECT().WriteOrderToDb(context.Background(), params.Ticker, params.Size, params.Side, price, "open", float32(0), params.Profit).Return(nil)
+				// Для покупки стоп-лосс ниже цены входа
+				opened := domain.Event{Type: domain.EventOrderOpened, Ticker: params.Ticker, Side: params.Side, Size: params.Size, Price: price,
+					StopLoss: price * (1 - params.Profit/100), TakeProfit: price * (1 + params.Profit/100)}
+				r.EXPECT().WriteOrderWithEvent(context.Background(), params.Ticker, params.Size, params.Side, price, "open", float32(0), params.Profit, opened).Return(nil)
 				resp.Result = "error"
 				r.EXPECT().SendOrder(strings.ToLower(params.Ticker), reverseSide(params.Side), params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder").Return(resp, nil)
-				r.EXPECT().WriteToTelegramBot("Order hadn't been placed:  error\n").Return()
+				r.EXPECT().Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: params.Ticker, Side: reverseSide(params.Side), Size: params.Size, Reason: "error"}).Return()
 			},
 		},
 		{
@@ -77,7 +102,7 @@ func TestLogin3(t *testing.T) {
 			mockBehavior: func(r *mock_service.MockrepoInterface, ch chan domain.WsResponse, params domain.Options, resp domain.APIResp) {
 				r.EXPECT().SetWSConnection("wss://demo-futures.kraken.com/ws/v1", params.Ticker).Return(ch, func() {}, nil)
 				r.EXPECT().SendOrder(strings.ToLower(params.Ticker), params.Side, params.Size, "http://demo-futures.kraken.com/derivatives/api/v3/sendorder").Return(resp, nil)
-				r.EXPECT().WriteToTelegramBot("Order hadn't been placed:  error\n").Return()
+				r.EXPECT().Notify(domain.Event{Type: domain.EventOrderRejected, Ticker: params.Ticker, Side: params.Side, Size: params.Size, Reason: "error"}).Return()
 			},
 		},
 		{
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/sirupsen/logrus"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+type blackout struct {
+	days     map[time.Weekday]bool
+	from, to int // минуты от начала дня
+}
+
+type schedule struct {
+	domain.Schedule
+	start, stop *cronExpr
+	loc         *time.Location
+	blackouts   []blackout
+}
+
+// Scheduler включает и выключает робота по расписаниям и не дает ему работать в запрещенные интервалы
+type Scheduler struct {
+	robot     interface{ SetStart(int) }
+	params    func() domain.Options
+	canStart  func() error // проверка запуска: сетка и пары не должны работать
+	log       logrus.FieldLogger
+	schedules map[string]schedule
+	mu        sync.Mutex
+}
+
+func NewScheduler(robot *RobotService, logger logrus.FieldLogger) *Scheduler {
+	return &Scheduler{
+		robot:     robot,
+		params:    robot.GetParams,
+		canStart:  func() error { return robot.CheckStart(domain.TradingRobot) },
+		log:       logger,
+		schedules: make(map[string]schedule),
+	}
+}
+
+// Set добавляет расписание или заменяет расписание с тем же ID
+func (s *Scheduler) Set(sch domain.Schedule) error {
+	parsed, err := parseSchedule(sch)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.schedules[sch.ID] = parsed
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Scheduler) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.schedules[id]
+	delete(s.schedules, id)
+	return ok
+}
+
+func (s *Scheduler) List() []domain.Schedule {
+	s.mu.Lock()
+	list := make([]domain.Schedule, 0, len(s.schedules))
+	for _, sch := range s.schedules {
+		list = append(list, sch.Schedule)
+	}
+	s.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// InBlackout сообщает, попадает ли момент t в запрещенный интервал одного из расписаний
+func (s *Scheduler) InBlackout(t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sch := range s.schedules {
+		if sch.inBlackout(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run проверяет расписания в начале каждой минуты
+func (s *Scheduler) Run() {
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		s.tick(time.Now().Truncate(time.Minute))
+	}
+}
+
+func (s *Scheduler) tick(now time.Time) {
+	started := s.params().Start == 1
+	if s.InBlackout(now) {
+		if started {
+			s.log.Infoln("Blackout window, stopping the robot")
+			s.robot.SetStart(0)
+		}
+		return
+	}
+
+	s.mu.Lock()
+	var start, stop bool
+	for _, sch := range s.schedules {
+		t := now.In(sch.loc)
+		if sch.stop != nil && sch.stop.Match(t) {
+			stop = true
+		}
+		if sch.start != nil && sch.start.Match(t) {
+			start = true
+		}
+	}
+	s.mu.Unlock()
+
+	switch {
+	case stop && started:
+		s.log.Infoln("Stopping the robot by schedule")
+		s.robot.SetStart(0)
+	case start && !stop && !started:
+		if s.params().Ticker == "" {
+			s.log.Warnln("Can't start the robot by schedule: trade params are not set")
+			return
+		}
+		if err := s.canStart(); err != nil {
+			s.log.Warnln("Can't start the robot by schedule: ", err)
+			return
+		}
+		s.log.Infoln("Starting the robot by schedule")
+		s.robot.SetStart(1)
+	}
+}
+
+func parseSchedule(sch domain.Schedule) (schedule, error) {
+	if sch.ID == "" {
+		return schedule{}, errors.New(`'id' must not be empty`)
+	}
+	if sch.Start == "" && sch.Stop == "" && len(sch.Blackouts) == 0 {
+		return schedule{}, errors.New(`schedule must have 'start', 'stop' or 'blackouts'`)
+	}
+	parsed := schedule{Schedule: sch, loc: time.UTC}
+	if sch.Timezone != "" {
+		loc, err := time.LoadLocation(sch.Timezone)
+		if err != nil {
+			return schedule{}, errors.New("unknown timezone: " + sch.Timezone)
+		}
+		parsed.loc = loc
+	}
+	if sch.Start != "" {
+		c, err := parseCron(sch.Start)
+		if err != nil {
+			return schedule{}, err
+		}
+		parsed.start = &c
+	}
+	if sch.Stop != "" {
+		c, err := parseCron(sch.Stop)
+		if err != nil {
+			return schedule{}, err
+		}
+		parsed.stop = &c
+	}
+	for _, b := range sch.Blackouts {
+		pb, err := parseBlackout(b)
+		if err != nil {
+			return schedule{}, err
+		}
+		parsed.blackouts = append(parsed.blackouts, pb)
+	}
+	return parsed, nil
+}
+
+func parseBlackout(b domain.Blackout) (blackout, error) {
+	var res blackout
+	var err error
+	if res.from, err = parseClock(b.From); err != nil {
+		return blackout{}, err
+	}
+	if res.to, err = parseClock(b.To); err != nil {
+		return blackout{}, err
+	}
+	if res.from == res.to {
+		return blackout{}, errors.New("blackout 'from' and 'to' must differ")
+	}
+	if len(b.Days) > 0 {
+		res.days = make(map[time.Weekday]bool, len(b.Days))
+		for _, day := range b.Days {
+			wd, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return blackout{}, errors.New("unknown day of week: " + day)
+			}
+			res.days[wd] = true
+		}
+	}
+	return res, nil
+}
+
+// parseClock переводит HH:MM в минуты от начала дня. 24:00 - конец дня
+func parseClock(s string) (int, error) {
+	var h, m int
+	_, err := fmt.Sscanf(s, "%d:%d", &h, &m)
+	if err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, errors.New("time must be in HH:MM format: " + s)
+	}
+	return h*60 + m, nil
+}
+
+func (sch schedule) inBlackout(t time.Time) bool {
+	t = t.In(sch.loc)
+	minute := t.Hour()*60 + t.Minute()
+	for _, b := range sch.blackouts {
+		if b.from < b.to {
+			if b.onDay(t.Weekday()) && minute >= b.from && minute < b.to {
+				return true
+			}
+			continue
+		}
+		// Интервал через полночь начинается в указанный день и заканчивается на следующий
+		if b.onDay(t.Weekday()) && minute >= b.from {
+			return true
+		}
+		if b.onDay((t.Weekday()+6)%7) && minute < b.to {
+			return true
+		}
+	}
+	return false
+}
+
+func (b blackout) onDay(day time.Weekday) bool {
+	return b.days == nil || b.days[day]
+}
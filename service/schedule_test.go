@@ -0,0 +1,131 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStarter struct {
+	params domain.Options
+}
+
+func (f *fakeStarter) SetStart(start int) {
+	f.params.Start = start
+}
+
+func TestParseCron(t *testing.T) {
+	// Test Table
+	type Test struct {
+		Name   string
+		Expr   string
+		Time   time.Time
+		Expect bool
+	}
+	monday := time.Date(2021, 12, 6, 9, 30, 0, 0, time.UTC)
+	tests := [...]Test{
+		{"Every minute", "* * * * *", monday, true},
+		{"Exact time", "30 9 * * *", monday, true},
+		{"Other minute", "31 9 * * *", monday, false},
+		{"Step", "*/15 * * * *", monday, true},
+		{"Weekdays", "30 9 * * 1-5", monday, true},
+		{"Weekend", "30 9 * * 6,0", monday, false},
+		{"Sunday as 7", "30 9 * * 7", monday.AddDate(0, 0, 6), true},
+		{"Day of month or weekday", "30 9 1 * 1", monday, true},
+		{"Month", "30 9 * 1 *", monday, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c, err := parseCron(test.Expr)
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expect, c.Match(test.Time))
+		})
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := parseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestSchedulerTick(t *testing.T) {
+	robot := &fakeStarter{params: domain.Options{Ticker: "PI_XBTUSD"}}
+	var startErr error
+	s := &Scheduler{
+		robot:     robot,
+		params:    func() domain.Options { return robot.params },
+		canStart:  func() error { return startErr },
+		log:       log.New(),
+		schedules: make(map[string]schedule),
+	}
+	err := s.Set(domain.Schedule{
+		ID:       "work",
+		Start:    "0 9 * * 1-5",
+		Stop:     "0 18 * * 1-5",
+		Timezone: "Europe/Moscow",
+		Blackouts: []domain.Blackout{
+			{From: "11:55", To: "12:05"},
+			{Days: []string{"sat", "sun"}, From: "00:00", To: "24:00"},
+		},
+	})
+	assert.NoError(t, err)
+
+	msk := time.FixedZone("MSK", 3*60*60)
+	s.tick(time.Date(2021, 12, 6, 9, 0, 0, 0, msk))
+	assert.Equal(t, 1, robot.params.Start)
+
+	// Пока работает сетка или пары, расписание робота не запускает
+	robot.params.Start = 0
+	startErr = domain.ErrGridActive
+	s.tick(time.Date(2021, 12, 6, 9, 0, 0, 0, msk))
+	assert.Equal(t, 0, robot.params.Start)
+	startErr = nil
+
+	// Время в другом часовом поясе не совпадает с расписанием
+	robot.params.Start = 0
+	s.tick(time.Date(2021, 12, 6, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, 0, robot.params.Start)
+
+	robot.params.Start = 1
+	s.tick(time.Date(2021, 12, 6, 12, 0, 0, 0, msk))
+	assert.Equal(t, 0, robot.params.Start)
+	assert.True(t, s.InBlackout(time.Date(2021, 12, 11, 15, 0, 0, 0, msk)))
+	assert.False(t, s.InBlackout(time.Date(2021, 12, 10, 15, 0, 0, 0, msk)))
+
+	robot.params.Start = 1
+	s.tick(time.Date(2021, 12, 6, 18, 0, 0, 0, msk))
+	assert.Equal(t, 0, robot.params.Start)
+
+	assert.Len(t, s.List(), 1)
+	assert.True(t, s.Delete("work"))
+	assert.False(t, s.Delete("work"))
+}
+
+func TestBlackoutOverMidnight(t *testing.T) {
+	sch, err := parseSchedule(domain.Schedule{ID: "night", Blackouts: []domain.Blackout{{Days: []string{"fri"}, From: "23:00", To: "01:00"}}})
+	assert.NoError(t, err)
+	friday := time.Date(2021, 12, 10, 23, 30, 0, 0, time.UTC)
+	assert.True(t, sch.inBlackout(friday))
+	assert.True(t, sch.inBlackout(friday.Add(time.Hour)))
+	assert.False(t, sch.inBlackout(friday.Add(2*time.Hour)))
+	assert.False(t, sch.inBlackout(friday.Add(-24*time.Hour)))
+}
+
+func TestGetStartInBlackout(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	// В запрещенный интервал робот не подключается к бирже и сбрасывает сигнал к старту
+	repo := mock_service.NewMockrepoInterface(c)
+	serv := NewRobotService(repo, log.New())
+	err := serv.SetSchedule(domain.Schedule{ID: "all-day", Start: "0 9 * * *", Blackouts: []domain.Blackout{{From: "00:00", To: "24:00"}}})
+	assert.NoError(t, err)
+	serv.SetParams(1, 1, 1, "PI_XBTUSD", "buy")
+	assert.Eventually(t, func() bool { return serv.GetParams().Start == 0 }, time.Second, 10*time.Millisecond)
+}
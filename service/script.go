@@ -0,0 +1,321 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/sirupsen/logrus"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+const (
+	scriptMaxSteps = 1000000
+	scriptTimeout  = 200 * time.Millisecond
+	// Рост кучи во время выполнения скрипта, после которого он прерывается. Отдельное выделение памяти
+	// больше 1 ГБ (например, "x" * 2000000000) Starlark отклоняет сам
+	scriptMaxMemory   = 64 << 20
+	scriptMemoryCheck = time.Millisecond
+)
+
+var errNoScript = errors.New("script is not found")
+
+type script struct {
+	domain.Script
+	signal *starlark.Function
+}
+
+// Scripts хранит загруженные стратегии на Starlark и выполняет их с ограничением
+// на число шагов интерпретатора, время выполнения и рост памяти
+type Scripts struct {
+	log       logrus.FieldLogger
+	scripts   map[string]script
+	maxSteps  uint64
+	timeout   time.Duration
+	maxMemory uint64
+	mu        sync.RWMutex
+}
+
+func NewScripts(logger logrus.FieldLogger) *Scripts {
+	return &Scripts{
+		log:       logger,
+		scripts:   make(map[string]script),
+		maxSteps:  scriptMaxSteps,
+		timeout:   scriptTimeout,
+		maxMemory: scriptMaxMemory,
+	}
+}
+
+// Set компилирует скрипт и добавляет его или заменяет скрипт с тем же именем
+func (s *Scripts) Set(sc domain.Script) error {
+	if sc.Name == "" {
+		return errors.New(`'name' must not be empty`)
+	}
+	thread := s.thread(sc.Name)
+	release := s.guard(thread)
+	globals, err := starlark.ExecFile(thread, sc.Name+".star", sc.Source, scriptBuiltins)
+	release()
+	if err != nil {
+		return err
+	}
+	fn, ok := globals["signal"].(*starlark.Function)
+	if !ok || fn.NumParams() != 1 {
+		return errors.New("script must define function signal(ctx)")
+	}
+	globals.Freeze()
+
+	s.mu.Lock()
+	s.scripts[sc.Name] = script{Script: sc, signal: fn}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Scripts) Delete(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.scripts[name]
+	delete(s.scripts, name)
+	return ok
+}
+
+func (s *Scripts) List() []domain.Script {
+	s.mu.RLock()
+	list := make([]domain.Script, 0, len(s.scripts))
+	for _, sc := range s.scripts {
+		list = append(list, sc.Script)
+	}
+	s.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Run вызывает signal(ctx) скрипта name. pos равен nil, если позиция не открыта
+func (s *Scripts) Run(name string, candles []domain.Candle, tick domain.WsResponse, pos *position) (string, error) {
+	s.mu.RLock()
+	sc, ok := s.scripts[name]
+	s.mu.RUnlock()
+	if !ok {
+		return "", errNoScript
+	}
+
+	thread := s.thread(name)
+	defer s.guard(thread)()
+	res, err := starlark.Call(thread, sc.signal, starlark.Tuple{scriptContext(candles, tick, pos)}, nil)
+	if err != nil {
+		return "", err
+	}
+	if res == starlark.None {
+		return "hold", nil
+	}
+	decision, ok := starlark.AsString(res)
+	if !ok {
+		return "", fmt.Errorf("signal must return a string, got %s", res.Type())
+	}
+	switch decision {
+	case "buy", "sell", "close", "hold":
+		return decision, nil
+	}
+	return "", fmt.Errorf("signal must return 'buy', 'sell', 'close' or 'hold', got %q", decision)
+}
+
+// thread - поток интерпретатора без load и с выводом print в лог
+func (s *Scripts) thread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			s.log.Debugf("script %s: %s\n", name, msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(s.maxSteps)
+	return thread
+}
+
+// guard прерывает поток по истечении timeout или когда куча выросла больше чем на maxMemory.
+// Куча общая для процесса, поэтому ограничение с запасом. Возвращает функцию, которая снимает ограничения
+func (s *Scripts) guard(thread *starlark.Thread) func() {
+	timer := time.AfterFunc(s.timeout, func() { thread.Cancel("timeout") })
+	done := make(chan struct{})
+	limit := heapBytes() + s.maxMemory
+	go func() {
+		ticker := time.NewTicker(scriptMemoryCheck)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if heapBytes() > limit {
+					thread.Cancel("memory limit exceeded")
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		timer.Stop()
+		close(done)
+	}
+}
+
+// heapBytes - память, занятая объектами в куче, включая еще не собранные
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
+
+// scriptContext собирает аргумент ctx: свечи, цены закрытия, текущие bid/ask и позицию
+func scriptContext(candles []domain.Candle, tick domain.WsResponse, pos *position) starlark.Value {
+	list := make([]starlark.Value, len(candles))
+	closes := make([]starlark.Value, len(candles))
+	for i, c := range candles {
+		list[i] = starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"time":  starlark.MakeInt64(c.Time.Unix()),
+			"open":  starlark.Float(c.Open),
+			"high":  starlark.Float(c.High),
+			"low":   starlark.Float(c.Low),
+			"close": starlark.Float(c.Close),
+		})
+		closes[i] = starlark.Float(c.Close)
+	}
+	var posValue starlark.Value = starlark.None
+	if pos != nil {
+		posValue = starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"side":  starlark.String(pos.side),
+			"size":  starlark.MakeInt(pos.size()),
+			"entry": starlark.Float(pos.avgPrice()),
+			"pnl":   starlark.Float(pos.pnl(midPrice(tick))),
+		})
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"candles":  starlark.NewList(list),
+		"closes":   starlark.NewList(closes),
+		"bid":      starlark.Float(tick.Bid),
+		"ask":      starlark.Float(tick.Ask),
+		"position": posValue,
+	})
+}
+
+// Индикаторы, доступные скриптам. Все функции принимают список чисел и период n
+// и считают значение по последним n элементам
+var scriptBuiltins = starlark.StringDict{
+	"sma":     starlark.NewBuiltin("sma", indicator(sma)),
+	"ema":     starlark.NewBuiltin("ema", indicator(ema)),
+	"stddev":  starlark.NewBuiltin("stddev", indicator(stddev)),
+	"highest": starlark.NewBuiltin("highest", indicator(highest)),
+	"lowest":  starlark.NewBuiltin("lowest", indicator(lowest)),
+}
+
+func indicator(f func([]float64) float64) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var values *starlark.List
+		var n int
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &values, &n); err != nil {
+			return nil, err
+		}
+		if n < 1 || n > values.Len() {
+			return nil, fmt.Errorf("%s: period %d is out of range [1, %d]", b.Name(), n, values.Len())
+		}
+		window := make([]float64, n)
+		for i := range window {
+			v, ok := starlark.AsFloat(values.Index(values.Len() - n + i))
+			if !ok {
+				return nil, fmt.Errorf("%s: values must be numbers", b.Name())
+			}
+			window[i] = v
+		}
+		return starlark.Float(f(window)), nil
+	}
+}
+
+func sma(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func ema(values []float64) float64 {
+	k := 2 / float64(len(values)+1)
+	res := values[0]
+	for _, v := range values[1:] {
+		res = v*k + res*(1-k)
+	}
+	return res
+}
+
+func stddev(values []float64) float64 {
+	mean := sma(values)
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
+
+func highest(values []float64) float64 {
+	res := values[0]
+	for _, v := range values[1:] {
+		res = math.Max(res, v)
+	}
+	return res
+}
+
+func lowest(values []float64) float64 {
+	res := values[0]
+	for _, v := range values[1:] {
+		res = math.Min(res, v)
+	}
+	return res
+}
+
+// scriptStrategy - стратегия, которая решает о входе в рынок вызовом скрипта
+type scriptStrategy struct {
+	scripts *Scripts
+	name    string
+	log     logrus.FieldLogger
+}
+
+func (s scriptStrategy) signal(candles []domain.Candle) string {
+	var tick domain.WsResponse
+	if len(candles) > 0 {
+		last := candles[len(candles)-1].Close
+		tick = domain.WsResponse{Bid: last, Ask: last}
+	}
+	decision, err := s.scripts.Run(s.name, candles, tick, nil)
+	if err != nil {
+		s.log.Errorln("Script error: ", err)
+		return ""
+	}
+	if decision == "buy" || decision == "sell" {
+		return decision
+	}
+	return ""
+}
+
+func (r *RobotService) SetScript(sc domain.Script) error {
+	return r.scripts.Set(sc)
+}
+
+func (r *RobotService) DeleteScript(name string) bool {
+	return r.scripts.Delete(name)
+}
+
+func (r *RobotService) GetScripts() []domain.Script {
+	return r.scripts.List()
+}
+
+// strategy возвращает стратегию входа по параметрам робота или nil для стратегии по средней цене тиков
+func (r *RobotService) strategy(params domain.Strategy) strategy {
+	if params.Name == "script" {
+		return scriptStrategy{scripts: r.scripts, name: params.Script, log: r.log}
+	}
+	return newStrategy(params)
+}
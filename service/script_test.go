@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const crossScript = `
+def signal(ctx):
+    if ctx.position != None:
+        if ctx.position.pnl > 0:
+            return "close"
+        return "hold"
+    if len(ctx.closes) < 3:
+        return "hold"
+    if ctx.closes[-1] > sma(ctx.closes, 3) and ctx.closes[-1] >= highest(ctx.closes, 3):
+        return "buy"
+    if ctx.closes[-1] < lowest(ctx.closes[:-1], 2):
+        return "sell"
+`
+
+func TestScripts(t *testing.T) {
+	scripts := NewScripts(log.New())
+
+	tests := [...]struct {
+		Name   string
+		Source string
+		Error  bool
+	}{
+		{"Valid script", crossScript, false},
+		{"Syntax error", "def signal(ctx):\n  return (", true},
+		{"No signal function", "x = 1", true},
+		{"Wrong signature", "def signal():\n  return 'hold'", true},
+		{"Load is not allowed", "load('os.star', 'system')\ndef signal(ctx):\n  return 'hold'", true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := scripts.Set(domain.Script{Name: "test", Source: test.Source})
+			assert.Equal(t, test.Error, err != nil)
+		})
+	}
+	assert.Error(t, scripts.Set(domain.Script{Source: crossScript}))
+	assert.NoError(t, scripts.Set(domain.Script{Name: "cross", Source: crossScript}))
+	assert.Len(t, scripts.List(), 2)
+
+	decision, err := scripts.Run("cross", series(100, 101), domain.WsResponse{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hold", decision)
+	decision, _ = scripts.Run("cross", series(100, 101, 103), domain.WsResponse{}, nil)
+	assert.Equal(t, "buy", decision)
+	decision, _ = scripts.Run("cross", series(100, 101, 99), domain.WsResponse{}, nil)
+	assert.Equal(t, "sell", decision)
+
+	pos := newPosition(domain.Instrument{}, "buy")
+	pos.add(1, 100)
+	decision, _ = scripts.Run("cross", series(100), domain.WsResponse{Bid: 105, Ask: 105}, pos)
+	assert.Equal(t, "close", decision)
+	decision, _ = scripts.Run("cross", series(100), domain.WsResponse{Bid: 95, Ask: 95}, pos)
+	assert.Equal(t, "hold", decision)
+
+	_, err = scripts.Run("unknown", nil, domain.WsResponse{}, nil)
+	assert.Equal(t, errNoScript, err)
+	assert.True(t, scripts.Delete("cross"))
+	assert.False(t, scripts.Delete("cross"))
+}
+
+func TestScriptLimits(t *testing.T) {
+	scripts := NewScripts(log.New())
+	scripts.maxSteps = 10000
+
+	// Бесконечных циклов в Starlark нет, но длинный цикл упирается в ограничение шагов
+	assert.NoError(t, scripts.Set(domain.Script{Name: "loop", Source: "def signal(ctx):\n  for i in range(100000000):\n    pass\n  return 'buy'"}))
+	_, err := scripts.Run("loop", nil, domain.WsResponse{}, nil)
+	assert.ErrorContains(t, err, "too many steps")
+	err = scripts.Set(domain.Script{Name: "init", Source: "n = len([i for i in range(100000000)])\ndef signal(ctx):\n  return 'buy'"})
+	assert.ErrorContains(t, err, "too many steps")
+
+	// Без ограничения шагов тот же цикл прерывается по времени
+	scripts.maxSteps = 0
+	scripts.timeout = 20 * time.Millisecond
+	_, err = scripts.Run("loop", nil, domain.WsResponse{}, nil)
+	assert.ErrorContains(t, err, "timeout")
+
+	// Скрипт, который копит данные, прерывается по росту памяти раньше, чем по времени
+	scripts.timeout = time.Minute
+	scripts.maxMemory = 8 << 20
+	assert.NoError(t, scripts.Set(domain.Script{Name: "memory", Source: "def signal(ctx):\n  data = []\n  for i in range(100000000):\n    data.append('x' * 100)\n  return 'buy'"}))
+	_, err = scripts.Run("memory", nil, domain.WsResponse{}, nil)
+	assert.ErrorContains(t, err, "memory limit exceeded")
+	assert.NoError(t, scripts.Set(domain.Script{Name: "repeat", Source: "def signal(ctx):\n  return 'x' * 2000000000"}))
+	_, err = scripts.Run("repeat", nil, domain.WsResponse{}, nil)
+	assert.ErrorContains(t, err, "excessive repeat")
+	scripts.maxSteps = 10000
+
+	assert.NoError(t, scripts.Set(domain.Script{Name: "bad", Source: "def signal(ctx):\n  return 'short'"}))
+	_, err = scripts.Run("bad", nil, domain.WsResponse{}, nil)
+	assert.Error(t, err)
+
+	assert.NoError(t, scripts.Set(domain.Script{Name: "period", Source: "def signal(ctx):\n  return sma(ctx.closes, 10)"}))
+	_, err = scripts.Run("period", series(1, 2), domain.WsResponse{}, nil)
+	assert.Error(t, err)
+}
+
+func TestIndicators(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	assert.Equal(t, 5.0, sma(values))
+	assert.Equal(t, 2.0, stddev(values))
+	assert.Equal(t, 9.0, highest(values))
+	assert.Equal(t, 2.0, lowest(values))
+	assert.InDelta(t, 5.52, ema(values), 0.01)
+}
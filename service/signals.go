@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// signalTTL - сколько ID принятого сигнала помнится в памяти. Позже повтор отклоняет уникальный индекс в базе,
+// а expiry сигнала не может быть дальше этого срока
+const signalTTL = 24 * time.Hour
+
+var ErrDuplicateSignal = domain.ErrDuplicateSignal
+
+// Signals помнит ID принятых сигналов, чтобы повторно присланный сигнал не исполнялся дважды
+type Signals struct {
+	seen  map[string]time.Time
+	now   func() time.Time
+	saved *signalSaved // настройки робота, которые сигнал заменил на время своей сделки
+	mu    sync.Mutex
+}
+
+// signalSaved - настройки оператора, которые восстанавливаются после сделки по сигналу
+type signalSaved struct {
+	ticker   string
+	side     string
+	size     int
+	sizing   domain.Sizing
+	exits    domain.Exits
+	strategy domain.Strategy
+	loop     domain.Loop
+	dca      domain.DCA
+}
+
+func NewSignals() *Signals {
+	return &Signals{
+		seen: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+// received сообщает, принимался ли сигнал с этим ID. Вызывается под mu
+func (s *Signals) received(id string) bool {
+	now := s.now()
+	for seenID, ts := range s.seen {
+		if now.Sub(ts) > signalTTL {
+			delete(s.seen, seenID)
+		}
+	}
+	_, ok := s.seen[id]
+	return ok
+}
+
+// HandleSignal проверяет сигнал и запускает робота по нему или закрывает позицию.
+// Каждый сигнал, принятый или отклоненный, записывается в базу. ID запоминается, только если сигнал принят,
+// поэтому отклоненный сигнал можно прислать повторно
+func (r *RobotService) HandleSignal(sig domain.Signal) error {
+	r.signals.mu.Lock()
+	defer r.signals.mu.Unlock()
+
+	err := r.checkSignal(sig)
+	if err == nil {
+		err = r.repo.WriteSignalToDb(context.Background(), sig, "accepted", "")
+		if err != nil && !errors.Is(err, ErrDuplicateSignal) {
+			r.log.Errorln("Can't write to DB: ", err)
+			return errors.New("can't save signal, try again")
+		}
+	}
+	if err != nil {
+		dbErr := r.repo.WriteSignalToDb(context.Background(), sig, "rejected", err.Error())
+		if dbErr != nil {
+			r.log.Errorln("Can't write to DB: ", dbErr)
+		}
+		r.log.Infoln("Signal rejected: ", err)
+		return err
+	}
+	r.signals.seen[sig.ID] = r.signals.now()
+
+	if sig.Side == "close" {
+		r.SetStart(0)
+		r.repo.Notify(domain.Event{Type: domain.EventSignal, SignalID: sig.ID, Ticker: sig.Ticker, Side: sig.Side})
+		return nil
+	}
+	r.mu.Lock()
+	r.signals.saved = &signalSaved{
+		ticker:   r.params.Ticker,
+		side:     r.params.Side,
+		size:     r.params.Size,
+		sizing:   r.params.Sizing,
+		exits:    r.params.Exits,
+		strategy: r.params.Strategy,
+		loop:     r.params.Loop,
+		dca:      r.params.DCA,
+	}
+	r.params.Ticker = sig.Ticker
+	r.params.Side = sig.Side
+	if sig.Size > 0 {
+		r.params.Size = sig.Size
+		r.params.Sizing = domain.Sizing{}
+	}
+	r.params.Exits = domain.Exits{StopPrice: sig.Stop, TargetPrice: sig.Target}
+	// Сигнал сам задает момент и направление входа, поэтому на время его сделки стратегия и цикл отключаются.
+	// Усреднение тоже отключается, иначе стоп сигнала не сработает, пока не исполнятся все страховочные ордера
+	r.params.Strategy = domain.Strategy{}
+	r.params.Loop.Enabled = false
+	r.params.DCA = domain.DCA{}
+	r.params.Start = 1
+	r.mu.Unlock()
+	r.repo.Notify(domain.Event{Type: domain.EventSignal, SignalID: sig.ID, Ticker: sig.Ticker, Side: sig.Side, Size: sig.Size})
+	return nil
+}
+
+// restoreAfterSignal возвращает настройки, которые сигнал заменил, когда его сделка закончилась
+func (r *RobotService) restoreAfterSignal() {
+	r.signals.mu.Lock()
+	defer r.signals.mu.Unlock()
+	saved := r.signals.saved
+	if saved == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Сигнал мог быть принят уже после того, как цикл торговли увидел остановку
+	if r.params.Start == 1 {
+		return
+	}
+	r.signals.saved = nil
+	r.params.Ticker = saved.ticker
+	r.params.Side = saved.side
+	r.params.Size = saved.size
+	r.params.Sizing = saved.sizing
+	r.params.Exits = saved.exits
+	r.params.Strategy = saved.strategy
+	r.params.Loop = saved.loop
+	r.params.DCA = saved.dca
+}
+
+func (r *RobotService) checkSignal(sig domain.Signal) error {
+	if sig.ID == "" {
+		return errors.New(`'id' must not be empty`)
+	}
+	if r.signals.received(sig.ID) {
+		return ErrDuplicateSignal
+	}
+	now := r.signals.now()
+	switch {
+	case sig.Expiry.IsZero():
+		return errors.New(`'expiry' must be set`)
+	case now.After(sig.Expiry):
+		return errors.New("signal is expired")
+	case sig.Expiry.Sub(now) > signalTTL:
+		return fmt.Errorf(`'expiry' must be within %v`, signalTTL)
+	}
+	params := r.GetParams()
+	switch sig.Side {
+	case "close":
+		if params.Start != 1 || params.Ticker != sig.Ticker {
+			return errors.New("robot is not trading " + sig.Ticker)
+		}
+		return nil
+	case "buy", "sell":
+	default:
+		return errors.New(`'side' must be 'buy', 'sell' or 'close'`)
+	}
+
+	if r.risk.Killed() {
+		return ErrKillSwitch
+	}
+	if params.Start == 1 {
+		return errors.New("robot is already trading")
+	}
+	if err := r.CheckStart(domain.TradingRobot); err != nil {
+		return err
+	}
+	inst, ok := r.instruments.Get(sig.Ticker)
+	if !ok || !inst.Tradeable {
+		return errors.New("unknown or not tradeable instrument " + sig.Ticker)
+	}
+	switch {
+	case sig.Size < 0:
+		return errors.New(`'size' must not be negative`)
+	case sig.Size > 0 && float32(sig.Size) < inst.MinOrderSize:
+		return fmt.Errorf(`'size' must be at least %v for %s`, inst.MinOrderSize, sig.Ticker)
+	case sig.Size == 0 && params.SizeMode == "" && params.Size < 1:
+		return errors.New(`'size' must be set, the robot has no default size`)
+	}
+	if sig.Stop < 0 || sig.Target < 0 {
+		return errors.New(`'stop' and 'target' must not be negative`)
+	}
+	if sig.Stop > 0 && sig.Target > 0 &&
+		((sig.Side == "buy" && sig.Stop >= sig.Target) || (sig.Side == "sell" && sig.Stop <= sig.Target)) {
+		return errors.New(`'stop' must be on the loss side of 'target'`)
+	}
+	// Незаданная граница считается от цены входа по "profit"
+	if (sig.Stop == 0 || sig.Target == 0) && params.Profit <= 0 {
+		return errors.New(`'stop' and 'target' must be set when 'profit' is not set`)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// signalRobot - робот без цикла торговли, чтобы проверять только прием сигналов
+func signalRobot(repo *mock_service.MockrepoInterface, params domain.Options) *RobotService {
+	logger := log.New()
+	robot := &RobotService{
+		repo:        repo,
+		log:         logger,
+		params:      params,
+		instruments: NewInstruments(repo, logger),
+		risk:        NewRiskManager(),
+		signals:     NewSignals(),
+	}
+	robot.schedules = NewScheduler(robot, logger)
+	robot.grid = NewGrid(repo, logger, robot.risk, robot.instruments, func() bool { return true })
+	robot.pairs = NewPairs(repo, logger, robot.risk, robot.instruments)
+	return robot
+}
+
+func TestHandleSignal(t *testing.T) {
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	exp := now.Add(time.Minute)
+	// Test Table
+	type Test struct {
+		Name         string
+		Params       domain.Options
+		Signal       domain.Signal
+		ExpectErr    string
+		ExpectParams domain.Options
+	}
+	tests := [...]Test{
+		{"Buy with exits", domain.Options{Size: 1, Strategy: domain.Strategy{Name: "bollinger"}}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Size: 3, Stop: 90, Target: 110, Expiry: exp},
+			"", domain.Options{Start: 1, Size: 3, Ticker: "PI_XBTUSD", Side: "buy", Exits: domain.Exits{StopPrice: 90, TargetPrice: 110}}},
+		{"Sell with default size and profit", domain.Options{Size: 2, Profit: 1}, domain.Signal{ID: "1", Ticker: "PI_ETHUSD", Side: "sell", Expiry: exp},
+			"", domain.Options{Start: 1, Size: 2, Profit: 1, Ticker: "PI_ETHUSD", Side: "sell"}},
+		{"Close", domain.Options{Start: 1, Size: 1, Ticker: "PI_XBTUSD", Side: "buy"}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "close", Expiry: exp},
+			"", domain.Options{Size: 1, Ticker: "PI_XBTUSD", Side: "buy"}},
+		{"Close other ticker", domain.Options{Start: 1, Size: 1, Ticker: "PI_XBTUSD"}, domain.Signal{ID: "1", Ticker: "PI_ETHUSD", Side: "close", Expiry: exp},
+			"robot is not trading PI_ETHUSD", domain.Options{Start: 1, Size: 1, Ticker: "PI_XBTUSD"}},
+		{"Already trading", domain.Options{Start: 1, Size: 1, Ticker: "PI_XBTUSD"}, domain.Signal{ID: "1", Ticker: "PI_ETHUSD", Side: "buy", Stop: 90, Target: 110, Expiry: exp},
+			"robot is already trading", domain.Options{Start: 1, Size: 1, Ticker: "PI_XBTUSD"}},
+		{"Expired", domain.Options{Size: 1}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Stop: 90, Target: 110, Expiry: now.Add(-time.Second)},
+			"signal is expired", domain.Options{Size: 1}},
+		{"No id", domain.Options{Size: 1}, domain.Signal{Ticker: "PI_XBTUSD", Side: "buy"},
+			"'id' must not be empty", domain.Options{Size: 1}},
+		{"Wrong side", domain.Options{Size: 1}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "long", Expiry: exp},
+			"'side' must be 'buy', 'sell' or 'close'", domain.Options{Size: 1}},
+		{"Unknown ticker", domain.Options{Size: 1}, domain.Signal{ID: "1", Ticker: "PI_DOGEUSD", Side: "buy", Stop: 90, Target: 110, Expiry: exp},
+			"unknown or not tradeable instrument PI_DOGEUSD", domain.Options{Size: 1}},
+		{"Stop above target", domain.Options{Size: 1}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Stop: 110, Target: 90, Expiry: exp},
+			"'stop' must be on the loss side of 'target'", domain.Options{Size: 1}},
+		{"No exits and no profit", domain.Options{Size: 1}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Stop: 90, Expiry: exp},
+			"'stop' and 'target' must be set when 'profit' is not set", domain.Options{Size: 1}},
+		{"No size", domain.Options{Profit: 1}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Expiry: exp},
+			"'size' must be set, the robot has no default size", domain.Options{Profit: 1}},
+		{"No expiry", domain.Options{Size: 1}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Stop: 90, Target: 110},
+			"'expiry' must be set", domain.Options{Size: 1}},
+		{"Expiry too far", domain.Options{Size: 1}, domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Stop: 90, Target: 110, Expiry: now.Add(48 * time.Hour)},
+			"'expiry' must be within 24h0m0s", domain.Options{Size: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			repo := mock_service.NewMockrepoInterface(c)
+			robot := signalRobot(repo, test.Params)
+			robot.signals.now = func() time.Time { return now }
+
+			status, reason := "accepted", ""
+			if test.ExpectErr != "" {
+				status, reason = "rejected", test.ExpectErr
+			} else {
+				repo.EXPECT().Notify(gomock.Any())
+			}
+			repo.EXPECT().WriteSignalToDb(gomock.Any(), test.Signal, status, reason).Return(nil)
+
+			err := robot.HandleSignal(test.Signal)
+			if test.ExpectErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.ExpectErr)
+			}
+			assert.Equal(t, test.ExpectParams, robot.GetParams())
+		})
+	}
+}
+
+func TestSignalDedup(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	robot := signalRobot(repo, domain.Options{Size: 1, Profit: 1})
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	robot.signals.now = func() time.Time { return now }
+	repo.EXPECT().Notify(gomock.Any()).AnyTimes()
+
+	// Отклоненный сигнал не запоминается: после исправления причины тот же ID принимается
+	sig := domain.Signal{ID: "42", Ticker: "PI_XBTUSD", Side: "buy", Expiry: now.Add(time.Minute)}
+	robot.SetStart(1)
+	repo.EXPECT().WriteSignalToDb(gomock.Any(), sig, "rejected", "robot is already trading").Return(nil)
+	assert.EqualError(t, robot.HandleSignal(sig), "robot is already trading")
+	robot.SetStart(0)
+	repo.EXPECT().WriteSignalToDb(gomock.Any(), sig, "accepted", "").Return(nil)
+	assert.NoError(t, robot.HandleSignal(sig))
+
+	robot.SetStart(0)
+	repo.EXPECT().WriteSignalToDb(gomock.Any(), sig, "rejected", ErrDuplicateSignal.Error()).Return(nil)
+	assert.Equal(t, ErrDuplicateSignal, robot.HandleSignal(sig))
+
+	// После перезапуска ID нет в памяти, повтор отклоняет уникальный индекс в базе
+	robot.signals = NewSignals()
+	robot.signals.now = func() time.Time { return now }
+	gomock.InOrder(
+		repo.EXPECT().WriteSignalToDb(gomock.Any(), sig, "accepted", "").Return(ErrDuplicateSignal),
+		repo.EXPECT().WriteSignalToDb(gomock.Any(), sig, "rejected", ErrDuplicateSignal.Error()).Return(nil),
+	)
+	assert.Equal(t, ErrDuplicateSignal, robot.HandleSignal(sig))
+	assert.Equal(t, 0, robot.GetParams().Start)
+}
+
+func TestSignalRestoresParams(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+	params := domain.Options{
+		Size: 1, Profit: 1, Ticker: "PI_ETHUSD", Side: "sell",
+		Exits:    domain.Exits{StopPrice: 3000},
+		Strategy: domain.Strategy{Name: "bollinger", Period: 20},
+		Loop:     domain.Loop{Enabled: true, Cycles: 3},
+		DCA:      domain.DCA{SafetyOrders: 2, Deviation: 1, SizeMultiplier: 2},
+	}
+	robot := signalRobot(repo, params)
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	robot.signals.now = func() time.Time { return now }
+	repo.EXPECT().Notify(gomock.Any())
+	repo.EXPECT().WriteSignalToDb(gomock.Any(), gomock.Any(), "accepted", "").Return(nil)
+
+	assert.NoError(t, robot.HandleSignal(domain.Signal{ID: "1", Ticker: "PI_XBTUSD", Side: "buy", Size: 5, Stop: 90, Target: 110, Expiry: now.Add(time.Minute)}))
+	// Пока сделка по сигналу идет, настройки оператора не возвращаются
+	robot.restoreAfterSignal()
+	got := robot.GetParams()
+	assert.Equal(t, domain.Strategy{}, got.Strategy)
+	assert.False(t, got.Loop.Enabled)
+	assert.Equal(t, domain.DCA{}, got.DCA)
+	assert.Equal(t, 5, got.Size)
+	assert.Equal(t, "PI_XBTUSD", got.Ticker)
+
+	robot.SetStart(0)
+	robot.restoreAfterSignal()
+	assert.Equal(t, params, robot.GetParams())
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+const (
+	accountsAddr = "https://demo-futures.kraken.com/derivatives/api/v3/accounts"
+	// defaultAtrResolution - свечи для ATR, если "atr_resolution" не задан
+	defaultAtrResolution = "1h"
+)
+
+// resolutionDurations - длительность свечей графиков Kraken
+var resolutionDurations = map[string]time.Duration{
+	"1m": time.Minute, "5m": 5 * time.Minute, "15m": 15 * time.Minute, "30m": 30 * time.Minute,
+	"1h": time.Hour, "4h": 4 * time.Hour, "12h": 12 * time.Hour, "1d": 24 * time.Hour, "1w": 7 * 24 * time.Hour,
+}
+
+// positionSize считает количество контрактов для сделки в зависимости от режима расчета размера.
+// Для режимов, которым нужна текущая цена, цены читаются из priceChan
+func (r *RobotService) positionSize(params domain.Options, inst domain.Instrument, priceChan chan domain.WsResponse) (int, error) {
+	if params.SizeMode == "" || params.SizeMode == "contracts" {
+		return roundSize(inst, params.Size), nil
+	}
+
+	price := midPrice(<-priceChan)
+	var contracts float64
+	switch params.SizeMode {
+	case "notional":
+		contracts = notionalToContracts(inst, params.Notional, price)
+	case "equity":
+		margin, err := r.repo.GetAvailableMargin(accountsAddr)
+		if err != nil {
+			return 0, err
+		}
+		contracts = notionalToContracts(inst, margin*params.EquityPercent/100, price)
+	case "risk":
+		stopDistance := price * params.Profit / 100
+		if params.AtrPeriod > 0 {
+			var err error
+			if stopDistance, err = r.historyATR(params); err != nil {
+				return 0, err
+			}
+		}
+		// Заданная цена stop-loss точнее оценок по "profit" и ATR
+		if params.StopPrice > 0 {
+			stopDistance = float32(math.Abs(float64(price - params.StopPrice)))
+		}
+		contracts = riskToContracts(inst, params.Risk, stopDistance, price)
+	default:
+		return 0, errors.New("unknown size mode: " + params.SizeMode)
+	}
+
+	size := roundSize(inst, int(math.Floor(contracts)))
+	if size < 1 || float32(size) < inst.MinOrderSize {
+		return 0, errors.New("calculated size is less than minimal order size")
+	}
+	return size, nil
+}
+
+func midPrice(tick domain.WsResponse) float32 {
+	return (tick.Ask + tick.Bid) / 2
+}
+
+func contractSize(inst domain.Instrument) float32 {
+	if inst.ContractSize == 0 {
+		return 1
+	}
+	return inst.ContractSize
+}
+
+// notionalToContracts переводит сумму в долларах в количество контрактов
+func notionalToContracts(inst domain.Instrument, notional, price float32) float64 {
+	if inst.Type == "futures_inverse" {
+		return float64(notional / contractSize(inst))
+	}
+	if price <= 0 {
+		return 0
+	}
+	return float64(notional / (price * contractSize(inst)))
+}
+
+// riskToContracts подбирает размер так, чтобы убыток при проходе цены на stopDistance был равен risk
+func riskToContracts(inst domain.Instrument, risk, stopDistance, price float32) float64 {
+	if stopDistance <= 0 {
+		return 0
+	}
+	lossPerContract := contractSize(inst) * stopDistance
+	if inst.Type == "futures_inverse" {
+		if price <= 0 {
+			return 0
+		}
+		lossPerContract /= price
+	}
+	return float64(risk / lossPerContract)
+}
+
+// historyATR считает ATR по последним закрытым свечам инструмента из графиков биржи
+func (r *RobotService) historyATR(params domain.Options) (float32, error) {
+	resolution := params.AtrResolution
+	if resolution == "" {
+		resolution = defaultAtrResolution
+	}
+	dur, ok := resolutionDurations[resolution]
+	if !ok {
+		return 0, errors.New("unknown atr resolution: " + resolution)
+	}
+	// Текущая свеча еще не закрыта и не учитывается, для первой нужна цена закрытия предыдущей
+	to := time.Now().Truncate(dur)
+	from := to.Add(-time.Duration(params.AtrPeriod+1) * dur)
+	candles, err := r.repo.GetCandles(params.Ticker, resolution, from, to, chartsAddr)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := atr(candles, params.AtrPeriod)
+	if !ok {
+		return 0, errors.New("not enough candles to calculate ATR")
+	}
+	return value, nil
+}
+
+// atr - средний истинный диапазон за последние period свечей:
+// max(high-low, |high-prevClose|, |low-prevClose|). Нужна еще одна свеча перед ними
+func atr(candles []domain.Candle, period int) (float32, bool) {
+	if period <= 0 || len(candles) < period+1 {
+		return 0, false
+	}
+	candles = candles[len(candles)-period-1:]
+	var sum float64
+	for i := 1; i < len(candles); i++ {
+		c, prevClose := candles[i], float64(candles[i-1].Close)
+		tr := math.Max(float64(c.High-c.Low), math.Max(math.Abs(float64(c.High)-prevClose), math.Abs(float64(c.Low)-prevClose)))
+		sum += tr
+	}
+	return float32(sum / float64(period)), true
+}
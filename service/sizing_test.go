@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionSize(t *testing.T) {
+	inverse := domain.Instrument{Symbol: "PI_XBTUSD", Type: "futures_inverse", ContractSize: 1, MinOrderSize: 1}
+	linear := domain.Instrument{Symbol: "PF_ETHUSD", Type: "flexible_futures", ContractSize: 1, MinOrderSize: 1}
+	// Test Table
+	type mockBehavior func(r *mock_service.MockrepoInterface)
+	type Test struct {
+		Name         string
+		Params       domain.Options
+		Inst         domain.Instrument
+		Prices       []float32
+		mockBehavior mockBehavior
+		Expect       int
+		ExpectErr    bool
+	}
+	tests := [...]Test{
+		{Name: "Contracts", Params: domain.Options{Size: 3}, Inst: inverse, Expect: 3},
+		{Name: "Notional inverse", Params: domain.Options{Sizing: domain.Sizing{SizeMode: "notional", Notional: 250}}, Inst: inverse, Prices: []float32{50000}, Expect: 250},
+		{Name: "Notional linear", Params: domain.Options{Sizing: domain.Sizing{SizeMode: "notional", Notional: 10000}}, Inst: linear, Prices: []float32{2000}, Expect: 5},
+		{
+			Name:   "Equity",
+			Params: domain.Options{Sizing: domain.Sizing{SizeMode: "equity", EquityPercent: 10}},
+			Inst:   inverse,
+			Prices: []float32{50000},
+			mockBehavior: func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().GetAvailableMargin(accountsAddr).Return(float32(5000), nil)
+			},
+			Expect: 500,
+		},
+		{
+			Name:   "Equity account error",
+			Params: domain.Options{Sizing: domain.Sizing{SizeMode: "equity", EquityPercent: 10}},
+			Inst:   inverse,
+			Prices: []float32{50000},
+			mockBehavior: func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().GetAvailableMargin(accountsAddr).Return(float32(0), errors.New("error"))
+			},
+			ExpectErr: true,
+		},
+		{Name: "Risk by stop distance", Params: domain.Options{Profit: 1, Sizing: domain.Sizing{SizeMode: "risk", Risk: 100}}, Inst: linear, Prices: []float32{2000}, Expect: 5},
+		{
+			Name:   "Risk by ATR",
+			Params: domain.Options{Ticker: "PF_ETHUSD", Profit: 1, Sizing: domain.Sizing{SizeMode: "risk", Risk: 100, AtrPeriod: 2, AtrResolution: "4h"}},
+			Inst:   linear,
+			Prices: []float32{2000},
+			mockBehavior: func(r *mock_service.MockrepoInterface) {
+				// Истинные диапазоны 10 и 30, ATR = 20
+				r.EXPECT().GetCandles("PF_ETHUSD", "4h", gomock.Any(), gomock.Any(), chartsAddr).DoAndReturn(
+					func(_, _ string, from, to time.Time, _ string) ([]domain.Candle, error) {
+						assert.Equal(t, 12*time.Hour, to.Sub(from))
+						return []domain.Candle{{Close: 2000}, {High: 2005, Low: 1995, Close: 2000}, {High: 2030, Low: 2010, Close: 2020}}, nil
+					})
+			},
+			Expect: 5,
+		},
+		{
+			Name:   "Not enough candles for ATR",
+			Params: domain.Options{Ticker: "PF_ETHUSD", Profit: 1, Sizing: domain.Sizing{SizeMode: "risk", Risk: 100, AtrPeriod: 14}},
+			Inst:   linear,
+			Prices: []float32{2000},
+			mockBehavior: func(r *mock_service.MockrepoInterface) {
+				r.EXPECT().GetCandles("PF_ETHUSD", "1h", gomock.Any(), gomock.Any(), chartsAddr).Return([]domain.Candle{{Close: 2000}}, nil)
+			},
+			ExpectErr: true,
+		},
+		{Name: "Size is too small", Params: domain.Options{Sizing: domain.Sizing{SizeMode: "notional", Notional: 100}}, Inst: linear, Prices: []float32{2000}, ExpectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockrepoInterface(c)
+			if test.mockBehavior != nil {
+				test.mockBehavior(repo)
+			}
+			ch := make(chan domain.WsResponse, len(test.Prices))
+			for _, price := range test.Prices {
+				ch <- domain.WsResponse{Bid: price, Ask: price}
+			}
+
+			robot := &RobotService{repo: repo, log: log.New()}
+			got, err := robot.positionSize(test.Params, test.Inst, ch)
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expect, got)
+		})
+	}
+}
+
+func TestATR(t *testing.T) {
+	candles := []domain.Candle{
+		{High: 110, Low: 90, Close: 100},
+		{High: 104, Low: 98, Close: 102},  // high-low = 6
+		{High: 112, Low: 108, Close: 110}, // |high-prevClose| = 10
+		{High: 103, Low: 95, Close: 96},   // |low-prevClose| = 15
+	}
+	got, ok := atr(candles, 3)
+	assert.True(t, ok)
+	assert.InDelta(t, 31.0/3, got, 1e-5)
+	got, ok = atr(candles, 2)
+	assert.True(t, ok)
+	assert.InDelta(t, 12.5, got, 1e-5)
+	_, ok = atr(candles, 4)
+	assert.False(t, ok)
+}
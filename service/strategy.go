@@ -0,0 +1,117 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// strategy по закрытым свечам решает, нужно ли входить в рынок и в какую сторону
+type strategy interface {
+	// signal возвращает "buy", "sell" или пустую строку, если сигнала нет
+	signal(candles []domain.Candle) string
+}
+
+// newStrategy возвращает nil для стратегии по средней цене тиков, которая не использует свечи
+func newStrategy(params domain.Strategy) strategy {
+	switch params.Name {
+	case "bollinger":
+		return bollinger{period: params.Period, stdDev: params.StdDev}
+	case "donchian":
+		return donchian{period: params.Period}
+	}
+	return nil
+}
+
+// bollinger - возврат к среднему: покупка при закрытии ниже нижней полосы, продажа - выше верхней
+type bollinger struct {
+	period int
+	stdDev float32
+}
+
+func (b bollinger) signal(candles []domain.Candle) string {
+	if len(candles) < b.period {
+		return ""
+	}
+	lower, upper := bollingerBands(candles[len(candles)-b.period:], b.stdDev)
+	last := candles[len(candles)-1].Close
+	switch {
+	case last < lower:
+		return "buy"
+	case last > upper:
+		return "sell"
+	}
+	return ""
+}
+
+// bollingerBands - границы полос вокруг скользящей средней цен закрытия
+func bollingerBands(candles []domain.Candle, stdDev float32) (lower, upper float32) {
+	var sum float64
+	for _, c := range candles {
+		sum += float64(c.Close)
+	}
+	mean := sum / float64(len(candles))
+	var variance float64
+	for _, c := range candles {
+		variance += (float64(c.Close) - mean) * (float64(c.Close) - mean)
+	}
+	dev := math.Sqrt(variance/float64(len(candles))) * float64(stdDev)
+	return float32(mean - dev), float32(mean + dev)
+}
+
+// donchian - пробой канала: покупка при закрытии выше максимума предыдущих period свечей,
+// продажа - ниже их минимума
+type donchian struct {
+	period int
+}
+
+func (d donchian) signal(candles []domain.Candle) string {
+	if len(candles) < d.period+1 {
+		return ""
+	}
+	channel := candles[len(candles)-d.period-1 : len(candles)-1]
+	high, low := channel[0].High, channel[0].Low
+	for _, c := range channel[1:] {
+		if c.High > high {
+			high = c.High
+		}
+		if c.Low < low {
+			low = c.Low
+		}
+	}
+	last := candles[len(candles)-1].Close
+	switch {
+	case last > high:
+		return "buy"
+	case last < low:
+		return "sell"
+	}
+	return ""
+}
+
+func (r *RobotService) SetStrategy(params domain.Strategy) {
+	r.mu.Lock()
+	r.params.Strategy = params
+	r.mu.Unlock()
+}
+
+// waitSignal собирает свечи из тиков и ждет сигнала стратегии. Если направление сделки задано вручную,
+// сигналы в другую сторону пропускаются. Возвращает false, если робот остановлен или соединение закрыто
+func (r *RobotService) waitSignal(params domain.Options, strat strategy, series *candles, priceChan chan domain.WsResponse) (string, bool) {
+	for wsReturn := range priceChan {
+		if r.GetParams().Start != 1 {
+			return "", false
+		}
+		if !series.add(time.Now(), midPrice(wsReturn)) {
+			continue
+		}
+		side := strat.signal(series.closed)
+		if side == "" || (params.Side != "" && side != params.Side) {
+			continue
+		}
+		r.log.Infof("Strategy %s signal: %s\n", params.Name, side)
+		return side, true
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// series строит свечи с одинаковыми ценами open/high/low/close
+func series(closes ...float32) []domain.Candle {
+	res := make([]domain.Candle, len(closes))
+	for i, c := range closes {
+		res[i] = domain.Candle{Time: time.Unix(int64(i*60), 0), Open: c, High: c, Low: c, Close: c}
+	}
+	return res
+}
+
+func TestCandles(t *testing.T) {
+	c := newCandles(time.Minute, 2)
+	start := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.False(t, c.add(start, 100))
+	assert.False(t, c.add(start.Add(10*time.Second), 105))
+	assert.False(t, c.add(start.Add(20*time.Second), 95))
+	assert.False(t, c.add(start.Add(59*time.Second), 101))
+	assert.True(t, c.add(start.Add(61*time.Second), 102))
+	assert.Equal(t, []domain.Candle{{Time: start, Open: 100, High: 105, Low: 95, Close: 101}}, c.closed)
+
+	// Минуты без тиков пропускаются, хранятся только последние max свечей
+	assert.True(t, c.add(start.Add(5*time.Minute), 103))
+	assert.True(t, c.add(start.Add(6*time.Minute), 104))
+	assert.Len(t, c.closed, 2)
+	assert.Equal(t, float32(103), c.closed[1].Close)
+}
+
+func TestBollinger(t *testing.T) {
+	tests := [...]struct {
+		Name    string
+		Candles []domain.Candle
+		Expect  string
+	}{
+		{"Not enough candles", series(100, 100, 90), ""},
+		{"Close below lower band", series(100, 101, 99, 100, 101, 99, 100, 90), "buy"},
+		{"Close above upper band", series(100, 101, 99, 100, 101, 99, 100, 110), "sell"},
+		{"Close inside bands", series(100, 101, 99, 100, 101, 99, 100, 100.5), ""},
+	}
+	strat := newStrategy(domain.Strategy{Name: "bollinger", Period: 8, StdDev: 2})
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expect, strat.signal(test.Candles))
+		})
+	}
+
+	lower, upper := bollingerBands(series(2, 4, 4, 4, 5, 5, 7, 9), 2)
+	assert.InDelta(t, 1, lower, 0.0001)
+	assert.InDelta(t, 9, upper, 0.0001)
+}
+
+func TestDonchian(t *testing.T) {
+	tests := [...]struct {
+		Name    string
+		Candles []domain.Candle
+		Expect  string
+	}{
+		{"Not enough candles", series(100, 101, 102), ""},
+		{"Breakout up", series(100, 103, 98, 101, 104), "buy"},
+		{"Breakout down", series(100, 103, 98, 101, 97), "sell"},
+		{"Inside channel", series(100, 103, 98, 101, 103), ""},
+		{"Old candles are ignored", series(200, 100, 103, 98, 101, 104), "buy"},
+	}
+	strat := newStrategy(domain.Strategy{Name: "donchian", Period: 4})
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expect, strat.signal(test.Candles))
+		})
+	}
+
+	assert.Nil(t, newStrategy(domain.Strategy{}))
+	assert.Nil(t, newStrategy(domain.Strategy{Name: "midpoint"}))
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// ticksBuffer - сколько тиков ждет медленного подписчика. Если он не успевает их забирать, новые тики пропускаются
+const ticksBuffer = 16
+
+// Ticks рассылает тики подписчикам, например gRPC клиентам WatchTicks и потокам /api/stream.
+// На каждый инструмент открывается одно WS соединение, общее для всех его подписчиков,
+// соединение закрывается, когда уходит последний подписчик
+type Ticks struct {
+	repo  repoInterface
+	feeds map[string]*tickFeed
+	mu    sync.Mutex
+	dial  sync.Mutex
+}
+
+type tickFeed struct {
+	subs   map[chan domain.WsResponse]struct{}
+	cancel func()
+}
+
+func NewTicks(repo repoInterface) *Ticks {
+	return &Ticks{repo: repo, feeds: make(map[string]*tickFeed)}
+}
+
+// Subscribe подписывает на тики инструмента. Канал закрывается функцией отписки или при закрытии соединения с биржей
+func (t *Ticks) Subscribe(ticker string) (chan domain.WsResponse, func(), error) {
+	for {
+		feed, err := t.feed(ticker)
+		if err != nil {
+			return nil, nil, err
+		}
+		t.mu.Lock()
+		// Соединение могло закрыться, пока подписчик добавлялся, тогда открывается новое
+		if t.feeds[ticker] == feed {
+			ch := make(chan domain.WsResponse, ticksBuffer)
+			feed.subs[ch] = struct{}{}
+			t.mu.Unlock()
+			return ch, func() { t.unsubscribe(ticker, feed, ch) }, nil
+		}
+		t.mu.Unlock()
+	}
+}
+
+// feed возвращает соединение инструмента и открывает его, если его еще нет. Соединения открываются по одному,
+// но без mu, чтобы не задерживать рассылку тиков и отписку
+func (t *Ticks) feed(ticker string) (*tickFeed, error) {
+	t.dial.Lock()
+	defer t.dial.Unlock()
+	t.mu.Lock()
+	feed, ok := t.feeds[ticker]
+	t.mu.Unlock()
+	if ok {
+		return feed, nil
+	}
+	upstream, cancel, err := t.repo.SetWSConnection(wsAddr, ticker)
+	if err != nil {
+		return nil, err
+	}
+	feed = &tickFeed{subs: make(map[chan domain.WsResponse]struct{}), cancel: cancel}
+	t.mu.Lock()
+	t.feeds[ticker] = feed
+	t.mu.Unlock()
+	go t.run(ticker, feed, upstream)
+	return feed, nil
+}
+
+func (t *Ticks) unsubscribe(ticker string, feed *tickFeed, ch chan domain.WsResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := feed.subs[ch]; !ok {
+		return
+	}
+	delete(feed.subs, ch)
+	close(ch)
+	if len(feed.subs) == 0 && t.feeds[ticker] == feed {
+		delete(t.feeds, ticker)
+		feed.cancel()
+	}
+}
+
+// run передает тики соединения подписчикам, пока соединение не закроется
+func (t *Ticks) run(ticker string, feed *tickFeed, upstream chan domain.WsResponse) {
+	for tick := range upstream {
+		t.mu.Lock()
+		for ch := range feed.subs {
+			select {
+			case ch <- tick:
+			default:
+			}
+		}
+		t.mu.Unlock()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range feed.subs {
+		delete(feed.subs, ch)
+		close(ch)
+	}
+	if t.feeds[ticker] == feed {
+		delete(t.feeds, ticker)
+	}
+}
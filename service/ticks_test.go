@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicks(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	repo := mock_service.NewMockrepoInterface(c)
+
+	// Два подписчика одного инструмента получают тики из одного соединения
+	upstream := make(chan domain.WsResponse)
+	closed := make(chan struct{})
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").Return(upstream, func() { close(closed) }, nil)
+	ticks := NewTicks(repo)
+	first, cancelFirst, err := ticks.Subscribe("PI_XBTUSD")
+	assert.NoError(t, err)
+	second, cancelSecond, err := ticks.Subscribe("PI_XBTUSD")
+	assert.NoError(t, err)
+
+	tick := domain.WsResponse{ProductID: "PI_XBTUSD", Bid: 50000, Ask: 50001, Time: 1638316800000}
+	upstream <- tick
+	assert.Equal(t, tick, <-first)
+	assert.Equal(t, tick, <-second)
+
+	// Соединение закрывается, когда уходит последний подписчик
+	cancelFirst()
+	cancelFirst()
+	_, ok := <-first
+	assert.False(t, ok)
+	select {
+	case <-closed:
+		t.Fatal("feed closed while it has a subscriber")
+	default:
+	}
+	cancelSecond()
+	<-closed
+	close(upstream)
+
+	// Следующий подписчик открывает новое соединение, при его закрытии канал подписчика закрывается
+	upstream = make(chan domain.WsResponse)
+	repo.EXPECT().SetWSConnection(wsAddr, "PI_XBTUSD").Return(upstream, func() {}, nil)
+	third, cancelThird, err := ticks.Subscribe("PI_XBTUSD")
+	assert.NoError(t, err)
+	close(upstream)
+	_, ok = <-third
+	assert.False(t, ok)
+	cancelThird()
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+)
+
+// Revocations - отозванные токены API (после /logout или обновления). Токен хранится, пока не истечет,
+// после этого он отклоняется и так
+type Revocations struct {
+	ids map[string]time.Time // id токена -> время, когда он истекает
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+func NewRevocations() *Revocations {
+	return &Revocations{
+		ids: make(map[string]time.Time),
+		now: time.Now,
+	}
+}
+
+// add добавляет отзыв токена и возвращает false, если токен уже был отозван
+func (rv *Revocations) add(id string, expires time.Time) bool {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	now := rv.now()
+	for revokedID, exp := range rv.ids {
+		if now.After(exp) {
+			delete(rv.ids, revokedID)
+		}
+	}
+	if _, ok := rv.ids[id]; ok {
+		return false
+	}
+	rv.ids[id] = expires
+	return true
+}
+
+func (rv *Revocations) has(id string) bool {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	_, ok := rv.ids[id]
+	return ok
+}
+
+// RevokeToken отзывает токен до момента expires. Отзыв сохраняется в базе, чтобы пережить перезапуск.
+// Если токен уже был отозван, возвращается domain.ErrTokenRevoked
+func (r *RobotService) RevokeToken(id string, expires time.Time) error {
+	if !r.revoked.add(id, expires) {
+		return domain.ErrTokenRevoked
+	}
+	return r.repo.RevokeToken(context.Background(), id, expires)
+}
+
+func (r *RobotService) IsTokenRevoked(id string) bool {
+	return r.revoked.has(id)
+}
+
+// LoadRevokedTokens загружает из базы отзывы еще не истекших токенов, вызывается при старте
+func (r *RobotService) LoadRevokedTokens() error {
+	ids, err := r.repo.GetRevokedTokens(context.Background())
+	if err != nil {
+		return err
+	}
+	for id, expires := range ids {
+		r.revoked.add(id, expires)
+	}
+	return nil
+}
+
+func (r *RobotService) GetUser(login string) (domain.User, error) {
+	return r.repo.GetUser(context.Background(), login)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	mock_service "github.com/Marseek/tfs-go-hw/course/service/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevokeToken(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_service.NewMockrepoInterface(c)
+	expires := time.Now().Add(time.Hour)
+	repo.EXPECT().RevokeToken(gomock.Any(), "t1", expires).Return(nil)
+	robot := NewRobotService(repo, log.New())
+
+	assert.NoError(t, robot.RevokeToken("t1", expires))
+	assert.True(t, robot.IsTokenRevoked("t1"))
+	// Повторный отзыв не доходит до базы
+	assert.Equal(t, domain.ErrTokenRevoked, robot.RevokeToken("t1", expires))
+	assert.False(t, robot.IsTokenRevoked("t2"))
+}
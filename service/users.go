@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Marseek/tfs-go-hw/course/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// HashPassword возвращает bcrypt хэш пароля для хранения в базе
+func HashPassword(passwd string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(passwd), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// Authenticate проверяет логин и пароль. Для неизвестного пользователя пароль все равно сравнивается
+// с хэшем, чтобы по времени ответа нельзя было узнать, существует ли логин
+func (r *RobotService) Authenticate(login, passwd string) (domain.User, error) {
+	u, err := r.repo.GetUser(context.Background(), login)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return domain.User{}, err
+	}
+	hash := []byte(u.PasswordHash)
+	if err != nil {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+		})
+		hash = dummyHash
+	}
+	// CompareHashAndPassword сравнивает хэши за постоянное время
+	if bcrypt.CompareHashAndPassword(hash, []byte(passwd)) != nil || err != nil || u.Disabled {
+		return domain.User{}, domain.ErrBadCredentials
+	}
+	return u, nil
+}
+
+func (r *RobotService) ListUsers() ([]domain.User, error) {
+	return r.repo.ListUsers(context.Background())
+}
+
+func (r *RobotService) CreateUser(login, passwd, role string) error {
+	hash, err := HashPassword(passwd)
+	if err != nil {
+		return err
+	}
+	return r.repo.CreateUser(context.Background(), domain.User{Login: login, PasswordHash: hash, Role: role})
+}
+
+func (r *RobotService) SetUserDisabled(login string, disabled bool) error {
+	return r.repo.SetUserDisabled(context.Background(), login, disabled)
+}
+
+func (r *RobotService) SetUserRole(login, role string) error {
+	return r.repo.SetUserRole(context.Background(), login, role)
+}
+
+func (r *RobotService) ResetPassword(login, passwd string) error {
+	hash, err := HashPassword(passwd)
+	if err != nil {
+		return err
+	}
+	return r.repo.SetUserPassword(context.Background(), login, hash)
+}